@@ -1,14 +1,25 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 
 	"backend-ai-sdlc/internal/api"
+	"backend-ai-sdlc/internal/artifacts"
 	"backend-ai-sdlc/internal/claude"
+	"backend-ai-sdlc/internal/events"
+	"backend-ai-sdlc/internal/gocheck"
+	"backend-ai-sdlc/internal/pipeline"
+	"backend-ai-sdlc/internal/prompts"
+	"backend-ai-sdlc/internal/retention"
+	"backend-ai-sdlc/internal/search"
+	"backend-ai-sdlc/internal/security"
 	"backend-ai-sdlc/internal/storage"
+	"backend-ai-sdlc/internal/webhook"
+	"backend-ai-sdlc/internal/workspace"
 
 	"github.com/joho/godotenv"
 	"github.com/rs/cors"
@@ -21,8 +32,9 @@ func main() {
 		log.Fatal("Erro ao carregar o arquivo .env")
 	}
 
-	// Inicializa o armazenamento
-	store := storage.NewMemoryStorage()
+	// Inicializa o armazenamento, alimentando o índice de busca
+	searchIndex := search.NewMemoryIndex()
+	store := storage.NewIndexedStorage(storage.NewMemoryStorage(), searchIndex)
 
 	// Pega a chave API do ambiente
 	apiKey := os.Getenv("CLAUDE_API_KEY")
@@ -33,21 +45,154 @@ func main() {
 	// Inicializa o cliente Claude
 	claudeClient := claude.NewClient(apiKey)
 
+	// Inicializa o artifact store com as versões dos arquivos gerados
+	artifactDir := os.Getenv("ARTIFACT_DIR")
+	if artifactDir == "" {
+		artifactDir = "artifacts"
+	}
+	artifactStore, err := artifacts.NewStore(artifactDir)
+	if err != nil {
+		log.Fatalf("Erro ao inicializar o artifact store: %v", err)
+	}
+	artifactStore.OnRecord(searchIndex.IndexFile)
+	artifactStore.OnRemove(searchIndex.RemoveFiles)
+
+	// Inicializa os workspaces, um diretório por conversa
+	workspaceRoot := os.Getenv("WORKSPACE_ROOT")
+	if workspaceRoot == "" {
+		workspaceRoot = "workspaces"
+	}
+	workspaces, err := workspace.NewManager(workspaceRoot)
+	if err != nil {
+		log.Fatalf("Erro ao inicializar os workspaces: %v", err)
+	}
+
+	// Hub de eventos por conversa, lido pelas conexões WebSocket e pelos clientes SSE:
+	// guarda os últimos 1000 eventos de cada conversa, para retomar o stream após uma
+	// reconexão, e 256 eventos por cliente antes de desconectá-lo
+	eventHub := events.NewHub(1000, 256)
+
+	// Inicia o janitor que aplica a política de retenção
+	policy, err := retention.PolicyFromEnv()
+	if err != nil {
+		log.Fatalf("Erro na política de retenção: %v", err)
+	}
+	// Locks das conversas em uso, compartilhados pelo engine, pelo janitor e
+	// pelas rotas que alteram conversas fora do chat
+	locks := &storage.Locks{}
+	janitor := retention.NewJanitor(policy, store, workspaces, artifactStore, locks)
+	janitor.OnRemove(eventHub.Remove)
+	go janitor.Run(context.Background())
+
+	// Carrega o pipeline de SDLC, do arquivo indicado em PIPELINE_FILE ou o padrão embutido
+	var def *pipeline.Definition
+	if pipelineFile := os.Getenv("PIPELINE_FILE"); pipelineFile != "" {
+		def, err = pipeline.Load(pipelineFile)
+	} else {
+		def, err = pipeline.Default()
+	}
+	if err != nil {
+		log.Fatalf("Erro ao carregar o pipeline: %v", err)
+	}
+	engine := api.NewEngine(def, store, claudeClient, artifactStore)
+	engine.SetLocks(locks)
+
+	// Verificação dos projetos Go gerados (GO_CHECK_BUILD habilita go build/vet)
+	goCheckOptions, err := gocheck.OptionsFromEnv()
+	if err != nil {
+		log.Fatalf("Erro nas opções de verificação Go: %v", err)
+	}
+	engine.SetGoCheckOptions(goCheckOptions)
+
+	// Templates de prompt externos em PROMPTS_DIR, recarregados quando os arquivos mudam.
+	// Um diretório vazio recebe os prompts embutidos no pipeline para servir de ponto de partida.
+	var promptStore *prompts.Store
+	if promptsDir := os.Getenv("PROMPTS_DIR"); promptsDir != "" {
+		seeded, err := prompts.Seed(promptsDir, def.Prompts(), def.PromptVersion())
+		if err != nil {
+			log.Fatalf("Erro ao criar o diretório de prompts: %v", err)
+		}
+		if seeded {
+			log.Printf("Wrote the built-in prompt templates to %s", promptsDir)
+		}
+		promptStore, err = prompts.Open(promptsDir, def.CheckPrompt)
+		if err != nil {
+			log.Fatalf("Erro ao carregar os templates de prompt: %v", err)
+		}
+		reloadInterval, err := prompts.ReloadIntervalFromEnv()
+		if err != nil {
+			log.Fatalf("Erro no intervalo de recarga dos prompts: %v", err)
+		}
+		engine.SetPrompts(promptStore)
+		go promptStore.Watch(reloadInterval, nil)
+		log.Printf("Loaded %d prompt templates from %s", len(promptStore.List()), promptsDir)
+	}
+
+	engine.SetEvents(eventHub)
+
+	// Webhooks de saída assinados. WEBHOOK_URL registra um assinante global para
+	// todos os eventos, assinado com WEBHOOK_SECRET.
+	webhookOptions, err := webhook.OptionsFromEnv()
+	if err != nil {
+		log.Fatalf("Erro nas opções de webhook: %v", err)
+	}
+	dispatcher := webhook.NewDispatcher(webhookOptions)
+	if webhookURL := os.Getenv("WEBHOOK_URL"); webhookURL != "" {
+		secret := os.Getenv("WEBHOOK_SECRET")
+		if secret == "" {
+			log.Fatal("WEBHOOK_SECRET não está definida")
+		}
+		if _, err := dispatcher.Subscribe(webhook.Subscriber{URL: webhookURL, Secret: secret}); err != nil {
+			log.Fatalf("Erro ao registrar o webhook: %v", err)
+		}
+	}
+	engine.SetWebhooks(dispatcher)
+	// As rotas /webhooks exigem WEBHOOK_ADMIN_TOKEN; sem ele, os assinantes só vêm de WEBHOOK_URL
+	webhookAdminToken := os.Getenv("WEBHOOK_ADMIN_TOKEN")
+	go dispatcher.Run(context.Background())
+
+	// Política de segurança (SECURITY_BLOCK_SEVERITY bloqueia downloads com achados graves)
+	securityPolicy, err := security.PolicyFromEnv()
+	if err != nil {
+		log.Fatalf("Erro na política de segurança: %v", err)
+	}
+
 	// Configura o CORS
 	c := cors.New(cors.Options{
 		AllowedOrigins:   []string{"http://localhost:3000"}, // Porta correta do frontend
 		AllowedMethods:   []string{"GET", "POST", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Content-Type", "X-Requested-With"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-Requested-With"},
 		AllowCredentials: true, // Permitir credenciais, se necessário
 		Debug:            true,
 	})
 
 	// Configura os handlers
 	mux := http.NewServeMux()
-	mux.HandleFunc("/chat", api.NewChatHandler(store, claudeClient))
+	mux.HandleFunc("/chat", api.NewChatHandler(store, engine, workspaces))
 	mux.HandleFunc("/messages", api.GetMessagesHandler(store))
-	mux.HandleFunc("/readFile", api.ReadFileContentHandler)
-	mux.HandleFunc("/downloadProject", api.DownloadProjectHandler) // Nova rota
+	mux.HandleFunc("/readFile", api.ReadFileContentHandler(store))
+	mux.HandleFunc("/downloadProject", api.DownloadProjectHandler(store, securityPolicy)) // Nova rota
+	mux.HandleFunc("/conversations/export", api.ExportConversationHandler(store, securityPolicy))
+	mux.HandleFunc("/conversations/import", api.ImportConversationHandler(store, artifactStore, workspaces, locks))
+	mux.HandleFunc("/conversations/pin", api.PinConversationHandler(store, locks))
+	mux.HandleFunc("GET /conversations/{id}/events", api.ConversationEventsHandler(eventHub, store))
+	mux.HandleFunc("/artifacts/versions", api.ArtifactVersionsHandler(artifactStore))
+	mux.HandleFunc("/artifacts/diff", api.ArtifactDiffHandler(artifactStore))
+	mux.HandleFunc("/artifacts/blob", api.ArtifactBlobHandler(artifactStore))
+	mux.HandleFunc("/artifacts/gc", api.ArtifactGCHandler(artifactStore))
+	mux.HandleFunc("/search", api.SearchHandler(searchIndex))
+	mux.HandleFunc("/review/findings", api.ReviewFindingsHandler(store))
+	mux.HandleFunc("/review/apply", api.ApplyFindingHandler(store, artifactStore, locks))
+	mux.HandleFunc("/security/findings", api.SecurityFindingsHandler(store, securityPolicy))
+	mux.HandleFunc("/git/commits", api.GitCommitsHandler(store))
+	mux.HandleFunc("/git/diff", api.GitDiffHandler(store))
+	mux.HandleFunc("/git/checkout", api.GitCheckoutHandler(store, artifactStore, locks))
+	mux.HandleFunc("/prompts", api.PromptsHandler(def, promptStore))
+	mux.HandleFunc("/webhooks", api.RequireAdminToken(webhookAdminToken, api.WebhooksHandler(dispatcher)))
+	mux.HandleFunc("/webhooks/unsubscribe", api.RequireAdminToken(webhookAdminToken, api.UnsubscribeWebhookHandler(dispatcher)))
+	mux.HandleFunc("/webhooks/deliveries", api.RequireAdminToken(webhookAdminToken, api.WebhookDeliveriesHandler(dispatcher)))
+	mux.HandleFunc("/webhooks/dead-letters", api.RequireAdminToken(webhookAdminToken, api.WebhookDeadLettersHandler(dispatcher)))
+	mux.HandleFunc("/webhooks/redeliver", api.RequireAdminToken(webhookAdminToken, api.WebhookRedeliverHandler(dispatcher)))
 
 	// Aplica o middleware CORS
 	handler := c.Handler(mux)
@@ -2,9 +2,10 @@ module backend-ai-sdlc
 
 go 1.23.0
 
-require github.com/rs/cors v1.11.1
-
 require (
-	github.com/gorilla/websocket v1.5.3 // indirect
-	github.com/joho/godotenv v1.5.1 // indirect
+	github.com/gorilla/websocket v1.5.3
+	github.com/joho/godotenv v1.5.1
+	github.com/rs/cors v1.11.1
+	golang.org/x/mod v0.22.0
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"backend-ai-sdlc/internal/artifacts"
+)
+
+type ArtifactVersionsResponse struct {
+	ConversationID string                         `json:"conversation_id"`
+	Files          map[string][]artifacts.Version `json:"files"`
+}
+
+// ArtifactVersionsHandler lista as versões de um arquivo, ou de todos os arquivos
+// da conversa quando path não é informado
+func ArtifactVersionsHandler(artifactStore *artifacts.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		conversationID := r.URL.Query().Get("conversation_id")
+		if conversationID == "" {
+			http.Error(w, "Missing conversation_id", http.StatusBadRequest)
+			return
+		}
+
+		index, err := artifactStore.Index(conversationID)
+		if err != nil {
+			log.Printf("Error loading artifact index: %v", err)
+			http.Error(w, "Error loading artifact index", http.StatusInternalServerError)
+			return
+		}
+
+		files := index.Files
+		if filePath := r.URL.Query().Get("path"); filePath != "" {
+			filePath = artifacts.NormalizePath(filePath)
+			files = map[string][]artifacts.Version{filePath: index.Files[filePath]}
+		}
+
+		sendJSONResponse(w, ArtifactVersionsResponse{
+			ConversationID: conversationID,
+			Files:          files,
+		})
+	}
+}
+
+// ArtifactDiffHandler devolve o diff unificado entre duas versões de um arquivo
+func ArtifactDiffHandler(artifactStore *artifacts.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		query := r.URL.Query()
+		conversationID := query.Get("conversation_id")
+		filePath := query.Get("path")
+		from, errFrom := strconv.Atoi(query.Get("from"))
+		to, errTo := strconv.Atoi(query.Get("to"))
+		if conversationID == "" || filePath == "" || errFrom != nil || errTo != nil {
+			http.Error(w, "Missing or invalid conversation_id, path, from or to", http.StatusBadRequest)
+			return
+		}
+
+		diff, err := artifactStore.Diff(conversationID, filePath, from, to)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/x-diff; charset=utf-8")
+		w.Write([]byte(diff))
+	}
+}
+
+// ArtifactBlobHandler devolve o conteúdo de um blob pelo hash SHA-256
+func ArtifactBlobHandler(artifactStore *artifacts.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		content, err := artifactStore.Get(r.URL.Query().Get("hash"))
+		if err != nil {
+			http.Error(w, "Blob not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write(content)
+	}
+}
+
+// ArtifactGCHandler remove os blobs que não são mais referenciados
+func ArtifactGCHandler(artifactStore *artifacts.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		result, err := artifactStore.GC()
+		if err != nil {
+			log.Printf("Error collecting artifacts: %v", err)
+			http.Error(w, "Error collecting artifacts", http.StatusInternalServerError)
+			return
+		}
+
+		log.Printf("Artifact GC removed %d blobs (%d bytes)", result.Removed, result.FreedBytes)
+		sendJSONResponse(w, result)
+	}
+}
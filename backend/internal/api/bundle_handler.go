@@ -0,0 +1,153 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"backend-ai-sdlc/internal/artifacts"
+	"backend-ai-sdlc/internal/bundle"
+	"backend-ai-sdlc/internal/security"
+	"backend-ai-sdlc/internal/storage"
+	"backend-ai-sdlc/internal/workspace"
+)
+
+// Tamanho máximo aceito para um bundle importado
+const maxBundleSize = 100 << 20
+
+type ImportResponse struct {
+	ConversationID string `json:"conversation_id"`
+	Steps          int    `json:"steps"`
+	Files          int    `json:"files"`
+	FormatVersion  int    `json:"format_version"`
+}
+
+// ExportConversationHandler devolve a conversa como um bundle ZIP portátil. A
+// política de segurança vale como no download do projeto.
+func ExportConversationHandler(store storage.Storage, policy security.Policy) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		conversationID := r.URL.Query().Get("conversation_id")
+		if conversationID == "" {
+			http.Error(w, "Missing conversation_id", http.StatusBadRequest)
+			return
+		}
+
+		conv, exists := store.GetConversation(conversationID)
+		if !exists {
+			http.Error(w, "Conversation not found", http.StatusNotFound)
+			return
+		}
+		if securityBlocked(w, policy, conv.SecurityIssues, "Export of conversation "+conversationID) {
+			return
+		}
+
+		buf := new(bytes.Buffer)
+		if err := bundle.Export(buf, conv); err != nil {
+			log.Printf("Error exporting conversation %s: %v", conversationID, err)
+			http.Error(w, "Error exporting conversation", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=conversation-%s.zip", conversationID))
+		w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+		if _, err := buf.WriteTo(w); err != nil {
+			log.Printf("Error writing bundle to response: %v", err)
+			return
+		}
+
+		log.Printf("Exported conversation %s", conversationID)
+	}
+}
+
+// ImportConversationHandler restaura um bundle no storage e no workspace da conversa.
+// O parâmetro opcional conversation_id importa a conversa com outro ID; se esse
+// ID já estiver em uso a importação é recusada com 409.
+func ImportConversationHandler(store storage.Storage, artifactStore *artifacts.Store, workspaces *workspace.Manager, locks *storage.Locks) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		data, err := io.ReadAll(io.LimitReader(r.Body, maxBundleSize+1))
+		if err != nil {
+			http.Error(w, "Error reading request body", http.StatusBadRequest)
+			return
+		}
+		if len(data) > maxBundleSize {
+			http.Error(w, "Bundle too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		b, err := bundle.Import(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			log.Printf("Error importing bundle: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		conv := b.Conversation
+		requestedID := r.URL.Query().Get("conversation_id")
+		if requestedID != "" {
+			conv.ID = requestedID
+		}
+		if conv.ID == "" {
+			http.Error(w, "Bundle has no conversation ID", http.StatusBadRequest)
+			return
+		}
+		// Um bundle nunca sobrescreve uma conversa existente: sem ID explícito a
+		// cópia importada recebe um ID novo
+		if _, exists := store.GetConversation(conv.ID); exists && requestedID == "" {
+			conv.ID = workspace.NewID()
+		}
+
+		// Os arquivos são restaurados no workspace deste ambiente
+		conv.Workspace, err = workspaces.Path(conv.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !lockConversation(w, locks, conv.ID) {
+			return
+		}
+		defer locks.Unlock(conv.ID)
+		if _, exists := store.GetConversation(conv.ID); exists {
+			http.Error(w, "Conversation already exists", http.StatusConflict)
+			return
+		}
+		if len(b.Files) > 0 {
+			for filePath, content := range b.Files {
+				if err := saveFileToDisk(conv.Workspace, filePath, string(content)); err != nil {
+					log.Printf("Error restoring file %s: %v", filePath, err)
+					http.Error(w, "Error restoring workspace files", http.StatusInternalServerError)
+					return
+				}
+				scanFile(conv, filePath, string(content))
+				if _, err := artifactStore.Record(conv.ID, filePath, len(conv.Steps), content); err != nil {
+					log.Printf("Error recording artifact for %s: %v", filePath, err)
+				}
+			}
+		}
+
+		commitWorkspace(conv, fmt.Sprintf("Import conversation bundle (format version %d)", b.Manifest.FormatVersion))
+
+		store.UpdateConversation(conv)
+		log.Printf("Imported conversation %s (format version %d, %d files)", conv.ID, b.Manifest.FormatVersion, len(b.Files))
+
+		sendJSONResponse(w, ImportResponse{
+			ConversationID: conv.ID,
+			Steps:          len(conv.Steps),
+			Files:          len(b.Files),
+			FormatVersion:  b.Manifest.FormatVersion,
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"backend-ai-sdlc/internal/artifacts"
+	"backend-ai-sdlc/internal/bundle"
+	"backend-ai-sdlc/internal/models"
+	"backend-ai-sdlc/internal/storage"
+	"backend-ai-sdlc/internal/workspace"
+)
+
+func TestImportDoesNotOverwrite(t *testing.T) {
+	root := t.TempDir()
+	workspaces, err := workspace.NewManager(filepath.Join(root, "workspaces"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	artifactStore, err := artifacts.NewStore(filepath.Join(root, "artifacts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := storage.NewMemoryStorage()
+	store.UpdateConversation(&models.Conversation{ID: "conv", Phase: "original"})
+
+	var buf bytes.Buffer
+	if err := bundle.Export(&buf, &models.Conversation{ID: "conv", Phase: "imported"}); err != nil {
+		t.Fatal(err)
+	}
+	handler := ImportConversationHandler(store, artifactStore, workspaces, &storage.Locks{})
+
+	tests := []struct {
+		name  string
+		query string
+		want  int
+	}{
+		{"existing id gets a fresh id", "", http.StatusOK},
+		{"explicit id in use", "?conversation_id=conv", http.StatusConflict},
+		{"explicit free id", "?conversation_id=copy", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/conversations/import"+tt.query, bytes.NewReader(buf.Bytes()))
+			handler(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+			if rec.Code == http.StatusOK {
+				var resp ImportResponse
+				if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+					t.Fatal(err)
+				}
+				if resp.ConversationID == "conv" {
+					t.Errorf("import reused the existing conversation ID")
+				}
+			}
+			if original, _ := store.GetConversation("conv"); original.Phase != "original" {
+				t.Errorf("existing conversation was overwritten: phase %q", original.Phase)
+			}
+		})
+	}
+}
@@ -0,0 +1,337 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"backend-ai-sdlc/internal/artifacts"
+	"backend-ai-sdlc/internal/models"
+	"backend-ai-sdlc/internal/patch"
+	"backend-ai-sdlc/internal/pipeline"
+	"backend-ai-sdlc/internal/structure"
+	"backend-ai-sdlc/internal/workspace"
+)
+
+const (
+	// maxChangeFileSize é o maior arquivo enviado ao Claude como contexto de uma mudança
+	maxChangeFileSize = 64 * 1024
+	// maxChangeContext limita o total de conteúdo dos arquivos no prompt da mudança
+	maxChangeContext = 400 * 1024
+)
+
+// changeConflictError indica que o workspace mudou depois que a mudança foi proposta
+type changeConflictError struct {
+	path string
+}
+
+func (e *changeConflictError) Error() string {
+	return fmt.Sprintf("%s changed after the change was proposed", e.path)
+}
+
+// proposeChange pede ao Claude as mudanças nos arquivos atuais como um diff
+// unificado. O diff é aplicado em memória; os arquivos em que ele não se aplica
+// são regenerados inteiros. O resultado fica pendente até a confirmação.
+// Uma resposta sem diff é uma resposta comum a uma pergunta.
+func (e *Engine) proposeChange(conv *models.Conversation, phase *pipeline.Phase, request string, conn *eventConn) (string, error) {
+	step := len(conv.Steps) + 1
+	data := e.promptData(conv, phase, request, "")
+	files, err := changeContext(conv.Workspace)
+	if err != nil {
+		return "", err
+	}
+	data.Files = files
+
+	prompt, err := e.render(phase, pipeline.PromptMain, data)
+	if err != nil {
+		return "", err
+	}
+	response, err := e.askClaude(conv, step, "", prompt)
+	if err != nil {
+		return "", fmt.Errorf("error getting response from Claude: %v", err)
+	}
+	conv.Outputs[phase.ID] = response
+
+	if !patch.Contains(response) {
+		return response, nil
+	}
+
+	diffs, err := patch.Parse(response)
+	if err != nil {
+		return "", err
+	}
+
+	sendWebSocketMessage(conn, "status_update", "Checking the proposed changes...")
+	change := &models.ChangeSet{Request: request}
+	index := make(map[string]int)
+	for _, diff := range diffs {
+		rel := diff.Path()
+		// Um caminho fora do projeto nunca é lido, removido nem regenerado
+		if err := diff.CheckPaths(); err != nil {
+			log.Printf("Rejecting diff for %s: %v", rel, err)
+			sendWebSocketMessage(conn, "status_update", fmt.Sprintf("Ignoring the diff for %s: %v", rel, err))
+			continue
+		}
+		i, seen := index[rel]
+		if !seen {
+			base, exists, err := readWorkspaceFile(conv.Workspace, rel)
+			if err != nil {
+				return "", err
+			}
+			change.Files = append(change.Files, models.FileChange{Path: rel, Base: base, Content: base, Created: !exists})
+			i = len(change.Files) - 1
+			index[rel] = i
+		}
+		file := &change.Files[i]
+
+		if diff.Deleted() {
+			if !file.Created {
+				file.Deleted, file.Content = true, ""
+			}
+			continue
+		}
+
+		// O diff é testado contra o conteúdo atual antes de qualquer escrita
+		current := file.Content
+		if diff.Created() && !file.Created {
+			err = fmt.Errorf("%s already exists", rel)
+		} else if !diff.Created() && file.Created {
+			err = fmt.Errorf("%s does not exist", rel)
+		} else {
+			file.Content, err = diff.Apply(current)
+		}
+		if err == nil {
+			continue
+		}
+
+		log.Printf("Diff for %s does not apply, regenerating the file: %v", rel, err)
+		sendWebSocketMessage(conn, "status_update", fmt.Sprintf("Regenerating %s: %v", rel, err))
+		data := e.promptData(conv, phase, request, "/"+rel)
+		data.Source, data.Patch = current, diff.String()
+		filePrompt, err := e.render(phase, pipeline.PromptFile, data)
+		if err != nil {
+			return "", err
+		}
+		file.Content, err = e.askClaude(conv, step, "/"+rel, filePrompt)
+		if err != nil {
+			return "", fmt.Errorf("error getting response from Claude for file %s: %v", rel, err)
+		}
+		file.Regenerated = true
+	}
+
+	proposed := ChangeProposedMessage{ConversationID: conv.ID}
+	var summary []string
+	var changed []models.FileChange
+	for _, file := range change.Files {
+		if !file.Deleted && file.Content == file.Base && !file.Created {
+			continue
+		}
+		changed = append(changed, file)
+
+		from, to := "a/"+file.Path, "b/"+file.Path
+		if file.Created {
+			from = patch.DevNull
+		}
+		if file.Deleted {
+			to = patch.DevNull
+		}
+		proposed.Files = append(proposed.Files, ProposedChange{
+			Path:        file.Path,
+			Diff:        artifacts.UnifiedDiff(from, to, file.Base, file.Content),
+			Created:     file.Created,
+			Deleted:     file.Deleted,
+			Regenerated: file.Regenerated,
+		})
+		summary = append(summary, fmt.Sprintf("- %s (%s)", file.Path, changeKind(file)))
+	}
+	if len(changed) == 0 {
+		return "The proposed diff does not change any file.", nil
+	}
+
+	change.Files = changed
+	conv.PendingChange = change
+	sendWebSocketMessage(conn, "change_proposed", proposed)
+
+	return fmt.Sprintf("I prepared changes to %d files:\n%s", len(changed), strings.Join(summary, "\n")), nil
+}
+
+// confirmChange aplica a mudança pendente após um YES ou a descarta após um NO
+func (e *Engine) confirmChange(conv *models.Conversation, phase *pipeline.Phase, input pipeline.Input, conn *eventConn) (string, error) {
+	change := conv.PendingChange
+	next, message := phase.Next(input == pipeline.InputYes)
+
+	var applied string
+	if input == pipeline.InputYes && change != nil {
+		var err error
+		applied, err = e.applyChange(conv, change, conn)
+
+		var conflict *changeConflictError
+		if errors.As(err, &conflict) {
+			applied = fmt.Sprintf("The changes were not applied: %v. Please send the request again.", err)
+			message = ""
+		} else if err != nil {
+			return "", err
+		}
+	}
+
+	conv.PendingChange = nil
+	response, err := e.enterPhase(conv, input, next, conn)
+	if err != nil {
+		return "", err
+	}
+	return joinMessages(applied, message, response), nil
+}
+
+// applyChange grava todos os arquivos da mudança. Se o workspace mudou desde a
+// proposta nada é gravado, e uma falha no meio da gravação desfaz os arquivos
+// já gravados.
+func (e *Engine) applyChange(conv *models.Conversation, change *models.ChangeSet, conn *eventConn) (string, error) {
+	for _, file := range change.Files {
+		current, exists, err := readWorkspaceFile(conv.Workspace, file.Path)
+		if err != nil {
+			return "", err
+		}
+		if current != file.Base || exists == file.Created {
+			return "", &changeConflictError{path: file.Path}
+		}
+	}
+
+	step := len(conv.Steps) + 1
+	var created, deleted []string
+	for i, file := range change.Files {
+		err := e.applyFileChange(conv, step, file, conn)
+		if err != nil {
+			log.Printf("Error applying change to %s, rolling back: %v", file.Path, err)
+			for _, done := range change.Files[:i+1] {
+				e.restoreFileChange(conv, done)
+			}
+			return "", err
+		}
+		if file.Created {
+			created = append(created, file.Path)
+		}
+		if file.Deleted {
+			deleted = append(deleted, file.Path)
+		}
+	}
+	// Só depois que a mudança inteira foi aplicada, já que o rollback devolve os arquivos removidos
+	e.artifactStore.RecordRemoval(conv.ID, deleted)
+
+	if len(created) > 0 && conv.Structure != "" {
+		updated, err := structure.AddFiles(conv.Structure, created)
+		if err != nil {
+			log.Printf("Error adding new files to the structure: %v", err)
+		} else {
+			conv.Structure = updated
+			if tree, err := structure.Parse(updated); err == nil {
+				sendWebSocketMessage(conn, "project_structure", generateFileList(tree))
+			}
+		}
+	}
+
+	e.store.UpdateConversation(conv)
+	return fmt.Sprintf("Applied the changes to %d files.", len(change.Files)), nil
+}
+
+func (e *Engine) applyFileChange(conv *models.Conversation, step int, file models.FileChange, conn *eventConn) error {
+	if !file.Deleted {
+		if _, err := workspace.Join(conv.Workspace, file.Path); err != nil {
+			return err
+		}
+		return e.writeFile(conv, step, "/"+file.Path, file.Content, conn)
+	}
+
+	fullPath, err := workspace.Join(conv.Workspace, file.Path)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(fullPath); err != nil {
+		return fmt.Errorf("error removing file: %v", err)
+	}
+	delete(conv.SecurityIssues, file.Path)
+	delete(conv.InvalidFiles, "/"+file.Path)
+	sendWebSocketMessage(conn, "file_removed", models.FileContent{Path: "/" + file.Path})
+	return nil
+}
+
+// restoreFileChange devolve o arquivo ao conteúdo que tinha antes da mudança
+func (e *Engine) restoreFileChange(conv *models.Conversation, file models.FileChange) {
+	fullPath, err := workspace.Join(conv.Workspace, file.Path)
+	if err != nil {
+		log.Printf("Error rolling back %s: %v", file.Path, err)
+		return
+	}
+	if file.Created {
+		if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+			log.Printf("Error rolling back %s: %v", file.Path, err)
+		}
+		delete(conv.SecurityIssues, file.Path)
+		e.artifactStore.RecordRemoval(conv.ID, []string{file.Path})
+		return
+	}
+	if err := saveFileToDisk(conv.Workspace, file.Path, file.Base); err != nil {
+		log.Printf("Error rolling back %s: %v", file.Path, err)
+	}
+	scanFile(conv, file.Path, file.Base)
+}
+
+// changeContext monta o conteúdo atual dos arquivos de texto do workspace para o prompt
+func changeContext(dir string) (string, error) {
+	files, err := workspace.Files(dir)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	var omitted []string
+	for _, file := range files {
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(file.Path)))
+		if err != nil {
+			return "", err
+		}
+		if bytes.IndexByte(content, 0) >= 0 {
+			continue
+		}
+		if len(content) > maxChangeFileSize || sb.Len()+len(content) > maxChangeContext {
+			omitted = append(omitted, file.Path)
+			continue
+		}
+		fmt.Fprintf(&sb, "File: %s\n```\n%s\n```\n\n", file.Path, strings.TrimSuffix(string(content), "\n"))
+	}
+	if len(omitted) > 0 {
+		fmt.Fprintf(&sb, "Files omitted because of their size: %s\n", strings.Join(omitted, ", "))
+	}
+	return sb.String(), nil
+}
+
+// readWorkspaceFile lê um arquivo do workspace; um arquivo inexistente volta vazio
+func readWorkspaceFile(dir, rel string) (string, bool, error) {
+	fullPath, err := workspace.Join(dir, rel)
+	if err != nil {
+		return "", false, err
+	}
+	content, err := os.ReadFile(fullPath)
+	if os.IsNotExist(err) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("error reading %s: %v", rel, err)
+	}
+	return string(content), true, nil
+}
+
+func changeKind(file models.FileChange) string {
+	switch {
+	case file.Deleted:
+		return "deleted"
+	case file.Created:
+		return "created"
+	case file.Regenerated:
+		return "regenerated"
+	}
+	return "modified"
+}
@@ -0,0 +1,1182 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"backend-ai-sdlc/internal/artifacts"
+	"backend-ai-sdlc/internal/ci"
+	"backend-ai-sdlc/internal/claude"
+	"backend-ai-sdlc/internal/documents"
+	"backend-ai-sdlc/internal/events"
+	"backend-ai-sdlc/internal/gocheck"
+	"backend-ai-sdlc/internal/middleware"
+	"backend-ai-sdlc/internal/models"
+	"backend-ai-sdlc/internal/multifile"
+	"backend-ai-sdlc/internal/pipeline"
+	"backend-ai-sdlc/internal/prompts"
+	"backend-ai-sdlc/internal/review"
+	"backend-ai-sdlc/internal/scaffold"
+	"backend-ai-sdlc/internal/storage"
+	"backend-ai-sdlc/internal/structure"
+	"backend-ai-sdlc/internal/testgen"
+	"backend-ai-sdlc/internal/validate"
+	"backend-ai-sdlc/internal/webhook"
+	"backend-ai-sdlc/internal/workspace"
+)
+
+// Tamanho do histórico de eventos por conversa e do buffer de cada assinante
+// do hub criado por NewEngine
+const (
+	defaultEventHistory = 1000
+	defaultEventBuffer  = 256
+)
+
+// maxRegenerations limita as novas tentativas de gerar um arquivo de configuração inválido
+const maxRegenerations = 2
+
+// Engine executa as fases do pipeline declarativo para cada mensagem do chat
+type Engine struct {
+	pipeline      *pipeline.Definition
+	store         storage.Storage
+	claudeClient  *claude.Client
+	artifactStore *artifacts.Store
+	goCheck       gocheck.Options
+	prompts       *prompts.Store
+	webhooks      *webhook.Dispatcher
+	events        *events.Hub
+	running       *storage.Locks
+}
+
+func NewEngine(def *pipeline.Definition, store storage.Storage, claudeClient *claude.Client, artifactStore *artifacts.Store) *Engine {
+	return &Engine{
+		pipeline:      def,
+		store:         store,
+		claudeClient:  claudeClient,
+		artifactStore: artifactStore,
+		events:        events.NewHub(defaultEventHistory, defaultEventBuffer),
+		running:       &storage.Locks{},
+	}
+}
+
+// SetGoCheckOptions define as opções da verificação dos arquivos Go gerados
+func (e *Engine) SetGoCheckOptions(opts gocheck.Options) {
+	e.goCheck = opts
+}
+
+// SetPrompts define o diretório de templates que substitui os prompts embutidos no pipeline
+func (e *Engine) SetPrompts(store *prompts.Store) {
+	e.prompts = store
+}
+
+// SetEvents define o hub em que as mensagens de cada conversa são publicadas
+func (e *Engine) SetEvents(hub *events.Hub) {
+	e.events = hub
+}
+
+// SetLocks define os locks de conversa compartilhados com o janitor, para que
+// uma conversa com um passo em execução não seja removida
+func (e *Engine) SetLocks(locks *storage.Locks) {
+	e.running = locks
+}
+
+// connFor cria a conexão de eventos de um passo da conversa
+func (e *Engine) connFor(conversationID string) *eventConn {
+	return &eventConn{hub: e.events, conversationID: conversationID}
+}
+
+// currentPhase retorna a fase em que a conversa está, começando pela fase inicial
+func (e *Engine) currentPhase(conv *models.Conversation) (*pipeline.Phase, error) {
+	if conv.Phase == "" && conv.State == models.StateComplete {
+		conv.Phase = e.pipeline.Followup
+	} else if conv.Phase == "" {
+		conv.Phase = e.pipeline.Start
+	}
+	phase, ok := e.pipeline.Phase(conv.Phase)
+	if !ok {
+		return nil, fmt.Errorf("conversation %s is in unknown phase %q", conv.ID, conv.Phase)
+	}
+	return phase, nil
+}
+
+// Handle processa uma mensagem do usuário e retorna a resposta do passo.
+// Mensagens que não são aceitas no estado atual retornam *pipeline.InvalidTransitionError.
+func (e *Engine) Handle(conv *models.Conversation, chatReq models.ChatRequest, conn *eventConn) (string, error) {
+	if conv.State == "" {
+		conv.State = models.StateAwaitingDescription
+	}
+	if conv.Outputs == nil {
+		conv.Outputs = make(map[string]string)
+	}
+
+	input := pipeline.InputFor(chatReq)
+	if err := e.pipeline.CheckInput(conv.State, input); err != nil {
+		return "", err
+	}
+
+	phase, err := e.currentPhase(conv)
+	if err != nil {
+		return "", err
+	}
+
+	if input == pipeline.InputMessage && conv.Description == "" {
+		conv.Description = chatReq.Message
+	}
+
+	switch {
+	case input == pipeline.InputMessage && conv.State == models.StateAwaitingFeedback:
+		return e.revisePhase(conv, phase, chatReq.Message, conn)
+	case input == pipeline.InputMessage:
+		return e.runPhase(conv, phase, input, chatReq.Message, conn)
+	case conv.State == models.StateAwaitingStart:
+		return e.startOptionalPhase(conv, phase, input, conn)
+	default:
+		return e.handleConfirmation(conv, phase, input, conn)
+	}
+}
+
+// setState aplica a transição validando-a pela tabela de transições
+func (e *Engine) setState(conv *models.Conversation, input pipeline.Input, to models.State) error {
+	if err := e.pipeline.Transition(conv.State, input, to); err != nil {
+		return err
+	}
+	log.Printf("Conversation %s: %s -> %s (%s, phase %s)", conv.ID, conv.State, to, input, conv.Phase)
+	conv.State = to
+	return nil
+}
+
+func (e *Engine) handleConfirmation(conv *models.Conversation, phase *pipeline.Phase, input pipeline.Input, conn *eventConn) (string, error) {
+	log.Printf("Handling confirmation for phase %s with answer: %s", phase.ID, input)
+
+	if phase.Output == pipeline.OutputPatch {
+		return e.confirmChange(conv, phase, input, conn)
+	}
+
+	next, message := phase.Next(input == pipeline.InputYes)
+
+	// Um NO em uma fase revisável pede o feedback do usuário para revisar a saída
+	if input == pipeline.InputNo && phase.Revisable() {
+		return message, e.setState(conv, input, models.StateAwaitingFeedback)
+	}
+
+	response, err := e.enterPhase(conv, input, next, conn)
+	if err != nil {
+		return "", err
+	}
+	return joinMessages(message, response), nil
+}
+
+// startOptionalPhase executa a fase opcional após um YES ou a pula após um NO
+func (e *Engine) startOptionalPhase(conv *models.Conversation, phase *pipeline.Phase, input pipeline.Input, conn *eventConn) (string, error) {
+	if input == pipeline.InputNo {
+		log.Printf("Skipping optional phase %s for conversation %s", phase.ID, conv.ID)
+		response, err := e.enterPhase(conv, input, phase.Transitions.Skip, conn)
+		if err != nil {
+			return "", err
+		}
+		return joinMessages(phase.Transitions.SkipMessage, response), nil
+	}
+
+	previousState := conv.State
+	response, err := e.beginPhase(conv, phase, input, conn)
+	if err != nil {
+		log.Printf("Phase %s failed, restoring conversation %s to %s", phase.ID, conv.ID, previousState)
+		conv.State = previousState
+		return "", err
+	}
+	return response, nil
+}
+
+// enterPhase leva a conversa para a fase indicada; uma fase vazia encerra o pipeline.
+// Fases opcionais aguardam o YES do usuário antes de começar.
+func (e *Engine) enterPhase(conv *models.Conversation, input pipeline.Input, phaseID string, conn *eventConn) (string, error) {
+	if phaseID == "" {
+		conv.Phase = e.pipeline.Followup
+		return "", e.setState(conv, input, models.StateComplete)
+	}
+
+	previousPhase, previousState := conv.Phase, conv.State
+	conv.Phase = phaseID
+	phase, err := e.currentPhase(conv)
+	if err != nil {
+		return "", err
+	}
+
+	if phase.Optional {
+		return phase.StartPrompt, e.setState(conv, input, models.StateAwaitingStart)
+	}
+
+	response, err := e.beginPhase(conv, phase, input, conn)
+	if err != nil {
+		// Volta ao estado anterior para que o usuário possa confirmar novamente
+		log.Printf("Phase %s failed, restoring conversation %s to %s", phase.ID, conv.ID, previousState)
+		conv.Phase, conv.State = previousPhase, previousState
+		return "", err
+	}
+	return response, nil
+}
+
+// beginPhase inicia a fase. Fases que não dependem de entrada do usuário são executadas imediatamente.
+func (e *Engine) beginPhase(conv *models.Conversation, phase *pipeline.Phase, input pipeline.Input, conn *eventConn) (string, error) {
+	switch {
+	case phase.Generates():
+		if err := e.setState(conv, input, models.StateGenerating); err != nil {
+			return "", err
+		}
+		return e.runPhase(conv, phase, pipeline.InputDone, "", conn)
+	case phase.Auto:
+		// Fases automáticas usam a descrição original do projeto como entrada
+		return e.runPhase(conv, phase, input, conv.Description, conn)
+	default:
+		return phase.InputPrompt, e.setState(conv, input, models.StateAwaitingDescription)
+	}
+}
+
+func (e *Engine) runPhase(conv *models.Conversation, phase *pipeline.Phase, input pipeline.Input, text string, conn *eventConn) (string, error) {
+	log.Printf("Running phase %s (%s) for conversation %s", phase.ID, phase.Output, conv.ID)
+
+	var response string
+	var err error
+	switch phase.Output {
+	case pipeline.OutputFileSet:
+		response, err = e.generateFiles(conv, phase, conn)
+	case pipeline.OutputTestSuite:
+		response, err = e.generateTests(conv, phase, conn)
+	case pipeline.OutputReview:
+		response, err = e.reviewFiles(conv, phase, conn)
+	case pipeline.OutputCI:
+		response, err = e.generateCI(conv, phase, text, conn)
+	case pipeline.OutputPatch:
+		response, err = e.proposeChange(conv, phase, text, conn)
+	default:
+		response, err = e.askPhase(conv, phase, text, conn)
+	}
+
+	// Uma entrada que a fase não entende é pedida de novo, sem sair da fase
+	var retry *retryInputError
+	if errors.As(err, &retry) {
+		return retry.Error(), e.setState(conv, input, models.StateAwaitingDescription)
+	}
+	if err == nil && phase.Fixable() {
+		err = e.compileAndFix(conv, phase, conn)
+	}
+	if err != nil {
+		return "", err
+	}
+	if phase.Generates() || phase.Output == pipeline.OutputCI {
+		e.publishGenerationFinished(conv, phase.ID)
+	}
+
+	// Uma mudança proposta aguarda o YES do usuário para ser aplicada
+	if phase.Output == pipeline.OutputPatch && conv.PendingChange != nil {
+		if phase.ConfirmationPrompt != "" {
+			sendWebSocketMessage(conn, "status_update", phase.ConfirmationPrompt)
+		}
+		return response, e.setState(conv, input, models.StateAwaitingReview)
+	}
+
+	if conv.State == models.StateComplete {
+		return response, e.setState(conv, input, models.StateComplete)
+	}
+
+	if phase.RequiresConfirmation {
+		to := models.StateStructureProposed
+		if phase.Generates() {
+			to = models.StateAwaitingReview
+		}
+		if err := e.setState(conv, input, to); err != nil {
+			return "", err
+		}
+		if phase.ConfirmationPrompt != "" {
+			sendWebSocketMessage(conn, "status_update", phase.ConfirmationPrompt)
+		}
+		return response, nil
+	}
+
+	nextResponse, err := e.enterPhase(conv, input, phase.Transitions.Next, conn)
+	if err != nil {
+		return "", err
+	}
+	return joinMessages(response, nextResponse), nil
+}
+
+// retryInputError pede ao usuário que responda de novo à fase atual
+type retryInputError struct {
+	message string
+}
+
+func (e *retryInputError) Error() string {
+	return e.message
+}
+
+// joinMessages junta as mensagens não vazias em uma única resposta
+func joinMessages(messages ...string) string {
+	var parts []string
+	for _, message := range messages {
+		if message != "" {
+			parts = append(parts, message)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// revisePhase envia ao Claude a saída atual da fase junto com o feedback do
+// usuário e propõe a versão revisada, mostrando o que mudou na estrutura
+func (e *Engine) revisePhase(conv *models.Conversation, phase *pipeline.Phase, feedback string, conn *eventConn) (string, error) {
+	log.Printf("Revising phase %s for conversation %s", phase.ID, conv.ID)
+
+	previous := conv.Outputs[phase.ID]
+
+	// Um documento editado pelo próprio usuário é aceito sem passar pelo modelo
+	if kind, ok := documents.Lookup(phase.Document); ok && kind.Apply(conv, feedback) == nil {
+		log.Printf("Accepted edited %s document for conversation %s", phase.Document, conv.ID)
+		conv.Revisions++
+		conv.Outputs[phase.ID] = feedback
+		return feedback, e.setState(conv, pipeline.InputMessage, models.StateStructureProposed)
+	}
+
+	prompt, err := e.render(phase, pipeline.PromptRevision, e.promptData(conv, phase, feedback, ""))
+	if err != nil {
+		return "", err
+	}
+
+	response, err := e.askClaude(conv, len(conv.Steps)+1, "", prompt)
+	if err != nil {
+		return "", fmt.Errorf("error getting response from Claude: %v", err)
+	}
+	if err := e.applyDocument(conv, phase, response); err != nil {
+		return "", err
+	}
+
+	if phase.Output == pipeline.OutputJSONStructure {
+		response = e.extendTemplate(conv, phase, response)
+		diff, err := structure.CompareJSON(previous, response)
+		if err != nil {
+			return "", fmt.Errorf("revised structure is not valid: %v", err)
+		}
+		conv.Structure = response
+		sendWebSocketMessage(conn, "project_structure", response)
+		sendWebSocketMessage(conn, "structure_diff", StructureDiffMessage{
+			Revision: conv.Revisions + 1,
+			Diff:     diff,
+		})
+	}
+
+	conv.Revisions++
+	conv.Outputs[phase.ID] = response
+
+	if err := e.setState(conv, pipeline.InputMessage, models.StateStructureProposed); err != nil {
+		return "", err
+	}
+	return response, nil
+}
+
+// askPhase envia o prompt da fase ao Claude
+func (e *Engine) askPhase(conv *models.Conversation, phase *pipeline.Phase, input string, conn *eventConn) (string, error) {
+	step := len(conv.Steps) + 1
+	if phase.Scaffold {
+		e.pickTemplate(conv, conn)
+	}
+	prompt, err := e.render(phase, pipeline.PromptMain, e.promptData(conv, phase, input, ""))
+	if err != nil {
+		return "", err
+	}
+
+	response, err := e.askClaude(conv, step, "", prompt)
+	if err != nil {
+		return "", fmt.Errorf("error getting response from Claude: %v", err)
+	}
+	if err := e.applyDocument(conv, phase, response); err != nil {
+		return "", err
+	}
+	conv.Outputs[phase.ID] = response
+
+	// A estrutura JSON do projeto também é enviada para o frontend
+	if phase.Output == pipeline.OutputJSONStructure {
+		response = e.extendTemplate(conv, phase, response)
+		conv.Outputs[phase.ID] = response
+		conv.Structure = response
+		sendWebSocketMessage(conn, "project_structure", response)
+	}
+
+	return response, nil
+}
+
+// applyDocument valida e guarda na conversa o documento tipado produzido pela fase
+func (e *Engine) applyDocument(conv *models.Conversation, phase *pipeline.Phase, response string) error {
+	kind, ok := documents.Lookup(phase.Document)
+	if !ok {
+		return nil
+	}
+	return kind.Apply(conv, response)
+}
+
+func (e *Engine) promptData(conv *models.Conversation, phase *pipeline.Phase, input, filePath string) pipeline.PromptData {
+	return pipeline.PromptData{
+		Input:        input,
+		Description:  conv.Description,
+		Documents:    documents.Markdown(conv),
+		Previous:     conv.Outputs[phase.ID],
+		Step:         len(conv.Steps) + 1,
+		Time:         time.Now().Format(time.RFC3339),
+		Structure:    conv.Structure,
+		FilePath:     filePath,
+		AppName:      defaultAppName,
+		ContractFile: filePath != "" && documents.IsContractFile(filePath),
+		Template:     templateSummary(conv),
+		Tree:         structureTree(conv.Structure),
+		Stack:        stackSummary(conv),
+		PriorFiles:   priorFiles(conv.Workspace),
+	}
+}
+
+// structureTree lista os arquivos da estrutura, um por linha
+func structureTree(text string) string {
+	if text == "" {
+		return ""
+	}
+	tree, err := structure.Parse(text)
+	if err != nil {
+		return ""
+	}
+	return strings.Join(structure.Files(tree), "\n")
+}
+
+// stackSummary resume a stack da arquitetura ou, sem ela, a do template do projeto
+func stackSummary(conv *models.Conversation) string {
+	if conv.Architecture != nil {
+		stack := conv.Architecture.Stack
+		var lines []string
+		for _, item := range [][2]string{{"Backend", stack.Backend}, {"Frontend", stack.Frontend}, {"Database", stack.Database}} {
+			if item[1] != "" {
+				lines = append(lines, item[0]+": "+item[1])
+			}
+		}
+		if len(stack.Other) > 0 {
+			lines = append(lines, "Other: "+strings.Join(stack.Other, ", "))
+		}
+		return strings.Join(lines, "\n")
+	}
+	if t, ok := scaffold.Lookup(conv.Template); ok {
+		return t.Description
+	}
+	return ""
+}
+
+// priorFiles lista os arquivos já gravados no workspace, um por linha
+func priorFiles(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	files, err := workspace.Files(dir)
+	if err != nil {
+		return ""
+	}
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.Path
+	}
+	return strings.Join(paths, "\n")
+}
+
+// render monta o prompt da fase, preferindo o template do diretório de prompts
+// ao template embutido no pipeline
+func (e *Engine) render(phase *pipeline.Phase, kind pipeline.PromptKind, data pipeline.PromptData) (prompts.Prompt, error) {
+	name := pipeline.PromptName(phase.ID, kind)
+	if e.prompts != nil {
+		if t, ok := e.prompts.Lookup(name); ok {
+			text, err := t.Execute(data)
+			return prompts.Prompt{Name: name, Version: t.Version, Text: text}, err
+		}
+	}
+	text, err := phase.Render(kind, data)
+	return prompts.Prompt{Name: name, Version: e.pipeline.PromptVersion(), Text: text}, err
+}
+
+// askClaude passa o prompt pelos middlewares da fase, envia-o ao Claude e registra o prompt enviado,
+// a versão do template e o consumo de tokens na conversa. A resposta passa pelos mesmos middlewares.
+func (e *Engine) askClaude(conv *models.Conversation, step int, path string, prompt prompts.Prompt) (string, error) {
+	phaseID, kind, _ := strings.Cut(prompt.Name, "/")
+	var chain *middleware.Chain
+	if phase, ok := e.pipeline.Phase(phaseID); ok {
+		chain = phase.Chain()
+	}
+	ctx := middleware.Context{
+		ConversationID: conv.ID,
+		Phase:          phaseID,
+		Kind:           kind,
+		Path:           path,
+		Stack:          stackSummary(conv),
+	}
+
+	text, err := chain.Prompt(ctx, prompt.Text)
+	if err != nil {
+		return "", err
+	}
+	completion, err := e.claudeClient.GetCompletion(text)
+	if err != nil {
+		return "", err
+	}
+
+	conv.Prompts = append(conv.Prompts, models.PromptRecord{
+		Step:     step,
+		Path:     path,
+		Template: prompt.Name,
+		Version:  prompt.Version,
+		Prompt:   text,
+		Usage:    completion.Usage,
+	})
+	conv.Usage.Add(completion.Usage)
+
+	return chain.Response(ctx, completion.Text)
+}
+
+func (e *Engine) generateAndSaveFileContent(conv *models.Conversation, phase *pipeline.Phase, filePath string, conn *eventConn, totalFiles int, filesProcessed *int) error {
+	step := len(conv.Steps) + 1
+	prompt, err := e.render(phase, pipeline.PromptFile, e.promptData(conv, phase, "", filePath))
+	if err != nil {
+		return err
+	}
+
+	response, err := e.askClaude(conv, step, filePath, prompt)
+	if err != nil {
+		return fmt.Errorf("error getting response from Claude for file %s: %v", filePath, err)
+	}
+
+	// Arquivos de configuração inválidos são gerados novamente com o erro no prompt
+	for attempt := 1; attempt <= maxRegenerations; attempt++ {
+		_, validationErr := validate.File(filePath, response)
+		if validationErr == nil {
+			break
+		}
+		log.Printf("Regenerating %s (attempt %d): %v", filePath, attempt, validationErr)
+		sendWebSocketMessage(conn, "status_update", fmt.Sprintf("Regenerating %s: %v", filePath, validationErr))
+
+		retryPrompt := prompt
+		retryPrompt.Text = fmt.Sprintf("%s\n\nA previous answer for this file was rejected because it is not valid: %v. Return only the raw file content, without Markdown code fences or explanations.", prompt.Text, validationErr)
+		response, err = e.askClaude(conv, step, filePath, retryPrompt)
+		if err != nil {
+			return fmt.Errorf("error getting response from Claude for file %s: %v", filePath, err)
+		}
+	}
+
+	if err := e.writeFile(conv, step, filePath, response, conn); err != nil {
+		return err
+	}
+
+	*filesProcessed++
+	percentage := (*filesProcessed * 100) / totalFiles
+	sendProgressUpdate(conn, percentage, fmt.Sprintf("Generating: %s", filePath))
+
+	log.Printf("Sent file content for %s to frontend", filePath)
+
+	return nil
+}
+
+// writeFile grava o arquivo no workspace, registra a versão no artifact store
+// e envia o conteúdo para o frontend. Arquivos de configuração são limpos e
+// validados; os inválidos ficam marcados na conversa.
+func (e *Engine) writeFile(conv *models.Conversation, step int, filePath, content string, conn *eventConn) error {
+	if kind := validate.Kind(filePath); kind != "" {
+		cleaned, validationErr := validate.File(filePath, content)
+		content = cleaned
+		e.markValidation(conv, filePath, kind, validationErr, conn)
+	}
+
+	if err := saveFileToDisk(conv.Workspace, filePath, content); err != nil {
+		return fmt.Errorf("error saving file to disk: %v", err)
+	}
+	sendWebSocketMessage(conn, "security_scan", SecurityScanMessage{
+		Path:   filePath,
+		Issues: scanFile(conv, filePath, content),
+	})
+
+	// Guarda a versão no artifact store para não perder o histórico ao regenerar
+	if _, err := e.artifactStore.Record(conv.ID, filePath, step, []byte(content)); err != nil {
+		return fmt.Errorf("error recording artifact version: %v", err)
+	}
+
+	fileContent := models.FileContent{
+		Path:    filePath,
+		Content: content,
+	}
+	sendWebSocketMessage(conn, "file_content", fileContent)
+	return nil
+}
+
+// markValidation registra o resultado da validação na conversa e avisa o frontend
+func (e *Engine) markValidation(conv *models.Conversation, filePath, kind string, validationErr error, conn *eventConn) {
+	message := FileValidationMessage{Path: filePath, Kind: kind, Valid: validationErr == nil}
+	if validationErr != nil {
+		if conv.InvalidFiles == nil {
+			conv.InvalidFiles = make(map[string]string)
+		}
+		conv.InvalidFiles[filePath] = validationErr.Error()
+		message.Error = validationErr.Error()
+		log.Printf("Conversation %s: %s is invalid: %v", conv.ID, filePath, validationErr)
+	} else {
+		delete(conv.InvalidFiles, filePath)
+	}
+	sendWebSocketMessage(conn, "file_validation", message)
+}
+
+// generateFiles gera o conteúdo de todos os arquivos da estrutura do projeto
+func (e *Engine) generateFiles(conv *models.Conversation, phase *pipeline.Phase, conn *eventConn) (string, error) {
+	projectStructure, err := structure.Parse(conv.Structure)
+	if err != nil {
+		return "", err
+	}
+
+	fileStructure := generateFileList(projectStructure)
+
+	sendWebSocketMessage(conn, "project_structure", fileStructure)
+	sendWebSocketMessage(conn, "status_update", "Generating project files...")
+
+	totalFiles := countFiles(fileStructure)
+	filesProcessed := 0
+
+	scaffolded, err := e.templateFiles(conv, projectStructure)
+	if err != nil {
+		return "", err
+	}
+
+	// Projetos pequenos são gerados em uma única resposta; o boilerplate do
+	// template não conta no limite porque não passa pelo modelo
+	var bundled map[string]string
+	if phase.Bulk(totalFiles - len(scaffolded)) {
+		bundled = e.generateBulk(conv, phase, conn)
+	}
+
+	write := func(filePath, content, status string) error {
+		if err := e.writeFile(conv, len(conv.Steps)+1, filePath, content, conn); err != nil {
+			return err
+		}
+		filesProcessed++
+		sendProgressUpdate(conn, (filesProcessed*100)/totalFiles, fmt.Sprintf("%s: %s", status, filePath))
+		return nil
+	}
+
+	// Arquivos que faltarem na resposta única ou forem inválidos voltam para a geração arquivo a arquivo
+	generate := func(filePath string) error {
+		if content, ok := scaffolded[path.Clean(filePath)]; ok {
+			return write(filePath, content, "Writing from template")
+		}
+		if content, ok := bundled[path.Clean(filePath)]; ok {
+			_, err := validate.File(filePath, content)
+			if err == nil {
+				return write(filePath, content, "Generating")
+			}
+			log.Printf("%s from the bulk response is invalid, generating it separately: %v", filePath, err)
+		} else if bundled != nil {
+			log.Printf("%s is missing from the bulk response, generating it separately", filePath)
+		}
+		return e.generateAndSaveFileContent(conv, phase, filePath, conn, totalFiles, &filesProcessed)
+	}
+
+	var processFiles func(structure map[string]interface{}, path string) error
+	processFiles = func(structure map[string]interface{}, path string) error {
+		for key, value := range structure {
+			newPath := path + "/" + key
+
+			switch v := value.(type) {
+			case []string:
+				// Processar lista de arquivos
+				for _, file := range v {
+					filePath := newPath + "/" + file
+					// Se for um diretório, adicione uma barra no final
+					if !strings.Contains(file, ".") && file != "Dockerfile" {
+						filePath += "/"
+					}
+
+					// Se for um diretório, não gere conteúdo
+					if strings.HasSuffix(filePath, "/") {
+						if err := saveFileToDisk(conv.Workspace, filePath, ""); err != nil {
+							return fmt.Errorf("error saving directory to disk: %v", err)
+						}
+						filesProcessed++
+						if totalFiles > 0 { // Verificação para evitar divisão por zero
+							percentage := (filesProcessed * 100) / totalFiles
+							sendProgressUpdate(conn, percentage, fmt.Sprintf("Creating directory: %s", filePath))
+						}
+						continue
+					}
+
+					// Geração de conteúdo do arquivo
+					if err := generate(filePath); err != nil {
+						return err
+					}
+				}
+			case map[string]interface{}:
+				// Caso o map esteja vazio, tratar como arquivo
+				if len(v) == 0 && (strings.Contains(key, ".") || key == "Dockerfile") {
+					if err := generate(newPath); err != nil {
+						return err
+					}
+				} else {
+					// Recursão para processar diretórios aninhados
+					if err := processFiles(v, newPath); err != nil {
+						return err
+					}
+				}
+			case []interface{}:
+				// Quando o arquivo for do tipo "App.js": [] ou "Dockerfile": [] ou similar, tratar como arquivo regular
+				if len(v) == 0 && (strings.Contains(key, ".") || key == "Dockerfile" || key == "go.mod" || key == "main.go" || key == "docker-compose.yml") {
+					// Este é um arquivo, mesmo que o array esteja vazio
+					if err := generate(newPath); err != nil {
+						return err
+					}
+				}
+			default:
+				// Tratamento especial para arquivos com objetos vazios {}
+				if key == "Dockerfile" || key == "package.json" || key == "App.js" || key == "index.js" || key == "go.mod" || key == "main.go" || key == "docker-compose.yml" || key == ".gitignore" {
+					if err := generate(newPath); err != nil {
+						return err
+					}
+				} else {
+					log.Printf("Unexpected value type for key: %s", key)
+				}
+			}
+		}
+		return nil
+	}
+
+	sendProgressUpdate(conn, 0, "Starting file generation...")
+
+	// Os documentos confirmados nas fases anteriores também fazem parte do
+	// projeto, dentro do diretório raiz da estrutura
+	root := structure.Root(projectStructure)
+	for filePath, content := range documents.Files(conv) {
+		if err := e.writeFile(conv, len(conv.Steps)+1, path.Join("/", root, filePath), content, conn); err != nil {
+			return "", err
+		}
+	}
+
+	err = processFiles(fileStructure, "")
+	if err != nil {
+		return "", err
+	}
+	sendProgressUpdate(conn, 100, "File generation complete!")
+
+	conv.ProjectCreated = true
+	e.store.UpdateConversation(conv)
+
+	return phase.Message, nil
+}
+
+// generateBulk pede ao Claude todos os arquivos do projeto em uma única
+// resposta. Retorna nil se a resposta não puder ser lida, para que a geração
+// continue arquivo a arquivo.
+func (e *Engine) generateBulk(conv *models.Conversation, phase *pipeline.Phase, conn *eventConn) map[string]string {
+	step := len(conv.Steps) + 1
+	prompt, err := e.render(phase, pipeline.PromptBulk, e.promptData(conv, phase, "", ""))
+	if err != nil {
+		log.Printf("Error rendering bulk prompt: %v", err)
+		return nil
+	}
+
+	sendWebSocketMessage(conn, "status_update", "Generating all project files in one response...")
+	response, err := e.askClaude(conv, step, "", prompt)
+	if err != nil {
+		log.Printf("Error getting bulk response from Claude: %v", err)
+		return nil
+	}
+
+	files, skipped, err := multifile.Parse(response)
+	if err != nil {
+		log.Printf("Error parsing bulk response, generating files separately: %v", err)
+		return nil
+	}
+	if len(skipped) > 0 {
+		log.Printf("Bulk response for conversation %s: ignoring invalid paths %q", conv.ID, skipped)
+	}
+
+	bundled := make(map[string]string, len(files))
+	for _, file := range files {
+		bundled[file.Path] = file.Content
+	}
+	log.Printf("Bulk response for conversation %s has %d files", conv.ID, len(bundled))
+	return bundled
+}
+
+// generateTests gera um arquivo de testes unitários para cada arquivo-fonte do
+// projeto e inclui os testes na estrutura
+func (e *Engine) generateTests(conv *models.Conversation, phase *pipeline.Phase, conn *eventConn) (string, error) {
+	tree, err := structure.Parse(conv.Structure)
+	if err != nil {
+		return "", err
+	}
+	targets := testgen.Targets(structure.Files(tree))
+
+	sendWebSocketMessage(conn, "status_update", "Generating unit tests...")
+	sendProgressUpdate(conn, 0, "Starting test generation...")
+
+	var generated []string
+	for i, target := range targets {
+		source, err := os.ReadFile(filepath.Join(conv.Workspace, filepath.FromSlash(target.Source)))
+		if err != nil {
+			log.Printf("Skipping tests for %s: %v", target.Source, err)
+			continue
+		}
+
+		step := len(conv.Steps) + 1
+		testPath := "/" + target.Test
+		data := e.promptData(conv, phase, "", "/"+target.Source)
+		data.Source, data.TestPath, data.Framework = string(source), testPath, target.Framework
+
+		prompt, err := e.render(phase, pipeline.PromptFile, data)
+		if err != nil {
+			return "", err
+		}
+		response, err := e.askClaude(conv, step, testPath, prompt)
+		if err != nil {
+			return "", fmt.Errorf("error getting response from Claude for tests of %s: %v", target.Source, err)
+		}
+		if err := e.writeFile(conv, step, testPath, response, conn); err != nil {
+			return "", err
+		}
+		generated = append(generated, target.Test)
+
+		percentage := ((i + 1) * 100) / len(targets)
+		sendProgressUpdate(conn, percentage, fmt.Sprintf("Generating tests: %s", testPath))
+	}
+
+	if len(generated) > 0 {
+		updated, err := structure.AddFiles(conv.Structure, generated)
+		if err != nil {
+			return "", err
+		}
+		conv.Structure = updated
+		if tree, err = structure.Parse(updated); err == nil {
+			sendWebSocketMessage(conn, "project_structure", generateFileList(tree))
+		}
+	}
+	sendProgressUpdate(conn, 100, "Test generation complete!")
+
+	e.store.UpdateConversation(conv)
+	return fmt.Sprintf("%s (%d test files)", phase.Message, len(generated)), nil
+}
+
+// reviewFiles pede ao Claude a revisão de cada arquivo do projeto e guarda os achados na conversa
+func (e *Engine) reviewFiles(conv *models.Conversation, phase *pipeline.Phase, conn *eventConn) (string, error) {
+	tree, err := structure.Parse(conv.Structure)
+	if err != nil {
+		return "", err
+	}
+	files := structure.Files(tree)
+
+	sendWebSocketMessage(conn, "status_update", "Reviewing project files...")
+	sendProgressUpdate(conn, 0, "Starting code review...")
+
+	findings := []models.Finding{}
+	for i, rel := range files {
+		filePath := "/" + rel
+		source, err := os.ReadFile(filepath.Join(conv.Workspace, filepath.FromSlash(rel)))
+		if err != nil || len(bytes.TrimSpace(source)) == 0 {
+			continue
+		}
+
+		step := len(conv.Steps) + 1
+		data := e.promptData(conv, phase, "", filePath)
+		data.Source = review.NumberLines(string(source))
+
+		prompt, err := e.render(phase, pipeline.PromptFile, data)
+		if err != nil {
+			return "", err
+		}
+		response, err := e.askClaude(conv, step, filePath, prompt)
+		if err != nil {
+			return "", fmt.Errorf("error getting response from Claude for review of %s: %v", filePath, err)
+		}
+
+		// Uma resposta ilegível para um arquivo não invalida a revisão dos demais
+		fileFindings, err := review.ParseFindings(response, filePath, string(source))
+		if err != nil {
+			log.Printf("Ignoring review of %s: %v", filePath, err)
+		}
+		for _, finding := range fileFindings {
+			finding.ID = fmt.Sprintf("F-%d", len(findings)+1)
+			findings = append(findings, finding)
+		}
+
+		percentage := ((i + 1) * 100) / len(files)
+		sendProgressUpdate(conn, percentage, fmt.Sprintf("Reviewing: %s", filePath))
+	}
+	sendProgressUpdate(conn, 100, "Code review complete!")
+
+	conv.Findings = findings
+	sendWebSocketMessage(conn, "review_findings", ReviewFindingsMessage{
+		ConversationID: conv.ID,
+		Findings:       findings,
+	})
+	e.store.UpdateConversation(conv)
+
+	return fmt.Sprintf("%s (%d findings)", phase.Message, len(findings)), nil
+}
+
+// generateCI gera o workflow de CI para o alvo escolhido, validando a sua
+// estrutura antes de salvá-lo no projeto
+func (e *Engine) generateCI(conv *models.Conversation, phase *pipeline.Phase, input string, conn *eventConn) (string, error) {
+	target, err := ci.ParseTarget(input)
+	if err != nil {
+		return "", &retryInputError{message: err.Error()}
+	}
+	conv.CITarget = target.Name
+
+	tree, err := structure.Parse(conv.Structure)
+	if err != nil {
+		return "", err
+	}
+
+	step := len(conv.Steps) + 1
+	filePath := "/" + target.File
+	data := e.promptData(conv, phase, input, filePath)
+	data.CITarget = target.Label
+	data.Stacks = ci.Describe(ci.DetectStacks(structure.Files(tree)))
+
+	prompt, err := e.render(phase, pipeline.PromptMain, data)
+	if err != nil {
+		return "", err
+	}
+
+	sendWebSocketMessage(conn, "status_update", fmt.Sprintf("Generating %s workflow...", target.Label))
+	response, err := e.askClaude(conv, step, filePath, prompt)
+	if err != nil {
+		return "", fmt.Errorf("error getting response from Claude: %v", err)
+	}
+
+	content := validate.StripFences(response)
+	validationErr := ci.Validate(target, content)
+	for attempt := 1; validationErr != nil && attempt <= maxRegenerations; attempt++ {
+		log.Printf("Regenerating %s (attempt %d): %v", filePath, attempt, validationErr)
+		sendWebSocketMessage(conn, "status_update", fmt.Sprintf("Regenerating %s: %v", filePath, validationErr))
+
+		retryPrompt := prompt
+		retryPrompt.Text = fmt.Sprintf("%s\n\nA previous answer was rejected because it is not a valid %s workflow: %v. Return only the raw file content, without Markdown code fences or explanations.", prompt.Text, target.Label, validationErr)
+		response, err = e.askClaude(conv, step, filePath, retryPrompt)
+		if err != nil {
+			return "", fmt.Errorf("error getting response from Claude: %v", err)
+		}
+		content = validate.StripFences(response)
+		validationErr = ci.Validate(target, content)
+	}
+	if validationErr != nil {
+		return "", fmt.Errorf("generated %s workflow is invalid: %v", target.Label, validationErr)
+	}
+
+	if err := e.writeFile(conv, step, filePath, content, conn); err != nil {
+		return "", err
+	}
+
+	updated, err := structure.AddFiles(conv.Structure, []string{target.File})
+	if err != nil {
+		return "", err
+	}
+	conv.Structure = updated
+	if tree, err = structure.Parse(updated); err == nil {
+		sendWebSocketMessage(conn, "project_structure", generateFileList(tree))
+	}
+
+	return fmt.Sprintf("%s (%s: %s)", phase.Message, target.Label, filePath), nil
+}
+
+// compileAndFix verifica os arquivos Go do workspace e devolve os diagnósticos
+// ao Claude para correção, por no máximo phase.FixRounds rodadas
+func (e *Engine) compileAndFix(conv *models.Conversation, phase *pipeline.Phase, conn *eventConn) error {
+	goFiles, err := gocheck.Files(conv.Workspace)
+	if err != nil || len(goFiles) == 0 {
+		return err
+	}
+
+	reported := make(map[string]bool)
+	for round := 1; ; round++ {
+		if err := e.formatGoFiles(conv, conn); err != nil {
+			return err
+		}
+
+		sendWebSocketMessage(conn, "status_update", "Checking Go files...")
+		diagnostics, err := gocheck.Check(conv.Workspace, e.goCheck)
+		if err != nil {
+			return fmt.Errorf("error checking Go files: %v", err)
+		}
+
+		// Arquivos corrigidos recebem uma lista vazia para o frontend limpar os erros
+		byFile := gocheck.ByFile(diagnostics)
+		for filePath := range reported {
+			if _, ok := byFile[filePath]; !ok {
+				byFile[filePath] = []gocheck.Diagnostic{}
+			}
+		}
+		paths := make([]string, 0, len(byFile))
+		for filePath := range byFile {
+			paths = append(paths, filePath)
+		}
+		sort.Strings(paths)
+		for _, filePath := range paths {
+			sendWebSocketMessage(conn, "diagnostics", DiagnosticsMessage{
+				Round:       round,
+				Path:        "/" + filePath,
+				Diagnostics: byFile[filePath],
+			})
+			reported[filePath] = len(byFile[filePath]) > 0
+		}
+
+		if len(diagnostics) == 0 {
+			sendWebSocketMessage(conn, "status_update", "Go check passed.")
+			return nil
+		}
+		if round > phase.FixRounds {
+			log.Printf("Conversation %s: %d Go diagnostics remain after %d fix rounds", conv.ID, len(diagnostics), phase.FixRounds)
+			sendWebSocketMessage(conn, "status_update", fmt.Sprintf("%d Go problems remain after %d fix rounds.", len(diagnostics), phase.FixRounds))
+			return nil
+		}
+
+		sendWebSocketMessage(conn, "status_update", fmt.Sprintf("Fixing Go problems (round %d of %d)...", round, phase.FixRounds))
+		for _, filePath := range paths {
+			if len(byFile[filePath]) == 0 {
+				continue
+			}
+			if err := e.fixFile(conv, phase, filePath, byFile[filePath], conn); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// fixFile pede ao Claude a versão corrigida do arquivo
+func (e *Engine) fixFile(conv *models.Conversation, phase *pipeline.Phase, filePath string, diagnostics []gocheck.Diagnostic, conn *eventConn) error {
+	source, err := os.ReadFile(filepath.Join(conv.Workspace, filepath.FromSlash(filePath)))
+	if err != nil {
+		return fmt.Errorf("error reading %s: %v", filePath, err)
+	}
+
+	lines := make([]string, len(diagnostics))
+	for i, d := range diagnostics {
+		lines[i] = d.String()
+	}
+
+	step := len(conv.Steps) + 1
+	data := e.promptData(conv, phase, "", "/"+filePath)
+	data.Source = string(source)
+	data.Diagnostics = strings.Join(lines, "\n")
+
+	prompt, err := e.render(phase, pipeline.PromptFix, data)
+	if err != nil {
+		return err
+	}
+	response, err := e.askClaude(conv, step, "/"+filePath, prompt)
+	if err != nil {
+		return fmt.Errorf("error getting response from Claude for fix of %s: %v", filePath, err)
+	}
+	return e.writeFile(conv, step, "/"+filePath, response, conn)
+}
+
+// formatGoFiles aplica o gofmt aos arquivos Go do workspace que ainda não estão formatados
+func (e *Engine) formatGoFiles(conv *models.Conversation, conn *eventConn) error {
+	goFiles, err := gocheck.Files(conv.Workspace)
+	if err != nil {
+		return err
+	}
+	for _, filePath := range goFiles {
+		source, err := os.ReadFile(filepath.Join(conv.Workspace, filepath.FromSlash(filePath)))
+		if err != nil {
+			return fmt.Errorf("error reading %s: %v", filePath, err)
+		}
+		// Arquivos com erro de sintaxe ficam como estão; o parser os reporta
+		formatted, err := gocheck.Format(source)
+		if err != nil || bytes.Equal(formatted, source) {
+			continue
+		}
+		if err := e.writeFile(conv, len(conv.Steps)+1, "/"+filePath, string(formatted), conn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pickTemplate escolhe o template de projeto pela stack da arquitetura; sem
+// arquitetura o projeto é gerado sem template
+func (e *Engine) pickTemplate(conv *models.Conversation, conn *eventConn) {
+	if conv.Architecture == nil {
+		conv.Template = ""
+		return
+	}
+
+	t, ok := scaffold.Match(conv.Architecture.Stack)
+	if !ok {
+		conv.Template = ""
+		return
+	}
+	conv.Template = t.Name
+	log.Printf("Conversation %s uses the %s template", conv.ID, t.Name)
+	sendWebSocketMessage(conn, "status_update", fmt.Sprintf("Starting from the %s project template...", t.Name))
+}
+
+// extendTemplate garante que a estrutura proposta contém todos os arquivos do template
+func (e *Engine) extendTemplate(conv *models.Conversation, phase *pipeline.Phase, response string) string {
+	t, ok := scaffold.Lookup(conv.Template)
+	if !phase.Scaffold || !ok {
+		return response
+	}
+	tree, err := structure.Parse(response)
+	if err != nil {
+		return response
+	}
+	files, err := t.Files()
+	if err != nil {
+		log.Printf("Error listing files of template %s: %v", t.Name, err)
+		return response
+	}
+
+	root := structure.Root(tree)
+	for i, f := range files {
+		files[i] = path.Join(root, f)
+	}
+	extended, err := structure.AddFiles(response, files)
+	if err != nil {
+		log.Printf("Error adding template files to the structure: %v", err)
+		return response
+	}
+	return extended
+}
+
+// templateFiles gera o boilerplate do template da conversa, pelo caminho que
+// cada arquivo tem na estrutura do projeto
+func (e *Engine) templateFiles(conv *models.Conversation, tree map[string]interface{}) (map[string]string, error) {
+	t, ok := scaffold.Lookup(conv.Template)
+	if !ok {
+		return nil, nil
+	}
+	rendered, err := t.Render(scaffold.Data{AppName: defaultAppName})
+	if err != nil {
+		return nil, err
+	}
+
+	root := structure.Root(tree)
+	files := make(map[string]string, len(rendered))
+	for name, content := range rendered {
+		files[path.Join("/", root, name)] = content
+	}
+	return files, nil
+}
+
+// templateSummary descreve o template da conversa para os prompts
+func templateSummary(conv *models.Conversation) string {
+	t, ok := scaffold.Lookup(conv.Template)
+	if !ok {
+		return ""
+	}
+	summary, err := t.Summary()
+	if err != nil {
+		log.Printf("Error describing template %s: %v", t.Name, err)
+		return ""
+	}
+	return summary
+}
@@ -0,0 +1,290 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"backend-ai-sdlc/internal/artifacts"
+	"backend-ai-sdlc/internal/gitrepo"
+	"backend-ai-sdlc/internal/models"
+	"backend-ai-sdlc/internal/storage"
+	"backend-ai-sdlc/internal/workspace"
+)
+
+const (
+	// Tamanho máximo da entrada do usuário no assunto do commit
+	maxCommitSubject = 72
+	// maxCommitInput limita a entrada do usuário copiada para o corpo do commit
+	maxCommitInput = 4 * 1024
+	// maxCommitPrompts limita os prompts listados no corpo do commit
+	maxCommitPrompts = 200
+)
+
+type GitCommitsResponse struct {
+	ConversationID string           `json:"conversation_id"`
+	Commits        []gitrepo.Commit `json:"commits"`
+}
+
+type GitCheckoutResponse struct {
+	ConversationID string `json:"conversation_id"`
+	Commit         string `json:"commit"`
+	Files          int    `json:"files"`
+}
+
+// commitWorkspace grava o estado do workspace no repositório git da conversa.
+// Falhas são apenas registradas no log: o histórico não deve interromper o chat.
+func commitWorkspace(conv *models.Conversation, message string) string {
+	if conv.Workspace == "" || !gitrepo.Available() {
+		return ""
+	}
+	if _, err := os.Stat(conv.Workspace); err != nil {
+		return ""
+	}
+
+	hash, err := gitrepo.Open(conv.Workspace).Commit(message)
+	if err != nil {
+		log.Printf("Error committing workspace of conversation %s: %v", conv.ID, err)
+		return ""
+	}
+	if hash != "" {
+		log.Printf("Committed workspace of conversation %s: %s", conv.ID, hash)
+	}
+	return hash
+}
+
+// stepCommitMessage monta a mensagem do commit de um passo: a entrada do usuário
+// no assunto e, no corpo, cada prompt enviado ao Claude identificado pelo
+// arquivo, pelo template e pelo hash do texto. O texto completo dos prompts fica
+// na conversa; no commit ele passaria facilmente de centenas de KiB.
+func stepCommitMessage(conv *models.Conversation, step models.Step) string {
+	input := strings.TrimSpace(step.Input)
+	subject := input
+	if i := strings.IndexByte(subject, '\n'); i >= 0 {
+		subject = subject[:i]
+	}
+	if len(subject) > maxCommitSubject {
+		subject = subject[:maxCommitSubject] + "..."
+	}
+	if len(input) > maxCommitInput {
+		input = input[:maxCommitInput] + "..."
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Step %d (%s): %s\n\n", step.Number, conv.Phase, subject)
+	fmt.Fprintf(&sb, "Input:\n%s\n", input)
+
+	listed, total := 0, 0
+	for _, record := range conv.Prompts {
+		if record.Step != step.Number {
+			continue
+		}
+		total++
+		if listed == maxCommitPrompts {
+			continue
+		}
+		if listed == 0 {
+			sb.WriteString("\nPrompts:\n")
+		}
+		listed++
+
+		target := record.Path
+		if target == "" {
+			target = "(step)"
+		}
+		fmt.Fprintf(&sb, "- %s", target)
+		if record.Template != "" {
+			fmt.Fprintf(&sb, " %s@%s", record.Template, record.Version)
+		}
+		sum := sha256.Sum256([]byte(record.Prompt))
+		fmt.Fprintf(&sb, " sha256:%s\n", hex.EncodeToString(sum[:])[:12])
+	}
+	if total > listed {
+		fmt.Fprintf(&sb, "- ... and %d more prompts\n", total-listed)
+	}
+	return sb.String()
+}
+
+// GitCommitsHandler lista os commits do repositório do workspace
+func GitCommitsHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		conv, ok := gitConversation(w, r, store)
+		if !ok {
+			return
+		}
+
+		limit := 0
+		if value := r.URL.Query().Get("limit"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				http.Error(w, "Invalid limit", http.StatusBadRequest)
+				return
+			}
+			limit = n
+		}
+
+		commits, err := gitrepo.Open(conv.Workspace).Log(limit)
+		if err != nil {
+			log.Printf("Error listing commits for conversation %s: %v", conv.ID, err)
+			http.Error(w, "Error listing commits", http.StatusInternalServerError)
+			return
+		}
+
+		sendJSONResponse(w, GitCommitsResponse{
+			ConversationID: conv.ID,
+			Commits:        commits,
+		})
+	}
+}
+
+// GitDiffHandler retorna o diff unificado entre dois commits do workspace
+func GitDiffHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		conv, ok := gitConversation(w, r, store)
+		if !ok {
+			return
+		}
+
+		from := r.URL.Query().Get("from")
+		to := r.URL.Query().Get("to")
+		if from == "" || to == "" {
+			http.Error(w, "Missing from or to", http.StatusBadRequest)
+			return
+		}
+
+		diff, err := gitrepo.Open(conv.Workspace).Diff(from, to)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/x-diff; charset=utf-8")
+		w.Write([]byte(diff))
+	}
+}
+
+// GitCheckoutHandler restaura o workspace para um commit anterior. A restauração
+// vira um novo commit, e os arquivos restaurados passam de novo pelo scanner e
+// pelo artifact store.
+func GitCheckoutHandler(store storage.Storage, artifactStore *artifacts.Store, locks *storage.Locks) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		conversationID := r.URL.Query().Get("conversation_id")
+		if !lockConversation(w, locks, conversationID) {
+			return
+		}
+		defer locks.Unlock(conversationID)
+
+		conv, ok := gitConversation(w, r, store)
+		if !ok {
+			return
+		}
+
+		commit := r.URL.Query().Get("commit")
+		if commit == "" {
+			http.Error(w, "Missing commit", http.StatusBadRequest)
+			return
+		}
+
+		hash, err := gitrepo.Open(conv.Workspace).Checkout(commit)
+		if err != nil {
+			log.Printf("Error checking out %s in conversation %s: %v", commit, conv.ID, err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		files, err := rescanWorkspace(conv, artifactStore)
+		if err != nil {
+			log.Printf("Error rescanning workspace of conversation %s: %v", conv.ID, err)
+			http.Error(w, "Error reading workspace files", http.StatusInternalServerError)
+			return
+		}
+
+		store.UpdateConversation(conv)
+		log.Printf("Checked out %s in conversation %s", commit, conv.ID)
+
+		sendJSONResponse(w, GitCheckoutResponse{
+			ConversationID: conv.ID,
+			Commit:         hash,
+			Files:          files,
+		})
+	}
+}
+
+// rescanWorkspace refaz o scan de segurança e registra a versão de todos os
+// arquivos do workspace. Os arquivos com versões que não existem mais no
+// workspace são avisados como removidos.
+func rescanWorkspace(conv *models.Conversation, artifactStore *artifacts.Store) (int, error) {
+	files, err := workspace.Files(conv.Workspace)
+	if err != nil {
+		return 0, err
+	}
+
+	var removed []string
+	if index, err := artifactStore.Index(conv.ID); err != nil {
+		log.Printf("Error reading artifact index of conversation %s: %v", conv.ID, err)
+	} else {
+		present := make(map[string]bool, len(files))
+		for _, file := range files {
+			present[file.Path] = true
+		}
+		for filePath := range index.Files {
+			if !present[filePath] {
+				removed = append(removed, filePath)
+			}
+		}
+	}
+	artifactStore.RecordRemoval(conv.ID, removed)
+
+	conv.SecurityIssues = nil
+	for _, file := range files {
+		content, err := os.ReadFile(filepath.Join(conv.Workspace, filepath.FromSlash(file.Path)))
+		if err != nil {
+			return 0, err
+		}
+		scanFile(conv, file.Path, string(content))
+		if _, err := artifactStore.Record(conv.ID, file.Path, len(conv.Steps), content); err != nil {
+			log.Printf("Error recording artifact version: %v", err)
+		}
+	}
+	return len(files), nil
+}
+
+func gitConversation(w http.ResponseWriter, r *http.Request, store storage.Storage) (*models.Conversation, bool) {
+	conversationID := r.URL.Query().Get("conversation_id")
+	if conversationID == "" {
+		http.Error(w, "Missing conversation_id", http.StatusBadRequest)
+		return nil, false
+	}
+
+	conv, exists := store.GetConversation(conversationID)
+	if !exists || conv.Workspace == "" {
+		http.Error(w, "Conversation not found", http.StatusNotFound)
+		return nil, false
+	}
+	if !gitrepo.Available() {
+		http.Error(w, "Git is not available on the server", http.StatusServiceUnavailable)
+		return nil, false
+	}
+	return conv, true
+}
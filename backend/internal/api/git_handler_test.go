@@ -0,0 +1,72 @@
+package api
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"backend-ai-sdlc/internal/artifacts"
+	"backend-ai-sdlc/internal/models"
+)
+
+func TestStepCommitMessageListsPrompts(t *testing.T) {
+	conv := &models.Conversation{Phase: "files"}
+	longPrompt := strings.Repeat("docs and spec ", 10000)
+	for i := 0; i < maxCommitPrompts+5; i++ {
+		conv.Prompts = append(conv.Prompts, models.PromptRecord{
+			Step:     2,
+			Path:     "/app/main.go",
+			Template: "files/file_prompt",
+			Version:  "v1",
+			Prompt:   longPrompt,
+		})
+	}
+	conv.Prompts = append(conv.Prompts, models.PromptRecord{Step: 1, Prompt: "other step"})
+
+	message := stepCommitMessage(conv, models.Step{Number: 2, Input: "YES\nmore"})
+
+	if !strings.HasPrefix(message, "Step 2 (files): YES\n\n") {
+		t.Errorf("unexpected subject: %q", strings.SplitN(message, "\n", 2)[0])
+	}
+	if strings.Contains(message, "docs and spec") {
+		t.Error("message contains the prompt text")
+	}
+	if !strings.Contains(message, "- /app/main.go files/file_prompt@v1 sha256:") {
+		t.Error("message does not list the prompt path, template and hash")
+	}
+	if !strings.Contains(message, "- ... and 5 more prompts\n") {
+		t.Error("message does not cap the listed prompts")
+	}
+	if len(message) > 64*1024 {
+		t.Errorf("message has %d bytes", len(message))
+	}
+}
+
+func TestRescanWorkspaceReportsRemovedFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "kept.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	artifactStore, err := artifacts.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"/kept.go", "/removed.go"} {
+		if _, err := artifactStore.Record("conv", name, 1, []byte("package main\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var removed []string
+	artifactStore.OnRemove(func(_ string, paths []string) {
+		removed = append(removed, paths...)
+	})
+
+	files, err := rescanWorkspace(&models.Conversation{ID: "conv", Workspace: dir}, artifactStore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files != 1 || len(removed) != 1 || removed[0] != "removed.go" {
+		t.Errorf("rescanWorkspace() = %d files, removed %v, want 1 file and [removed.go]", files, removed)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"archive/zip"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -13,13 +14,18 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/gorilla/websocket"
 
-	"backend-ai-sdlc/internal/claude"
+	"backend-ai-sdlc/internal/events"
+	"backend-ai-sdlc/internal/gocheck"
 	"backend-ai-sdlc/internal/models"
+	"backend-ai-sdlc/internal/pipeline"
+	"backend-ai-sdlc/internal/security"
 	"backend-ai-sdlc/internal/storage"
+	"backend-ai-sdlc/internal/structure"
+	"backend-ai-sdlc/internal/webhook"
+	"backend-ai-sdlc/internal/workspace"
 )
 
 var upgrader = websocket.Upgrader{
@@ -28,17 +34,68 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
-var confirmationSteps = map[int]bool{
-	1:  true,
-	2:  true,
-	7:  true,
-	8:  true,
-	13: true,
-}
+// Nome do projeto usado no download e nos arquivos de configuração gerados
+const defaultAppName = "chat-app-maker"
 
+// WebSocketMessage é uma mensagem enviada ao cliente. Seq numera os eventos
+// da conversa e é o valor enviado em last_seq para retomá-los.
 type WebSocketMessage struct {
 	Type    string      `json:"type"`
 	Content interface{} `json:"content"`
+	Seq     uint64      `json:"seq,omitempty"`
+}
+
+// InvalidTransitionMessage informa ao cliente quais entradas são aceitas no estado atual
+type InvalidTransitionMessage struct {
+	Message string `json:"message"`
+	*pipeline.InvalidTransitionError
+}
+
+// StructureDiffMessage mostra o que mudou na estrutura a cada revisão
+type StructureDiffMessage struct {
+	Revision int            `json:"revision"`
+	Diff     structure.Diff `json:"diff"`
+}
+
+// DiagnosticsMessage traz os problemas encontrados em um arquivo Go na rodada de verificação
+type DiagnosticsMessage struct {
+	Round       int                  `json:"round"`
+	Path        string               `json:"path"`
+	Diagnostics []gocheck.Diagnostic `json:"diagnostics"`
+}
+
+// FileValidationMessage marca na árvore se o arquivo de configuração é válido
+type FileValidationMessage struct {
+	Path  string `json:"path"`
+	Kind  string `json:"kind"`
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+}
+
+// ReviewFindingsMessage traz os achados da revisão de código da conversa
+type ReviewFindingsMessage struct {
+	ConversationID string           `json:"conversation_id"`
+	Findings       []models.Finding `json:"findings"`
+}
+
+// SecurityScanMessage traz o resultado do scanner de segurança para um arquivo salvo
+type SecurityScanMessage struct {
+	Path   string                 `json:"path"`
+	Issues []models.SecurityIssue `json:"issues"`
+}
+
+// ChangeProposedMessage mostra os hunks de uma mudança que aguarda confirmação
+type ChangeProposedMessage struct {
+	ConversationID string           `json:"conversation_id"`
+	Files          []ProposedChange `json:"files"`
+}
+
+type ProposedChange struct {
+	Path        string `json:"path"`
+	Diff        string `json:"diff"`
+	Created     bool   `json:"created,omitempty"`
+	Deleted     bool   `json:"deleted,omitempty"`
+	Regenerated bool   `json:"regenerated,omitempty"`
 }
 
 type Progress struct {
@@ -52,17 +109,36 @@ type FileContentResponse struct {
 	Content string `json:"content"`
 }
 
-func DownloadProjectHandler(w http.ResponseWriter, r *http.Request) {
+// resolveProject retorna a conversa informada em conversation_id, cujo
+// workspace guarda o projeto, e o nome do projeto usado no download
+func resolveProject(store storage.Storage, r *http.Request) (*models.Conversation, string, bool) {
+	conv, exists := store.GetConversation(r.URL.Query().Get("conversation_id"))
+	if !exists || conv.Workspace == "" {
+		return nil, "", false
+	}
 	projectName := r.URL.Query().Get("project")
 	if projectName == "" {
-		http.Error(w, "Missing project name", http.StatusBadRequest)
-		log.Println("Error: Missing project name")
-		return
+		projectName = defaultAppName
 	}
+	return conv, projectName, true
+}
 
-	// Ajuste o diretório base conforme necessário
-	baseDir := "/Users/jeovahsimoes/Documents/vtkl/ai-sdlc-v3/backend/cmd/server"
-	projectPath := filepath.Join(baseDir, projectName)
+// DownloadProjectHandler compacta o projeto em ZIP. Projetos com achados de
+// segurança acima do limite da política não podem ser baixados.
+func DownloadProjectHandler(store storage.Storage, policy security.Policy) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		downloadProject(store, policy, w, r)
+	}
+}
+
+func downloadProject(store storage.Storage, policy security.Policy, w http.ResponseWriter, r *http.Request) {
+	conv, projectName, ok := resolveProject(store, r)
+	if !ok {
+		http.Error(w, "Missing or unknown conversation_id", http.StatusBadRequest)
+		log.Println("Error: Missing or unknown conversation_id")
+		return
+	}
+	projectPath := conv.Workspace
 	log.Printf("Project path: %s", projectPath)
 
 	// Verifica se o diretório do projeto existe
@@ -72,6 +148,10 @@ func DownloadProjectHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if securityBlocked(w, policy, conv.SecurityIssues, "Download of conversation "+conv.ID) {
+		return
+	}
+
 	// Crie um buffer para armazenar o arquivo ZIP
 	buf := new(bytes.Buffer)
 	zipWriter := zip.NewWriter(buf)
@@ -140,23 +220,25 @@ func DownloadProjectHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // Função para ler o conteúdo do arquivo no disco
-func ReadFileContentHandler(w http.ResponseWriter, r *http.Request) {
-	filePath := r.URL.Query().Get("path")
-	projectName := r.URL.Query().Get("project")
-
-	log.Printf("Received request for file: %s in project: %s", filePath, projectName)
+func ReadFileContentHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		readFileContent(store, w, r)
+	}
+}
 
-	if filePath == "" || projectName == "" {
-		log.Printf("Missing file path or project name")
-		http.Error(w, "Missing file path or project name", http.StatusBadRequest)
+func readFileContent(store storage.Storage, w http.ResponseWriter, r *http.Request) {
+	filePath := r.URL.Query().Get("path")
+	conv, _, ok := resolveProject(store, r)
+	if filePath == "" || !ok {
+		log.Printf("Missing file path or conversation_id")
+		http.Error(w, "Missing file path or unknown conversation_id", http.StatusBadRequest)
 		return
 	}
+	log.Printf("Received request for file: %s in conversation: %s", filePath, conv.ID)
+	projectPath := conv.Workspace
 
-	// Ajuste o diretório base conforme necessário
-	baseDir := "/Users/jeovahsimoes/Documents/vtkl/ai-sdlc-v3/backend/cmd/server"
-
-	// Construa o caminho completo do arquivo
-	fullPath := filepath.Join(baseDir, projectName, filePath)
+	// Construa o caminho completo do arquivo, sem permitir sair do projeto
+	fullPath := filepath.Join(projectPath, filepath.Clean("/"+filePath))
 
 	log.Printf("Attempting to read file from: %s", fullPath)
 
@@ -217,18 +299,79 @@ func GetMessagesHandler(store storage.Storage) http.HandlerFunc {
 	}
 }
 
-func NewChatHandler(store storage.Storage, claudeClient *claude.Client) http.HandlerFunc {
+// PinConversationHandler fixa ou libera uma conversa; conversas fixadas não são
+// removidas pela política de retenção
+func PinConversationHandler(store storage.Storage, locks *storage.Locks) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		conversationID := r.URL.Query().Get("conversation_id")
+		if conversationID == "" {
+			http.Error(w, "Missing conversation_id", http.StatusBadRequest)
+			return
+		}
+
+		pinned, err := strconv.ParseBool(r.URL.Query().Get("pinned"))
+		if err != nil {
+			http.Error(w, "Invalid pinned value", http.StatusBadRequest)
+			return
+		}
+
+		if !lockConversation(w, locks, conversationID) {
+			return
+		}
+		defer locks.Unlock(conversationID)
+
+		conv, exists := store.GetConversation(conversationID)
+		if !exists {
+			http.Error(w, "Conversation not found", http.StatusNotFound)
+			return
+		}
+
+		conv.Pinned = pinned
+		store.UpdateConversation(conv)
+		log.Printf("Conversation %s pinned: %t", conversationID, pinned)
+
+		sendJSONResponse(w, map[string]interface{}{
+			"conversation_id": conversationID,
+			"pinned":          pinned,
+		})
+	}
+}
+
+// lockConversation trava a conversa para uma alteração feita fora do chat.
+// Enquanto um passo executa, a alteração é recusada com 409: o passo grava a
+// sua cópia da conversa ao terminar e sobrescreveria a alteração.
+func lockConversation(w http.ResponseWriter, locks *storage.Locks, conversationID string) bool {
+	if !locks.TryLock(conversationID) {
+		http.Error(w, "A step is still running for this conversation", http.StatusConflict)
+		return false
+	}
+	return true
+}
+
+// NewChatHandler recebe as mensagens do chat pelo WebSocket. Cada passo executa
+// em segundo plano, um por conversa, e as mensagens que ele produz chegam à
+// conexão pelo hub de eventos; uma mensagem "resume" com o último seq recebido
+// reenvia os eventos perdidos durante uma reconexão.
+func NewChatHandler(store storage.Storage, engine *Engine, workspaces *workspace.Manager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		conn, err := upgrader.Upgrade(w, r, nil)
+		ws, err := upgrader.Upgrade(w, r, nil)
 		if err != nil {
 			log.Printf("Error upgrading to WebSocket: %v", err)
 			return
 		}
-		defer conn.Close()
+		defer ws.Close()
+
+		session := newChatSession(ws, engine.events)
+		defer session.close()
 
 		for {
 			var chatReq models.ChatRequest
-			err := conn.ReadJSON(&chatReq)
+			err := ws.ReadJSON(&chatReq)
 			if err != nil {
 				if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 					log.Printf("WebSocket error: %v", err)
@@ -236,309 +379,118 @@ func NewChatHandler(store storage.Storage, claudeClient *claude.Client) http.Han
 				break
 			}
 
-			log.Printf("Received chat request: %+v", chatReq)
-
-			conv, exists := store.GetOrCreateConversation(chatReq.ConversationID)
-			if !exists {
-				conv = &models.Conversation{
-					ID:    chatReq.ConversationID,
-					Steps: []models.Step{},
-				}
-				store.UpdateConversation(conv)
-				log.Printf("Created new conversation with ID: %s", chatReq.ConversationID)
-			} else {
-				log.Printf("Retrieved existing conversation with ID: %s", chatReq.ConversationID)
-			}
-
-			currentStep := len(conv.Steps)
-			log.Printf("Current step: %d", currentStep)
-
-			var claudeResponse string
-			if chatReq.IsConfirmation {
-				claudeResponse, err = handleConfirmation(chatReq.Message, currentStep, conv, claudeClient, store, conn)
-			} else {
-				claudeResponse, err = processNormalMessage(chatReq.Message, currentStep, conv, claudeClient, conn)
-			}
-
-			if err != nil {
-				log.Printf("Error processing message: %v", err)
-				sendWebSocketError(conn, "Error processing message")
+			if chatReq.Type == models.ChatResume {
+				log.Printf("Resuming conversation %s after event %d", chatReq.ConversationID, chatReq.LastSeq)
+				session.follow(chatReq.ConversationID, chatReq.LastSeq)
 				continue
 			}
 
-			currentStep++
-			newStep := models.Step{
-				Number:   currentStep,
-				Input:    chatReq.Message,
-				Response: claudeResponse,
+			log.Printf("Received chat request: %+v", chatReq)
+			// A primeira mensagem chega sem ID; o ID da conversa é sempre gerado aqui
+			if chatReq.ConversationID == "" {
+				chatReq.ConversationID = workspace.NewID()
+			} else if err := workspace.CheckID(chatReq.ConversationID); err != nil {
+				log.Printf("Rejected chat request: %v", err)
+				session.send("error", "Invalid conversation_id")
+				continue
 			}
-			conv.Steps = append(conv.Steps, newStep)
+			session.attach(chatReq.ConversationID)
 
-			store.UpdateConversation(conv)
-			log.Printf("Updated conversation with ID: %s, new step count: %d", chatReq.ConversationID, len(conv.Steps))
-
-			chatResponse := models.ChatResponse{
-				ConversationID:       chatReq.ConversationID,
-				Message:              claudeResponse,
-				StepNumber:           currentStep,
-				RequiresConfirmation: confirmationSteps[currentStep+1],
+			if !engine.running.TryLock(chatReq.ConversationID) {
+				log.Printf("Rejected message for conversation %s: a step is still running", chatReq.ConversationID)
+				session.send("error", "A step is still running for this conversation")
+				continue
 			}
-
-			log.Printf("Sending response: %+v", chatResponse)
-			sendWebSocketMessage(conn, "chat_response", chatResponse)
+			go func(chatReq models.ChatRequest) {
+				defer engine.running.Unlock(chatReq.ConversationID)
+				runChatStep(store, engine, workspaces, chatReq)
+			}(chatReq)
 		}
 	}
 }
 
-func handleConfirmation(answer string, currentStep int, conv *models.Conversation, claudeClient *claude.Client, store storage.Storage, conn *websocket.Conn) (string, error) {
-	log.Printf("Handling confirmation for step %d with answer: %s", currentStep, answer)
-
-	switch currentStep {
-	case 1:
-		if answer == "YES" {
-			log.Println("Confirmation received for step 1. Processing step 2.")
-			return processStep2(conv, claudeClient, store, conn)
-		}
-		return "I understand. Let's revise the JSON structure. What would you like to change?", nil
-	case 2:
-		if answer == "YES" {
-			log.Println("Confirmation received for step 2. Moving to next step.")
-			return "Great! The file contents have been generated. Let's move on to the next step.", nil
+// runChatStep executa um passo da conversa, independente da conexão que o pediu
+func runChatStep(store storage.Storage, engine *Engine, workspaces *workspace.Manager, chatReq models.ChatRequest) {
+	conv, exists := store.GetOrCreateConversation(chatReq.ConversationID)
+	if !exists {
+		dir, err := workspaces.Path(chatReq.ConversationID)
+		if err != nil {
+			log.Printf("Error creating conversation: %v", err)
+			sendWebSocketError(engine.connFor(chatReq.ConversationID), "Invalid conversation_id")
+			return
 		}
-		return "I see. What part of the generated file contents would you like to modify?", nil
-	case 7, 8, 13:
-		if answer == "YES" {
-			return fmt.Sprintf("Excellent! We'll continue with the next phase. (Step %d completed)", currentStep), nil
+		conv = &models.Conversation{
+			ID:        chatReq.ConversationID,
+			Steps:     []models.Step{},
+			Workspace: dir,
 		}
-		return fmt.Sprintf("No problem. Let's revisit step %d. What needs adjustment?", currentStep), nil
-	default:
-		log.Printf("Unexpected confirmation for step %d", currentStep)
-		return "", fmt.Errorf("unexpected confirmation for step %d", currentStep)
-	}
-}
-
-func processNormalMessage(message string, currentStep int, conv *models.Conversation, claudeClient *claude.Client, conn *websocket.Conn) (string, error) {
-	enhancedPrompt := enhancePrompt(message, currentStep+1)
-	response, err := claudeClient.GetResponse(enhancedPrompt)
-	if err != nil {
-		return "", fmt.Errorf("error getting response from Claude: %v", err)
-	}
-
-	// Se estamos no passo 1, enviamos a estrutura JSON para o frontend
-	if currentStep == 0 {
-		sendWebSocketMessage(conn, "project_structure", response)
-	}
-
-	return response, nil
-}
-
-func enhancePrompt(originalPrompt string, currentStep int) string {
-	currentTime := time.Now().Format(time.RFC3339)
-
-	switch currentStep {
-	case 1:
-		promptTemplate := `Based on the following description of a project, provide a simplified JSON representation of the project structure.
-		Description:
-		%s
-		Please respond with a JSON object containing the project structure. The backend must be implemented in the specified backend technology (e.g., Go, Node.js, Python), and the frontend must be implemented in the specified frontend technology (e.g., React, Vue, Angular). Include both backend and frontend if applicable, with nested objects representing directories and arrays for files.
-
-		Ensure to include the following files:
-		1. Any necessary configuration files for package management (e.g., package.json for the frontend, go.mod for Go in the backend, requirements.txt for Python, etc.), representing them as regular files.
-		2. A Dockerfile for both the backend and frontend. The Dockerfile must be suitable for running the backend technology (e.g., Go, Node.js, Python) and the frontend technology (e.g., React, Vue, Angular).
-		3. A docker-compose.yml file to set up the project environment, including separate services for the backend and frontend.
-
-		Be sure that the Dockerfile for the backend and frontend properly sets up the runtime environment, installs dependencies, and runs the application according to best practices for the specified technology.
-
-		Keep the structure as simple as possible while accurately representing the project. Provide only the JSON object, with no additional text or explanations.`
-
-		// Format the template with the original prompt
-		return fmt.Sprintf(promptTemplate, originalPrompt)
-	case 2:
-		return fmt.Sprintf(`Based on the JSON file structure provided in the previous step, generate the content for all files in the structure. Follow these rules:
-
-		1. Use the project name and structure from the JSON as context.
-		2. Generate appropriate content for each file, including code, configuration, or documentation as needed.
-		3. For code files, provide complete, functional code that follows best practices for the respective language or framework.
-		4. For configuration files, include realistic and relevant settings.
-		5. For documentation files like README.md, provide comprehensive information about the project structure, setup, and usage.
-		6. Use comments in the code to explain complex logic or important details.
-		7. Ensure consistency across all files in terms of naming conventions, coding style, and overall architecture.
-		8. If there are dependencies between files, ensure they are properly referenced and imported.
-
-		Present each file's content in the following format:
-
-		--- [File Path] ---
-		[File Content]
-
-		--- [Next File Path] ---
-		[Next File Content]
-
-		...and so on for all files in the structure.
-
-		The JSON structure from the previous step was:
-
-		%s
-
-		Please generate the content for all files based on this structure.`, originalPrompt)
-	default:
-		return fmt.Sprintf(`Interaction #%d:
-		This question was asked at %s.
-		- Additional instructions: 
-		1. Continue to respond based on previous interactions.
-		2. Provide a clear and objective response, and include additional examples if useful.
-		
-		User question: %s`, currentStep, currentTime, originalPrompt)
-	}
-}
-
-func generateAndSaveFileContent(claudeClient *claude.Client, appName, filePath string, conn *websocket.Conn, totalFiles int, filesProcessed *int) error {
-
-	prompt := fmt.Sprintf(`Generate the content for the file: %s
-
-	Use the following rules:
-	1. Provide complete, functional code that follows best practices for the respective language or framework.
-	2. If it's a Dockerfile for the backend, make sure it installs dependencies (e.g., go.mod), compiles the code, and runs the backend service.
-	3. If it's a Dockerfile for the frontend, ensure it installs the necessary frontend dependencies (e.g., Node modules), builds the frontend, and serves the application.
-	4. For configuration files like go.mod and package.json, ensure they use the project name "chat-app-maker" instead of generic placeholders like "github.com/your_username/your_project".
-	5. Ensure consistency in naming conventions, coding style, and architecture across all files.
-
-	Please generate only the content of the file, without any additional explanations or file path indicators.`, filePath)
-
-	response, err := claudeClient.GetResponse(prompt)
-	if err != nil {
-		return fmt.Errorf("error getting response from Claude for file %s: %v", filePath, err)
+		store.UpdateConversation(conv)
+		log.Printf("Created new conversation with ID: %s", chatReq.ConversationID)
+	} else {
+		log.Printf("Retrieved existing conversation with ID: %s", chatReq.ConversationID)
 	}
 
-	if err := saveFileToDisk(appName, filePath, response); err != nil {
-		return fmt.Errorf("error saving file to disk: %v", err)
-	}
+	currentStep := len(conv.Steps)
+	log.Printf("Current step: %d, phase: %s", currentStep, conv.Phase)
+	conn := engine.connFor(conv.ID)
 
-	fileContent := models.FileContent{
-		Path:    filePath,
-		Content: response,
-	}
-	sendWebSocketMessage(conn, "file_content", fileContent)
-
-	*filesProcessed++
-	percentage := (*filesProcessed * 100) / totalFiles
-	sendProgressUpdate(conn, percentage, fmt.Sprintf("Generating: %s", filePath))
-
-	log.Printf("Sent file content for %s to frontend", filePath)
-
-	return nil
-}
+	claudeResponse, err := engine.Handle(conv, chatReq, conn)
 
-func processStep2(conv *models.Conversation, claudeClient *claude.Client, store storage.Storage, conn *websocket.Conn) (string, error) {
-	jsonStructure := conv.Steps[0].Response
-	var projectStructure map[string]interface{}
-	err := json.Unmarshal([]byte(jsonStructure), &projectStructure)
-	if err != nil {
-		return "", fmt.Errorf("error parsing JSON structure: %v", err)
-	}
-
-	appName := "chat-app-maker"
-
-	fileStructure := generateFileList(projectStructure)
-
-	sendWebSocketMessage(conn, "project_structure", fileStructure)
-	sendWebSocketMessage(conn, "status_update", "Generating project files...")
-
-	totalFiles := countFiles(fileStructure)
-	filesProcessed := 0
-
-	var processFiles func(structure map[string]interface{}, path string) error
-	processFiles = func(structure map[string]interface{}, path string) error {
-		for key, value := range structure {
-			newPath := path + "/" + key
-
-			switch v := value.(type) {
-			case []string:
-				// Processar lista de arquivos
-				for _, file := range v {
-					filePath := newPath + "/" + file
-					// Se for um diretório, adicione uma barra no final
-					if !strings.Contains(file, ".") && file != "Dockerfile" {
-						filePath += "/"
-					}
-
-					// Se for um diretório, não gere conteúdo
-					if strings.HasSuffix(filePath, "/") {
-						if err := saveFileToDisk(appName, filePath, ""); err != nil {
-							return fmt.Errorf("error saving directory to disk: %v", err)
-						}
-						filesProcessed++
-						if totalFiles > 0 { // Verificação para evitar divisão por zero
-							percentage := (filesProcessed * 100) / totalFiles
-							sendProgressUpdate(conn, percentage, fmt.Sprintf("Creating directory: %s", filePath))
-						}
-						continue
-					}
-
-					// Geração de conteúdo do arquivo
-					err := generateAndSaveFileContent(claudeClient, appName, filePath, conn, totalFiles, &filesProcessed)
-					if err != nil {
-						return err
-					}
-				}
-			case map[string]interface{}:
-				// Caso o map esteja vazio, tratar como arquivo
-				if len(v) == 0 && (strings.Contains(key, ".") || key == "Dockerfile") {
-					filePath := newPath
-					err := generateAndSaveFileContent(claudeClient, appName, filePath, conn, totalFiles, &filesProcessed)
-					if err != nil {
-						return err
-					}
-				} else {
-					// Recursão para processar diretórios aninhados
-					err := processFiles(v, newPath)
-					if err != nil {
-						return err
-					}
-				}
-			case []interface{}:
-				// Quando o arquivo for do tipo "App.js": [] ou "Dockerfile": [] ou similar, tratar como arquivo regular
-				if len(v) == 0 && (strings.Contains(key, ".") || key == "Dockerfile" || key == "go.mod" || key == "main.go" || key == "docker-compose.yml") {
-					// Este é um arquivo, mesmo que o array esteja vazio
-					filePath := newPath
-					err := generateAndSaveFileContent(claudeClient, appName, filePath, conn, totalFiles, &filesProcessed)
-					if err != nil {
-						return err
-					}
-				}
-			default:
-				// Tratamento especial para arquivos com objetos vazios {}
-				if key == "Dockerfile" || key == "package.json" || key == "App.js" || key == "index.js" || key == "go.mod" || key == "main.go" || key == "docker-compose.yml" || key == ".gitignore" {
-					filePath := newPath
-					err := generateAndSaveFileContent(claudeClient, appName, filePath, conn, totalFiles, &filesProcessed)
-					if err != nil {
-						return err
-					}
-				} else {
-					log.Printf("Unexpected value type for key: %s", key)
-				}
-			}
-		}
-		return nil
+	var invalidTransition *pipeline.InvalidTransitionError
+	if errors.As(err, &invalidTransition) {
+		log.Printf("Rejected message for conversation %s: %v", chatReq.ConversationID, err)
+		sendWebSocketMessage(conn, "invalid_transition", InvalidTransitionMessage{
+			Message:                invalidTransition.Error(),
+			InvalidTransitionError: invalidTransition,
+		})
+		return
 	}
-
-	sendProgressUpdate(conn, 0, "Starting file generation...")
-	err = processFiles(fileStructure, "")
 	if err != nil {
-		return "", err
+		log.Printf("Error processing message: %v", err)
+		// O que o passo já fez, como arquivos gravados, continua valendo
+		store.UpdateConversation(conv)
+		engine.publish(webhook.EventError, conv, ErrorEvent{
+			Step:    currentStep + 1,
+			Phase:   conv.Phase,
+			Message: err.Error(),
+		})
+		sendWebSocketError(conn, "Error processing message")
+		return
 	}
-	sendProgressUpdate(conn, 100, "File generation complete!")
 
+	currentStep++
 	newStep := models.Step{
-		Number:   2,
-		Input:    "Generate file contents",
-		Response: "File contents generated and sent to frontend",
+		Number:   currentStep,
+		Input:    chatReq.Message,
+		Response: claudeResponse,
 	}
+	newStep.PromptVersions = promptVersions(conv, currentStep)
+	newStep.Commit = commitWorkspace(conv, stepCommitMessage(conv, newStep))
 	conv.Steps = append(conv.Steps, newStep)
-	store.UpdateConversation(conv)
-
-	sendWebSocketMessage(conn, "status_update", "Is this the structure you were expecting? Please confirm with YES or NO.")
 
-	return "Great! I've generated the content for all files based on the JSON structure. The files have been sent to the frontend for display.", nil
+	store.UpdateConversation(conv)
+	log.Printf("Updated conversation with ID: %s, new step count: %d", chatReq.ConversationID, len(conv.Steps))
+
+	chatResponse := models.ChatResponse{
+		ConversationID:       conv.ID,
+		Message:              claudeResponse,
+		StepNumber:           currentStep,
+		RequiresConfirmation: pipeline.RequiresConfirmation(conv.State),
+		State:                conv.State,
+	}
+
+	log.Printf("Sending response: %+v", chatResponse)
+	sendWebSocketMessage(conn, "chat_response", chatResponse)
+
+	engine.publish(webhook.EventStepCompleted, conv, StepCompletedEvent{
+		Step:                 currentStep,
+		Phase:                conv.Phase,
+		State:                conv.State,
+		Input:                chatReq.Message,
+		Response:             claudeResponse,
+		RequiresConfirmation: chatResponse.RequiresConfirmation,
+		Commit:               newStep.Commit,
+	})
 }
 
 func generateFileList(structure map[string]interface{}) map[string]interface{} {
@@ -562,7 +514,7 @@ func generateFileList(structure map[string]interface{}) map[string]interface{} {
 	return result
 }
 
-func sendProgressUpdate(conn *websocket.Conn, percentage int, message string) {
+func sendProgressUpdate(conn *eventConn, percentage int, message string) {
 	progress := Progress{Percentage: percentage, Message: message}
 	sendWebSocketMessage(conn, "progress_update", progress)
 }
@@ -620,17 +572,20 @@ func sendJSONResponse(w http.ResponseWriter, data interface{}) {
 	}
 }
 
-func sendWebSocketMessage(conn *websocket.Conn, messageType string, content interface{}) {
-	message := WebSocketMessage{
-		Type:    messageType,
-		Content: content,
-	}
-	if err := conn.WriteJSON(message); err != nil {
-		log.Printf("Error sending WebSocket message: %v", err)
+// eventConn publica as mensagens de um passo no hub de eventos da conversa, de
+// onde elas chegam às conexões WebSocket e aos clientes SSE
+type eventConn struct {
+	hub            *events.Hub
+	conversationID string
+}
+
+func sendWebSocketMessage(conn *eventConn, messageType string, content interface{}) {
+	if _, err := conn.hub.Publish(conn.conversationID, messageType, content); err != nil {
+		log.Printf("Error publishing %s event: %v", messageType, err)
 	}
 }
 
-func sendWebSocketError(conn *websocket.Conn, errorMessage string) {
+func sendWebSocketError(conn *eventConn, errorMessage string) {
 	sendWebSocketMessage(conn, "error", errorMessage)
 }
 
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"backend-ai-sdlc/internal/models"
+	"backend-ai-sdlc/internal/security"
+	"backend-ai-sdlc/internal/storage"
+)
+
+func TestProjectRoutesUseConversationWorkspace(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "backend"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "backend", "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store := storage.NewMemoryStorage()
+	store.UpdateConversation(&models.Conversation{ID: "conv", Workspace: dir})
+
+	download := DownloadProjectHandler(store, security.Policy{})
+	read := ReadFileContentHandler(store)
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		url     string
+		want    int
+	}{
+		{"download", download, "/downloadProject?conversation_id=conv&project=app", http.StatusOK},
+		{"download without conversation", download, "/downloadProject?project=app", http.StatusBadRequest},
+		{"download of unknown conversation", download, "/downloadProject?conversation_id=other", http.StatusBadRequest},
+		{"read file", read, "/readFile?conversation_id=conv&path=/backend/main.go", http.StatusOK},
+		{"read file without conversation", read, "/readFile?project=app&path=/backend/main.go", http.StatusBadRequest},
+		{"read file outside the workspace", read, "/readFile?conversation_id=conv&path=../../etc/passwd", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.handler(rec, httptest.NewRequest(http.MethodGet, tt.url, nil))
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	read(rec, httptest.NewRequest(http.MethodGet, "/readFile?conversation_id=conv&path=/backend/main.go", nil))
+	var resp FileContentResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content != "package main\n" {
+		t.Errorf("readFile content = %q", resp.Content)
+	}
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"backend-ai-sdlc/internal/models"
+	"backend-ai-sdlc/internal/pipeline"
+	"backend-ai-sdlc/internal/prompts"
+)
+
+// PromptInfo descreve um template de prompt em uso e de onde ele vem
+type PromptInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// Source é "directory" para templates do diretório de prompts e "pipeline" para os embutidos
+	Source string `json:"source"`
+	Path   string `json:"path,omitempty"`
+}
+
+// promptVersions reúne a versão de cada template usado nas chamadas ao Claude do passo
+func promptVersions(conv *models.Conversation, step int) map[string]string {
+	versions := make(map[string]string)
+	for _, record := range conv.Prompts {
+		if record.Step == step && record.Template != "" {
+			versions[record.Template] = record.Version
+		}
+	}
+	if len(versions) == 0 {
+		return nil
+	}
+	return versions
+}
+
+// PromptsHandler lista os templates de prompt em uso e suas versões
+func PromptsHandler(def *pipeline.Definition, store *prompts.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		names := make([]string, 0)
+		for name := range def.Prompts() {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		list := make([]PromptInfo, 0, len(names))
+		for _, name := range names {
+			info := PromptInfo{Name: name, Version: def.PromptVersion(), Source: "pipeline"}
+			if store != nil {
+				if t, ok := store.Lookup(name); ok {
+					info = PromptInfo{Name: name, Version: t.Version, Source: "directory", Path: t.Path}
+				}
+			}
+			list = append(list, info)
+		}
+		sendJSONResponse(w, list)
+	}
+}
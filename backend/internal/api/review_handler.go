@@ -0,0 +1,134 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"backend-ai-sdlc/internal/artifacts"
+	"backend-ai-sdlc/internal/models"
+	"backend-ai-sdlc/internal/review"
+	"backend-ai-sdlc/internal/storage"
+)
+
+type ApplyFindingResponse struct {
+	Finding models.Finding `json:"finding"`
+	Content string         `json:"content"`
+}
+
+// ReviewFindingsHandler lista os achados da revisão de código da conversa
+func ReviewFindingsHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		conversationID := r.URL.Query().Get("conversation_id")
+		if conversationID == "" {
+			http.Error(w, "Missing conversation_id", http.StatusBadRequest)
+			return
+		}
+
+		conv, exists := store.GetConversation(conversationID)
+		if !exists {
+			http.Error(w, "Conversation not found", http.StatusNotFound)
+			return
+		}
+
+		findings := conv.Findings
+		if findings == nil {
+			findings = []models.Finding{}
+		}
+		sendJSONResponse(w, ReviewFindingsMessage{
+			ConversationID: conversationID,
+			Findings:       findings,
+		})
+	}
+}
+
+// ApplyFindingHandler aplica no workspace a correção sugerida por um achado
+func ApplyFindingHandler(store storage.Storage, artifactStore *artifacts.Store, locks *storage.Locks) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		conversationID := r.URL.Query().Get("conversation_id")
+		findingID := r.URL.Query().Get("finding_id")
+		if conversationID == "" || findingID == "" {
+			http.Error(w, "Missing conversation_id or finding_id", http.StatusBadRequest)
+			return
+		}
+
+		if !lockConversation(w, locks, conversationID) {
+			return
+		}
+		defer locks.Unlock(conversationID)
+
+		conv, exists := store.GetConversation(conversationID)
+		if !exists {
+			http.Error(w, "Conversation not found", http.StatusNotFound)
+			return
+		}
+
+		index := -1
+		for i, finding := range conv.Findings {
+			if finding.ID == findingID {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			http.Error(w, "Finding not found", http.StatusNotFound)
+			return
+		}
+		finding := conv.Findings[index]
+		if finding.Status != models.FindingOpen {
+			http.Error(w, "Finding was already applied", http.StatusConflict)
+			return
+		}
+
+		if finding.Replacement == nil {
+			http.Error(w, "Finding has no replacement to apply", http.StatusUnprocessableEntity)
+			return
+		}
+
+		content, err := os.ReadFile(filepath.Join(conv.Workspace, filepath.FromSlash(strings.TrimPrefix(finding.Path, "/"))))
+		if err != nil {
+			http.Error(w, "File not found", http.StatusNotFound)
+			return
+		}
+
+		updated, err := review.Apply(string(content), finding)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+
+		if err := saveFileToDisk(conv.Workspace, finding.Path, updated); err != nil {
+			log.Printf("Error applying finding %s: %v", findingID, err)
+			http.Error(w, "Error saving file", http.StatusInternalServerError)
+			return
+		}
+		scanFile(conv, finding.Path, updated)
+		if _, err := artifactStore.Record(conv.ID, finding.Path, len(conv.Steps), []byte(updated)); err != nil {
+			log.Printf("Error recording artifact version: %v", err)
+		}
+
+		conv.Findings[index].Status = models.FindingApplied
+		review.Shift(conv.Findings, finding)
+		commitWorkspace(conv, fmt.Sprintf("Apply review finding %s to %s\n\n%s", finding.ID, finding.Path, finding.Message))
+		store.UpdateConversation(conv)
+		log.Printf("Applied finding %s to %s in conversation %s", findingID, finding.Path, conversationID)
+
+		sendJSONResponse(w, ApplyFindingResponse{
+			Finding: conv.Findings[index],
+			Content: updated,
+		})
+	}
+}
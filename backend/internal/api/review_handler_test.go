@@ -0,0 +1,65 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"backend-ai-sdlc/internal/artifacts"
+	"backend-ai-sdlc/internal/models"
+	"backend-ai-sdlc/internal/storage"
+)
+
+func TestApplyFinding(t *testing.T) {
+	deletion := ""
+	tests := []struct {
+		name        string
+		replacement *string
+		want        int
+		wantContent string
+	}{
+		{"finding without a fix is rejected", nil, http.StatusUnprocessableEntity, "one\ntwo\nthree\n"},
+		{"empty replacement deletes the lines", &deletion, http.StatusOK, "one\nthree\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("one\ntwo\nthree\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			store := storage.NewMemoryStorage()
+			store.UpdateConversation(&models.Conversation{
+				ID:        "conv",
+				Workspace: dir,
+				Findings: []models.Finding{{
+					ID: "F-1", Path: "/main.go", StartLine: 2, EndLine: 2, Original: "two",
+					Replacement: tt.replacement, Status: models.FindingOpen,
+				}},
+			})
+			artifactStore, err := artifacts.NewStore(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			handler := ApplyFindingHandler(store, artifactStore, &storage.Locks{})
+
+			rec := httptest.NewRecorder()
+			handler(rec, httptest.NewRequest(http.MethodPost, "/review/apply?conversation_id=conv&finding_id=F-1", nil))
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+			content, err := os.ReadFile(filepath.Join(dir, "main.go"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tt.wantContent {
+				t.Errorf("content = %q, want %q", content, tt.wantContent)
+			}
+			conv, _ := store.GetConversation("conv")
+			if applied := conv.Findings[0].Status == models.FindingApplied; applied != (tt.want == http.StatusOK) {
+				t.Errorf("finding status = %q", conv.Findings[0].Status)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"backend-ai-sdlc/internal/search"
+)
+
+const defaultSearchLimit = 20
+
+type SearchResponse struct {
+	Query string       `json:"query"`
+	Hits  []search.Hit `json:"hits"`
+}
+
+// SearchHandler busca nos passos das conversas e no código gerado
+func SearchHandler(searcher search.Searcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		query := r.URL.Query().Get("q")
+		if query == "" {
+			http.Error(w, "Missing q", http.StatusBadRequest)
+			return
+		}
+
+		limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+		if err != nil || limit <= 0 {
+			limit = defaultSearchLimit
+		}
+
+		hits := searcher.Search(query, limit)
+		if hits == nil {
+			hits = []search.Hit{}
+		}
+
+		sendJSONResponse(w, SearchResponse{
+			Query: query,
+			Hits:  hits,
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"backend-ai-sdlc/internal/artifacts"
+	"backend-ai-sdlc/internal/models"
+	"backend-ai-sdlc/internal/security"
+	"backend-ai-sdlc/internal/storage"
+)
+
+type SecurityFindingsResponse struct {
+	ConversationID string                            `json:"conversation_id"`
+	Files          map[string][]models.SecurityIssue `json:"files"`
+	// Blocked lista os arquivos que impedem o download pela política atual
+	Blocked []string `json:"blocked"`
+}
+
+// scanFile roda o scanner de segurança no arquivo salvo e guarda o resultado na conversa
+func scanFile(conv *models.Conversation, filePath, content string) []models.SecurityIssue {
+	if strings.HasSuffix(filePath, "/") {
+		return nil
+	}
+
+	filePath = artifacts.NormalizePath(filePath)
+	issues := security.Scan(filePath, []byte(content))
+	if len(issues) == 0 {
+		delete(conv.SecurityIssues, filePath)
+		return issues
+	}
+
+	if conv.SecurityIssues == nil {
+		conv.SecurityIssues = make(map[string][]models.SecurityIssue)
+	}
+	conv.SecurityIssues[filePath] = issues
+	return issues
+}
+
+// securityBlocked responde 403 quando os achados passam do limite da política.
+// Vale para os downloads, o ZIP do projeto e o bundle da conversa. /readFile e
+// /artifacts/blob continuam abertos: servem o editor e o histórico de versões,
+// que o usuário precisa para ver e corrigir os próprios achados.
+func securityBlocked(w http.ResponseWriter, policy security.Policy, issues map[string][]models.SecurityIssue, what string) bool {
+	blocked := policy.Blocking(issues)
+	if len(blocked) == 0 {
+		return false
+	}
+	log.Printf("%s blocked by security policy: %v", what, blocked)
+	http.Error(w, fmt.Sprintf("Download blocked by security policy: %s findings in %s",
+		policy.BlockSeverity, strings.Join(blocked, ", ")), http.StatusForbidden)
+	return true
+}
+
+// SecurityFindingsHandler lista os achados de segurança de cada arquivo da conversa
+func SecurityFindingsHandler(store storage.Storage, policy security.Policy) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		conversationID := r.URL.Query().Get("conversation_id")
+		if conversationID == "" {
+			http.Error(w, "Missing conversation_id", http.StatusBadRequest)
+			return
+		}
+
+		conv, exists := store.GetConversation(conversationID)
+		if !exists {
+			http.Error(w, "Conversation not found", http.StatusNotFound)
+			return
+		}
+
+		files := conv.SecurityIssues
+		if files == nil {
+			files = map[string][]models.SecurityIssue{}
+		}
+		blocked := policy.Blocking(files)
+		if blocked == nil {
+			blocked = []string{}
+		}
+
+		sendJSONResponse(w, SecurityFindingsResponse{
+			ConversationID: conversationID,
+			Files:          files,
+			Blocked:        blocked,
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"backend-ai-sdlc/internal/models"
+	"backend-ai-sdlc/internal/security"
+	"backend-ai-sdlc/internal/storage"
+)
+
+func TestExportBlockedBySecurityPolicy(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	store.UpdateConversation(&models.Conversation{
+		ID:        "conv",
+		Workspace: t.TempDir(),
+		SecurityIssues: map[string][]models.SecurityIssue{
+			"config.go": {{Rule: "aws-access-key", Severity: security.SeverityHigh}},
+		},
+	})
+
+	tests := []struct {
+		name   string
+		policy security.Policy
+		want   int
+	}{
+		{"blocked", security.Policy{BlockSeverity: security.SeverityHigh}, http.StatusForbidden},
+		{"below the threshold", security.Policy{BlockSeverity: ""}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/conversations/export?conversation_id=conv", nil)
+			ExportConversationHandler(store, tt.policy)(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"backend-ai-sdlc/internal/events"
+)
+
+// wsWriteTimeout limita o tempo de uma escrita em uma conexão que parou de ler
+const wsWriteTimeout = 10 * time.Second
+
+// chatSession liga uma conexão WebSocket aos eventos de uma conversa. Os
+// passos publicam as mensagens no hub, e a sessão as repassa à conexão com o
+// número de sequência; uma nova conexão retoma do último número recebido.
+type chatSession struct {
+	ws  *websocket.Conn
+	hub *events.Hub
+
+	// mu protege a assinatura atual e as escritas na conexão
+	mu             sync.Mutex
+	conversationID string
+	sub            *events.Subscription
+	closed         bool
+}
+
+func newChatSession(ws *websocket.Conn, hub *events.Hub) *chatSession {
+	return &chatSession{ws: ws, hub: hub}
+}
+
+// follow passa a repassar os eventos da conversa publicados depois de lastSeq
+func (s *chatSession) follow(conversationID string, lastSeq uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribe(conversationID, lastSeq)
+}
+
+// attach segue a conversa a partir do próximo evento, se ela ainda não é a seguida
+func (s *chatSession) attach(conversationID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sub == nil || s.conversationID != conversationID {
+		s.subscribe(conversationID, s.hub.LastID(conversationID))
+	}
+}
+
+// subscribe troca a assinatura da sessão; deve ser chamado com mu travado
+func (s *chatSession) subscribe(conversationID string, lastSeq uint64) {
+	if s.closed {
+		return
+	}
+	if s.sub != nil {
+		s.sub.Close()
+	}
+	sub, replay, complete := s.hub.Subscribe(conversationID, lastSeq)
+	s.sub, s.conversationID = sub, conversationID
+	go s.forward(sub, conversationID, replay, complete)
+}
+
+// forward escreve os eventos da assinatura na conexão. Se a conexão não
+// acompanhar os eventos e o hub encerrar a assinatura, ela é refeita a partir
+// do último evento escrito, sem bloquear a geração.
+func (s *chatSession) forward(sub *events.Subscription, conversationID string, replay []events.Event, complete bool) {
+	if !complete && !s.write(sub, WebSocketMessage{Type: "resync", Content: map[string]uint64{"last_seq": s.hub.LastID(conversationID)}}) {
+		return
+	}
+
+	var last uint64
+	for _, event := range replay {
+		if !s.writeEvent(sub, event) {
+			return
+		}
+		last = event.ID
+	}
+	for event := range sub.C {
+		if !s.writeEvent(sub, event) {
+			return
+		}
+		last = event.ID
+	}
+
+	if sub.Dropped() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.sub == sub {
+			log.Printf("WebSocket client of conversation %s fell behind, resuming from event %d", conversationID, last)
+			s.subscribe(conversationID, last)
+		}
+	}
+}
+
+func (s *chatSession) writeEvent(sub *events.Subscription, event events.Event) bool {
+	return s.write(sub, WebSocketMessage{Type: event.Type, Content: event.Content, Seq: event.ID})
+}
+
+// write envia a mensagem se sub ainda é a assinatura atual da sessão
+func (s *chatSession) write(sub *events.Subscription, message WebSocketMessage) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || s.sub != sub {
+		return false
+	}
+	s.ws.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if err := s.ws.WriteJSON(message); err != nil {
+		log.Printf("Error sending WebSocket message: %v", err)
+		return false
+	}
+	return true
+}
+
+// send escreve uma mensagem que não faz parte dos eventos da conversa
+func (s *chatSession) send(messageType string, content interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.ws.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if err := s.ws.WriteJSON(WebSocketMessage{Type: messageType, Content: content}); err != nil {
+		log.Printf("Error sending WebSocket message: %v", err)
+	}
+}
+
+// close encerra a assinatura; os passos em execução continuam publicando no hub
+func (s *chatSession) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.sub != nil {
+		s.sub.Close()
+		s.sub = nil
+	}
+}
@@ -1,29 +1,88 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
+	"strconv"
+	"time"
+
+	"backend-ai-sdlc/internal/events"
+	"backend-ai-sdlc/internal/storage"
 )
 
-var messageChan = make(chan string)
+// sseKeepAlive é o intervalo dos comentários que mantêm o stream aberto em proxies
+const sseKeepAlive = 15 * time.Second
 
-func NewSSEHandler() http.HandlerFunc {
+// ConversationEventsHandler transmite os eventos de uma conversa por
+// Server-Sent Events (GET /conversations/{id}/events), para clientes que só
+// leem. O cabeçalho Last-Event-ID, ou o parâmetro last_event_id, retoma o
+// stream a partir do último evento recebido. Um evento "resync" avisa que
+// parte dos eventos perdidos já não está disponível e que o estado da conversa
+// deve ser recarregado.
+func ConversationEventsHandler(hub *events.Hub, store storage.Storage) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "text/event-stream")
-		w.Header().Set("Cache-Control", "no-cache")
-		w.Header().Set("Connection", "keep-alive")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
+		conversationID := r.PathValue("id")
+		if _, exists := store.GetConversation(conversationID); !exists {
+			http.Error(w, "Conversation not found", http.StatusNotFound)
+			return
+		}
+
+		lastID := r.Header.Get("Last-Event-ID")
+		if lastID == "" {
+			lastID = r.URL.Query().Get("last_event_id")
+		}
+		var after uint64
+		if lastID != "" {
+			var err error
+			if after, err = strconv.ParseUint(lastID, 10, 64); err != nil {
+				http.Error(w, "Invalid Last-Event-ID", http.StatusBadRequest)
+				return
+			}
+		}
 
 		flusher, ok := w.(http.Flusher)
 		if !ok {
-			http.Error(w, "Streaming unsupported!", http.StatusInternalServerError)
+			http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
 			return
 		}
 
+		sub, replay, complete := hub.Subscribe(conversationID, after)
+		defer sub.Close()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+
+		if !complete {
+			fmt.Fprintf(w, "event: resync\ndata: {\"last_event_id\":%d}\n\n", hub.LastID(conversationID))
+		}
+		for _, event := range replay {
+			if err := writeSSEEvent(w, event); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+
+		keepAlive := time.NewTicker(sseKeepAlive)
+		defer keepAlive.Stop()
 		for {
 			select {
-			case message := <-messageChan:
-				fmt.Fprintf(w, "data: %s\n\n", message)
+			case event, open := <-sub.C:
+				if !open {
+					if sub.Dropped() {
+						log.Printf("Dropped slow SSE client of conversation %s", conversationID)
+					}
+					return
+				}
+				if err := writeSSEEvent(w, event); err != nil {
+					return
+				}
+				flusher.Flush()
+			case <-keepAlive.C:
+				fmt.Fprint(w, ": keep-alive\n\n")
 				flusher.Flush()
 			case <-r.Context().Done():
 				return
@@ -32,6 +91,12 @@ func NewSSEHandler() http.HandlerFunc {
 	}
 }
 
-func SendSSEMessage(message string) {
-	messageChan <- message
+// writeSSEEvent escreve o evento com o mesmo formato das mensagens do WebSocket
+func writeSSEEvent(w http.ResponseWriter, event events.Event) error {
+	data, err := json.Marshal(WebSocketMessage{Type: event.Type, Content: event.Content})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
 }
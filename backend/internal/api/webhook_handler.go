@@ -0,0 +1,197 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"backend-ai-sdlc/internal/models"
+	"backend-ai-sdlc/internal/webhook"
+	"backend-ai-sdlc/internal/workspace"
+)
+
+// StepCompletedEvent é o conteúdo do evento step_completed
+type StepCompletedEvent struct {
+	Step                 int          `json:"step"`
+	Phase                string       `json:"phase"`
+	State                models.State `json:"state"`
+	Input                string       `json:"input"`
+	Response             string       `json:"response"`
+	RequiresConfirmation bool         `json:"requires_confirmation"`
+	Commit               string       `json:"commit,omitempty"`
+}
+
+// GenerationFinishedEvent é o conteúdo do evento generation_finished, enviado
+// quando uma fase que grava arquivos termina
+type GenerationFinishedEvent struct {
+	Step  int    `json:"step"`
+	Phase string `json:"phase"`
+	// Files é o total de arquivos no workspace depois da fase
+	Files int `json:"files"`
+}
+
+// ErrorEvent é o conteúdo do evento error
+type ErrorEvent struct {
+	Step    int    `json:"step"`
+	Phase   string `json:"phase"`
+	Message string `json:"message"`
+}
+
+// SetWebhooks define o dispatcher que entrega os eventos das conversas aos assinantes
+func (e *Engine) SetWebhooks(dispatcher *webhook.Dispatcher) {
+	e.webhooks = dispatcher
+}
+
+func (e *Engine) publish(eventType string, conv *models.Conversation, data interface{}) {
+	if e.webhooks != nil {
+		e.webhooks.Publish(eventType, conv.ID, data)
+	}
+}
+
+// publishGenerationFinished avisa os assinantes que a fase terminou de gravar os arquivos
+func (e *Engine) publishGenerationFinished(conv *models.Conversation, phase string) {
+	files, err := workspace.Files(conv.Workspace)
+	if err != nil {
+		log.Printf("Error listing workspace files for conversation %s: %v", conv.ID, err)
+	}
+	e.publish(webhook.EventGenerationFinished, conv, GenerationFinishedEvent{
+		Step:  len(conv.Steps) + 1,
+		Phase: phase,
+		Files: len(files),
+	})
+}
+
+// RequireAdminToken protege as rotas de administração dos webhooks: a
+// requisição precisa do cabeçalho "Authorization: Bearer <token>". Sem token
+// configurado, as rotas ficam desativadas.
+func RequireAdminToken(token string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token == "" {
+			http.Error(w, "Webhook administration is disabled", http.StatusForbidden)
+			return
+		}
+		given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// WebhooksHandler lista (GET) ou registra (POST) assinantes. Um assinante com
+// conversation_id recebe apenas os eventos daquela conversa.
+func WebhooksHandler(dispatcher *webhook.Dispatcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			sendJSONResponse(w, dispatcher.Subscribers(r.URL.Query().Get("conversation_id")))
+		case http.MethodPost:
+			var sub webhook.Subscriber
+			if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+			sub, err := dispatcher.Subscribe(sub)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			log.Printf("Registered webhook %s for %s", sub.ID, sub.URL)
+			w.WriteHeader(http.StatusCreated)
+			sendJSONResponse(w, sub)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// UnsubscribeWebhookHandler remove um assinante
+func UnsubscribeWebhookHandler(dispatcher *webhook.Dispatcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id := r.URL.Query().Get("id")
+		if id == "" {
+			http.Error(w, "Missing id", http.StatusBadRequest)
+			return
+		}
+		if !dispatcher.Unsubscribe(id) {
+			http.Error(w, "Webhook not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Removed webhook %s", id)
+		sendJSONResponse(w, map[string]string{"id": id})
+	}
+}
+
+// WebhookDeliveriesHandler retorna o log de entregas, das mais recentes para as mais antigas
+func WebhookDeliveriesHandler(dispatcher *webhook.Dispatcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		limit := 100
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				http.Error(w, "Invalid limit", http.StatusBadRequest)
+				return
+			}
+			limit = n
+		}
+
+		query := r.URL.Query()
+		deliveries := dispatcher.Deliveries(query.Get("conversation_id"), query.Get("subscriber_id"), limit)
+		if deliveries == nil {
+			deliveries = []webhook.Delivery{}
+		}
+		sendJSONResponse(w, deliveries)
+	}
+}
+
+// WebhookDeadLettersHandler lista os eventos que esgotaram as tentativas de entrega
+func WebhookDeadLettersHandler(dispatcher *webhook.Dispatcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		letters := dispatcher.DeadLetters()
+		if letters == nil {
+			letters = []webhook.DeadLetter{}
+		}
+		sendJSONResponse(w, letters)
+	}
+}
+
+// WebhookRedeliverHandler reenvia um evento da fila de mensagens mortas
+func WebhookRedeliverHandler(dispatcher *webhook.Dispatcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id := r.URL.Query().Get("id")
+		if id == "" {
+			http.Error(w, "Missing id", http.StatusBadRequest)
+			return
+		}
+		if err := dispatcher.Redeliver(id); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		log.Printf("Redelivering dead letter %s", id)
+		sendJSONResponse(w, map[string]string{"id": id})
+	}
+}
@@ -0,0 +1,104 @@
+package artifacts
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{
+			name: "identical",
+			a:    "one\ntwo\n",
+			b:    "one\ntwo\n",
+			want: "--- a\n+++ b\n",
+		},
+		{
+			name: "changed line",
+			a:    "one\ntwo\nthree\n",
+			b:    "one\nTWO\nthree\n",
+			want: "--- a\n+++ b\n@@ -1,3 +1,3 @@\n one\n-two\n+TWO\n three\n",
+		},
+		{
+			name: "new file",
+			a:    "",
+			b:    "one\ntwo\n",
+			want: "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+one\n+two\n",
+		},
+		{
+			name: "deleted file",
+			a:    "one\n",
+			b:    "",
+			want: "--- a\n+++ b\n@@ -1,1 +0,0 @@\n-one\n",
+		},
+		{
+			name: "distant changes make separate hunks",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			b:    "one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n",
+			want: "--- a\n+++ b\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+ten\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UnifiedDiff("a", "b", tt.a, tt.b); got != tt.want {
+				t.Errorf("UnifiedDiff() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffLinesIsMinimal(t *testing.T) {
+	tests := []struct {
+		a, b   string
+		common int
+	}{
+		{"abcabba", "cbabac", 4},
+		{"abc", "xyz", 0},
+		{"aaaa", "aa", 2},
+		{"abcdef", "bcdefa", 5},
+		{"xaxbxc", "abc", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			a, b := strings.Split(tt.a, ""), strings.Split(tt.b, "")
+			ops := diffLines(a, b)
+
+			var gotA, gotB []string
+			common := 0
+			for _, op := range ops {
+				if op.kind != '+' {
+					gotA = append(gotA, op.line)
+				}
+				if op.kind != '-' {
+					gotB = append(gotB, op.line)
+				}
+				if op.kind == ' ' {
+					common++
+				}
+			}
+			if strings.Join(gotA, "") != tt.a || strings.Join(gotB, "") != tt.b {
+				t.Fatalf("operations rebuild %q and %q", strings.Join(gotA, ""), strings.Join(gotB, ""))
+			}
+			if common != tt.common {
+				t.Errorf("common lines = %d, want %d", common, tt.common)
+			}
+		})
+	}
+}
+
+func TestUnifiedDiffLargeFiles(t *testing.T) {
+	var a, b strings.Builder
+	for i := 0; i < 100000; i++ {
+		fmt.Fprintf(&a, "line %d\n", i)
+		fmt.Fprintf(&b, "other %d\n", i)
+	}
+	diff := UnifiedDiff("a", "b", a.String(), b.String())
+	if want := "@@ -1,100000 +1,100000 @@\n"; !strings.Contains(diff, want) {
+		t.Errorf("diff of unrelated files does not have a single hunk %q", want)
+	}
+}
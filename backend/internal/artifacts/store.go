@@ -0,0 +1,332 @@
+package artifacts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Version é uma versão de um arquivo gerado ou editado, apontando para o blob pelo hash
+type Version struct {
+	Hash      string    `json:"hash"`
+	Size      int       `json:"size"`
+	Step      int       `json:"step"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Index mapeia, para uma conversa, cada caminho de arquivo para a lista de versões
+type Index struct {
+	ConversationID string               `json:"conversation_id"`
+	Files          map[string][]Version `json:"files"`
+}
+
+type GCResult struct {
+	Removed    int   `json:"removed"`
+	FreedBytes int64 `json:"freed_bytes"`
+	Kept       int   `json:"kept"`
+}
+
+// Store guarda o conteúdo dos arquivos como blobs endereçados por SHA-256.
+// Blobs idênticos são armazenados uma única vez, mesmo entre projetos diferentes.
+type Store struct {
+	root     string
+	mu       sync.Mutex
+	onRecord []func(conversationID, filePath string, content []byte)
+	onRemove []func(conversationID string, paths []string)
+}
+
+func NewStore(root string) (*Store, error) {
+	for _, dir := range []string{filepath.Join(root, "blobs"), filepath.Join(root, "index")} {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return nil, fmt.Errorf("error creating artifact directory: %v", err)
+		}
+	}
+	return &Store{root: root}, nil
+}
+
+// OnRecord registra uma função chamada a cada nova versão gravada com Record,
+// por exemplo para alimentar o índice de busca
+func (s *Store) OnRecord(fn func(conversationID, filePath string, content []byte)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onRecord = append(s.onRecord, fn)
+}
+
+// OnRemove registra uma função chamada quando arquivos saem do workspace,
+// para que o índice de busca não continue apontando para eles
+func (s *Store) OnRemove(fn func(conversationID string, paths []string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onRemove = append(s.onRemove, fn)
+}
+
+// RecordRemoval avisa que os arquivos foram removidos do workspace. O
+// histórico de versões é mantido; só as funções de OnRemove são chamadas.
+func (s *Store) RecordRemoval(conversationID string, paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+	normalized := make([]string, len(paths))
+	for i, p := range paths {
+		normalized[i] = NormalizePath(p)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, fn := range s.onRemove {
+		fn(conversationID, normalized)
+	}
+}
+
+// Put grava o conteúdo como blob e retorna o hash
+func (s *Store) Put(content []byte) (string, error) {
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])
+
+	blobPath := s.blobPath(hash)
+	if _, err := os.Stat(blobPath); err == nil {
+		return hash, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(blobPath), os.ModePerm); err != nil {
+		return "", fmt.Errorf("error creating blob directory: %v", err)
+	}
+
+	// Escreve em um arquivo temporário e renomeia para não deixar blobs incompletos
+	tmp, err := os.CreateTemp(filepath.Dir(blobPath), hash+".tmp")
+	if err != nil {
+		return "", fmt.Errorf("error creating blob: %v", err)
+	}
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("error writing blob: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("error closing blob: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), blobPath); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("error renaming blob: %v", err)
+	}
+
+	return hash, nil
+}
+
+func (s *Store) Get(hash string) ([]byte, error) {
+	if !isHash(hash) {
+		return nil, fmt.Errorf("invalid blob hash: %s", hash)
+	}
+	content, err := os.ReadFile(s.blobPath(hash))
+	if err != nil {
+		return nil, fmt.Errorf("error reading blob %s: %v", hash, err)
+	}
+	return content, nil
+}
+
+// Record grava uma nova versão do arquivo no índice da conversa. Se o conteúdo
+// for igual ao da última versão, nenhuma versão nova é criada.
+func (s *Store) Record(conversationID, filePath string, step int, content []byte) (Version, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hash, err := s.Put(content)
+	if err != nil {
+		return Version{}, err
+	}
+
+	index, err := s.loadIndex(conversationID)
+	if err != nil {
+		return Version{}, err
+	}
+
+	filePath = NormalizePath(filePath)
+	versions := index.Files[filePath]
+	if len(versions) > 0 && versions[len(versions)-1].Hash == hash {
+		return versions[len(versions)-1], nil
+	}
+
+	version := Version{
+		Hash:      hash,
+		Size:      len(content),
+		Step:      step,
+		CreatedAt: time.Now().UTC(),
+	}
+	index.Files[filePath] = append(versions, version)
+
+	if err := s.saveIndex(index); err != nil {
+		return Version{}, err
+	}
+
+	for _, fn := range s.onRecord {
+		fn(conversationID, filePath, content)
+	}
+	return version, nil
+}
+
+func (s *Store) Index(conversationID string) (*Index, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loadIndex(conversationID)
+}
+
+func (s *Store) Versions(conversationID, filePath string) ([]Version, error) {
+	index, err := s.Index(conversationID)
+	if err != nil {
+		return nil, err
+	}
+	return index.Files[NormalizePath(filePath)], nil
+}
+
+// Diff retorna o diff unificado entre duas versões (índices a partir de 1) de um arquivo
+func (s *Store) Diff(conversationID, filePath string, from, to int) (string, error) {
+	versions, err := s.Versions(conversationID, filePath)
+	if err != nil {
+		return "", err
+	}
+	if from < 1 || from > len(versions) || to < 1 || to > len(versions) {
+		return "", fmt.Errorf("version out of range: file %s has %d versions", filePath, len(versions))
+	}
+
+	oldContent, err := s.Get(versions[from-1].Hash)
+	if err != nil {
+		return "", err
+	}
+	newContent, err := s.Get(versions[to-1].Hash)
+	if err != nil {
+		return "", err
+	}
+
+	filePath = NormalizePath(filePath)
+	return UnifiedDiff(
+		fmt.Sprintf("%s@v%d", filePath, from),
+		fmt.Sprintf("%s@v%d", filePath, to),
+		string(oldContent),
+		string(newContent),
+	), nil
+}
+
+// DeleteIndex remove o índice da conversa; os blobs são liberados no próximo GC
+func (s *Store) DeleteIndex(conversationID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := os.Remove(s.indexPath(conversationID))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error deleting index: %v", err)
+	}
+	return nil
+}
+
+// GC remove os blobs que não são referenciados por nenhum índice
+func (s *Store) GC() (GCResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result GCResult
+	referenced := make(map[string]bool)
+
+	indexFiles, err := filepath.Glob(filepath.Join(s.root, "index", "*.json"))
+	if err != nil {
+		return result, fmt.Errorf("error listing indexes: %v", err)
+	}
+	for _, indexFile := range indexFiles {
+		index, err := readIndex(indexFile)
+		if err != nil {
+			return result, err
+		}
+		for _, versions := range index.Files {
+			for _, version := range versions {
+				referenced[version.Hash] = true
+			}
+		}
+	}
+
+	err = filepath.Walk(filepath.Join(s.root, "blobs"), func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if referenced[info.Name()] {
+			result.Kept++
+			return nil
+		}
+		if err := os.Remove(p); err != nil {
+			return err
+		}
+		result.Removed++
+		result.FreedBytes += info.Size()
+		return nil
+	})
+	if err != nil {
+		return result, fmt.Errorf("error collecting blobs: %v", err)
+	}
+
+	return result, nil
+}
+
+// NormalizePath converte caminhos como "/backend/main.go" para "backend/main.go"
+func NormalizePath(filePath string) string {
+	return strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(filePath)), "/")
+}
+
+func (s *Store) loadIndex(conversationID string) (*Index, error) {
+	index, err := readIndex(s.indexPath(conversationID))
+	if os.IsNotExist(err) {
+		return &Index{ConversationID: conversationID, Files: make(map[string][]Version)}, nil
+	}
+	return index, err
+}
+
+func (s *Store) saveIndex(index *Index) error {
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding index: %v", err)
+	}
+	if err := os.WriteFile(s.indexPath(index.ConversationID), data, 0644); err != nil {
+		return fmt.Errorf("error writing index: %v", err)
+	}
+	return nil
+}
+
+func readIndex(indexPath string) (*Index, error) {
+	data, err := os.ReadFile(indexPath)
+	if err != nil {
+		return nil, err
+	}
+	var index Index
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("error parsing index %s: %v", indexPath, err)
+	}
+	if index.Files == nil {
+		index.Files = make(map[string][]Version)
+	}
+	return &index, nil
+}
+
+func (s *Store) blobPath(hash string) string {
+	return filepath.Join(s.root, "blobs", hash[:2], hash)
+}
+
+func (s *Store) indexPath(conversationID string) string {
+	return filepath.Join(s.root, "index", url.PathEscape(conversationID)+".json")
+}
+
+func isHash(hash string) bool {
+	if len(hash) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
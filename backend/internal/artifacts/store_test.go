@@ -0,0 +1,149 @@
+package artifacts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordDeduplicates(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := store.Record("conv-a", "/backend/main.go", 1, []byte("package main\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := store.Record("conv-a", "backend/main.go", 2, []byte("package main\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != first {
+		t.Errorf("recording the same content created a new version: %+v", again)
+	}
+	if _, err := store.Record("conv-b", "/main.go", 1, []byte("package main\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Record("conv-a", "/backend/main.go", 3, []byte("package main\n\nfunc main() {}\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	versions, err := store.Versions("conv-a", "/backend/main.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 || versions[0].Step != 1 || versions[1].Step != 3 {
+		t.Errorf("versions = %+v, want steps 1 and 3", versions)
+	}
+	if blobs := countBlobs(t, store); blobs != 2 {
+		t.Errorf("blobs = %d, want 2: identical content is stored once across conversations", blobs)
+	}
+}
+
+func TestGCRemovesUnreferencedBlobs(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	shared := []byte("shared\n")
+	if _, err := store.Record("conv-a", "/a.txt", 1, shared); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Record("conv-a", "/only-a.txt", 1, []byte("only a\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Record("conv-b", "/b.txt", 1, shared); err != nil {
+		t.Fatal(err)
+	}
+	orphan, err := store.Put([]byte("orphan\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.DeleteIndex("conv-a"); err != nil {
+		t.Fatal(err)
+	}
+	result, err := store.GC()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Removed != 2 || result.Kept != 1 || result.FreedBytes != int64(len("only a\n")+len("orphan\n")) {
+		t.Errorf("GC() = %+v, want 2 removed and 1 kept", result)
+	}
+	if _, err := store.Get(orphan); err == nil {
+		t.Error("unreferenced blob survived the GC")
+	}
+	versions, err := store.Versions("conv-b", "/b.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content, err := store.Get(versions[0].Hash); err != nil || string(content) != string(shared) {
+		t.Errorf("blob still referenced by conv-b = %q, %v", content, err)
+	}
+}
+
+func TestStoreDiff(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for step, content := range []string{"one\ntwo\n", "one\n2\n"} {
+		if _, err := store.Record("conv", "/f.txt", step+1, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	diff, err := store.Diff("conv", "f.txt", 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "--- f.txt@v1\n+++ f.txt@v2\n@@ -1,2 +1,2 @@\n one\n-two\n+2\n"; diff != want {
+		t.Errorf("Diff() =\n%s\nwant\n%s", diff, want)
+	}
+	if _, err := store.Diff("conv", "f.txt", 1, 3); err == nil || !strings.Contains(err.Error(), "out of range") {
+		t.Errorf("Diff() of a missing version error = %v", err)
+	}
+}
+
+func countBlobs(t *testing.T, store *Store) int {
+	t.Helper()
+	count := 0
+	err := filepath.Walk(filepath.Join(store.root, "blobs"), func(_ string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			count++
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestRecordRemovalKeepsVersions(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var removed []string
+	store.OnRemove(func(conversationID string, paths []string) {
+		for _, p := range paths {
+			removed = append(removed, conversationID+":"+p)
+		}
+	})
+	if _, err := store.Record("conv", "/main.go", 1, []byte("package main\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	store.RecordRemoval("conv", nil)
+	store.RecordRemoval("conv", []string{"/main.go", "web/index.html"})
+	if want := []string{"conv:main.go", "conv:web/index.html"}; strings.Join(removed, ",") != strings.Join(want, ",") {
+		t.Errorf("removed = %v, want %v", removed, want)
+	}
+	if versions, err := store.Versions("conv", "main.go"); err != nil || len(versions) != 1 {
+		t.Errorf("versions after the removal = %+v, %v", versions, err)
+	}
+}
@@ -0,0 +1,258 @@
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"backend-ai-sdlc/internal/models"
+)
+
+// FormatVersion é a versão atual do formato do bundle. Sempre que o formato
+// mudar, incremente a versão e registre um upgrader em upgraders.
+const FormatVersion = 2
+
+const (
+	// maxEntrySize limita o tamanho descompactado de cada entrada do bundle
+	maxEntrySize = 50 << 20
+	// maxUnpackedSize limita o total descompactado de todas as entradas
+	maxUnpackedSize = 200 << 20
+)
+
+const (
+	manifestEntry     = "manifest.json"
+	conversationEntry = "conversation.json"
+	structureEntry    = "structure.json"
+	filesPrefix       = "files/"
+)
+
+type Manifest struct {
+	FormatVersion  int       `json:"format_version"`
+	ExportedAt     time.Time `json:"exported_at"`
+	ConversationID string    `json:"conversation_id"`
+	Files          []string  `json:"files"`
+}
+
+// Bundle é uma conversa exportada: log de passos, estrutura, prompts,
+// consumo de tokens e os arquivos gerados no workspace
+type Bundle struct {
+	Manifest     Manifest
+	Conversation *models.Conversation
+	Files        map[string][]byte
+}
+
+// upgraders convertem um bundle da versão indicada para a versão seguinte
+var upgraders = map[int]func(b *Bundle) error{
+	// A versão 2 passou a exigir o estado explícito da conversa
+	1: func(b *Bundle) error {
+		conv := b.Conversation
+		if conv.State != "" {
+			return nil
+		}
+		switch {
+		case conv.ProjectCreated:
+			conv.State = models.StateComplete
+		case conv.Structure != "":
+			conv.State = models.StateStructureProposed
+		default:
+			conv.State = models.StateAwaitingDescription
+		}
+		return nil
+	},
+}
+
+// Export escreve a conversa e os arquivos do seu workspace como um arquivo ZIP
+func Export(w io.Writer, conv *models.Conversation) error {
+	files, err := collectFiles(conv.Workspace)
+	if err != nil {
+		return fmt.Errorf("error collecting workspace files: %v", err)
+	}
+
+	manifest := Manifest{
+		FormatVersion:  FormatVersion,
+		ExportedAt:     time.Now().UTC(),
+		ConversationID: conv.ID,
+	}
+	for filePath := range files {
+		manifest.Files = append(manifest.Files, filePath)
+	}
+	sort.Strings(manifest.Files)
+
+	zipWriter := zip.NewWriter(w)
+
+	entries := []struct {
+		name string
+		data interface{}
+	}{
+		{manifestEntry, manifest},
+		{conversationEntry, conv},
+	}
+	for _, entry := range entries {
+		if err := writeJSON(zipWriter, entry.name, entry.data); err != nil {
+			return err
+		}
+	}
+
+	if conv.Structure != "" {
+		if err := writeEntry(zipWriter, structureEntry, []byte(conv.Structure)); err != nil {
+			return err
+		}
+	}
+
+	for _, filePath := range manifest.Files {
+		if err := writeEntry(zipWriter, filesPrefix+filePath, files[filePath]); err != nil {
+			return err
+		}
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		return fmt.Errorf("error closing zip writer: %v", err)
+	}
+	return nil
+}
+
+// Import lê um bundle exportado, aplicando os upgraders necessários para
+// que bundles de versões anteriores continuem carregando
+func Import(r io.ReaderAt, size int64) (*Bundle, error) {
+	zipReader, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("error opening bundle: %v", err)
+	}
+
+	b := &Bundle{Files: make(map[string][]byte)}
+	var hasManifest, hasConversation bool
+
+	// O tamanho declarado no zip não é confiável: o limite vale para os bytes descompactados
+	remaining := int64(maxUnpackedSize)
+	for _, f := range zipReader.File {
+		data, err := readEntry(f, min(maxEntrySize, remaining))
+		if err != nil {
+			return nil, err
+		}
+		remaining -= int64(len(data))
+
+		switch {
+		case f.Name == manifestEntry:
+			if err := json.Unmarshal(data, &b.Manifest); err != nil {
+				return nil, fmt.Errorf("error parsing manifest: %v", err)
+			}
+			hasManifest = true
+		case f.Name == conversationEntry:
+			if err := json.Unmarshal(data, &b.Conversation); err != nil {
+				return nil, fmt.Errorf("error parsing conversation: %v", err)
+			}
+			hasConversation = true
+		case strings.HasPrefix(f.Name, filesPrefix) && !strings.HasSuffix(f.Name, "/"):
+			filePath, err := cleanPath(strings.TrimPrefix(f.Name, filesPrefix))
+			if err != nil {
+				return nil, err
+			}
+			b.Files[filePath] = data
+		}
+	}
+
+	if !hasManifest || !hasConversation {
+		return nil, fmt.Errorf("bundle is missing %s or %s", manifestEntry, conversationEntry)
+	}
+	if b.Manifest.FormatVersion > FormatVersion {
+		return nil, fmt.Errorf("bundle format version %d is newer than supported version %d", b.Manifest.FormatVersion, FormatVersion)
+	}
+
+	for version := b.Manifest.FormatVersion; version < FormatVersion; version++ {
+		upgrade, ok := upgraders[version]
+		if !ok {
+			return nil, fmt.Errorf("no upgrader for bundle format version %d", version)
+		}
+		if err := upgrade(b); err != nil {
+			return nil, fmt.Errorf("error upgrading bundle from version %d: %v", version, err)
+		}
+	}
+	b.Manifest.FormatVersion = FormatVersion
+
+	return b, nil
+}
+
+// collectFiles lê todos os arquivos do workspace, indexados pelo caminho relativo
+func collectFiles(workspace string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	if workspace == "" {
+		return files, nil
+	}
+	if _, err := os.Stat(workspace); os.IsNotExist(err) {
+		return files, nil
+	}
+
+	err := filepath.Walk(workspace, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		relPath, err := filepath.Rel(workspace, p)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(relPath)] = data
+		return nil
+	})
+	return files, err
+}
+
+// cleanPath rejeita caminhos absolutos ou que escapem do workspace
+func cleanPath(name string) (string, error) {
+	cleaned := path.Clean(name)
+	if path.IsAbs(cleaned) || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("invalid file path in bundle: %s", name)
+	}
+	return cleaned, nil
+}
+
+func writeJSON(zipWriter *zip.Writer, name string, data interface{}) error {
+	content, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding %s: %v", name, err)
+	}
+	return writeEntry(zipWriter, name, content)
+}
+
+func writeEntry(zipWriter *zip.Writer, name string, content []byte) error {
+	entry, err := zipWriter.Create(name)
+	if err != nil {
+		return fmt.Errorf("error creating zip entry %s: %v", name, err)
+	}
+	if _, err := io.Copy(entry, bytes.NewReader(content)); err != nil {
+		return fmt.Errorf("error writing zip entry %s: %v", name, err)
+	}
+	return nil
+}
+
+// readEntry lê a entrada descompactada, recusando-a se passar de limit bytes
+func readEntry(f *zip.File, limit int64) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("error opening zip entry %s: %v", f.Name, err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("error reading zip entry %s: %v", f.Name, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("zip entry %s exceeds the bundle size limit", f.Name)
+	}
+	return data, nil
+}
@@ -0,0 +1,208 @@
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"backend-ai-sdlc/internal/models"
+)
+
+// zipOf monta um bundle com as entradas indicadas, na ordem dada
+func zipOf(t *testing.T, entries ...[2]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, entry := range entries {
+		w, err := zw.Create(entry[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(entry[1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func importBytes(data []byte) (*Bundle, error) {
+	return Import(bytes.NewReader(data), int64(len(data)))
+}
+
+func TestImportRejectsOversizedEntries(t *testing.T) {
+	manifest := [2]string{manifestEntry, `{"format_version": 2}`}
+	conversation := [2]string{conversationEntry, `{"id": "conv"}`}
+	big := strings.Repeat("0", maxEntrySize+1)
+
+	tests := []struct {
+		name    string
+		entries [][2]string
+	}{
+		{"entry over the limit", [][2]string{manifest, conversation, {"files/big.txt", big}}},
+		{"total over the limit", [][2]string{manifest, conversation,
+			{"files/a.txt", big[:maxEntrySize]}, {"files/b.txt", big[:maxEntrySize]},
+			{"files/c.txt", big[:maxEntrySize]}, {"files/d.txt", big[:maxEntrySize]},
+			{"files/e.txt", "x"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := zipOf(t, tt.entries...)
+			if len(data) > 1<<20 {
+				t.Fatalf("test bundle is %d bytes compressed, want a small zip", len(data))
+			}
+			if _, err := importBytes(data); err == nil || !strings.Contains(err.Error(), "size limit") {
+				t.Fatalf("Import() error = %v, want a size limit error", err)
+			}
+		})
+	}
+}
+
+func TestCleanPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "backend/main.go", want: "backend/main.go"},
+		{name: "backend/./main.go", want: "backend/main.go"},
+		{name: "docs/a..b.md", want: "docs/a..b.md"},
+		{name: ".", wantErr: true},
+		{name: "a/..", wantErr: true},
+		{name: "..", wantErr: true},
+		{name: "../x", wantErr: true},
+		{name: "/etc/passwd", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cleanPath(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("cleanPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("cleanPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"backend/main.go":   "package main\n",
+		"frontend/index.js": "console.log('hi')\n",
+	}
+	for name, content := range files {
+		full := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	conv := &models.Conversation{
+		ID:        "conv",
+		Workspace: dir,
+		State:     models.StateComplete,
+		Structure: `{"backend": ["main.go"], "frontend": ["index.js"]}`,
+		Steps:     []models.Step{{Number: 1, Input: "a todo app", Response: "{}"}},
+		Prompts:   []models.PromptRecord{{Step: 1, Path: "/backend/main.go", Prompt: "generate main.go"}},
+		Usage:     models.Usage{Requests: 1, InputTokens: 10, OutputTokens: 20},
+	}
+
+	var buf bytes.Buffer
+	if err := Export(&buf, conv); err != nil {
+		t.Fatal(err)
+	}
+	entries := map[string]bool{}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range zr.File {
+		entries[f.Name] = true
+	}
+	for _, name := range []string{"prompts.json", "usage.json"} {
+		if entries[name] {
+			t.Errorf("bundle has %s, which duplicates conversation.json", name)
+		}
+	}
+
+	b, err := importBytes(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if b.Manifest.FormatVersion != FormatVersion || b.Manifest.ConversationID != "conv" {
+		t.Errorf("manifest = %+v", b.Manifest)
+	}
+	if want := []string{"backend/main.go", "frontend/index.js"}; !reflect.DeepEqual(b.Manifest.Files, want) {
+		t.Errorf("manifest files = %v, want %v", b.Manifest.Files, want)
+	}
+	for name, content := range files {
+		if got := string(b.Files[name]); got != content {
+			t.Errorf("file %s = %q, want %q", name, got, content)
+		}
+	}
+	got := b.Conversation
+	if got.ID != conv.ID || got.State != conv.State || got.Structure != conv.Structure ||
+		!reflect.DeepEqual(got.Steps, conv.Steps) || !reflect.DeepEqual(got.Prompts, conv.Prompts) || got.Usage != conv.Usage {
+		t.Errorf("imported conversation = %+v, want %+v", got, conv)
+	}
+}
+
+func TestImportUpgradesVersion1(t *testing.T) {
+	tests := []struct {
+		name         string
+		conversation string
+		want         models.State
+	}{
+		{"created project", `{"id": "conv", "project_created": true, "structure": "{}"}`, models.StateComplete},
+		{"proposed structure", `{"id": "conv", "structure": "{}"}`, models.StateStructureProposed},
+		{"only a description", `{"id": "conv"}`, models.StateAwaitingDescription},
+		{"explicit state is kept", `{"id": "conv", "state": "awaiting_feedback", "structure": "{}"}`, models.StateAwaitingFeedback},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := zipOf(t,
+				[2]string{manifestEntry, `{"format_version": 1, "conversation_id": "conv"}`},
+				[2]string{conversationEntry, tt.conversation},
+			)
+			b, err := importBytes(data)
+			if err != nil {
+				t.Fatalf("Import() error = %v", err)
+			}
+			if b.Conversation.State != tt.want {
+				t.Errorf("state = %q, want %q", b.Conversation.State, tt.want)
+			}
+			if b.Manifest.FormatVersion != FormatVersion {
+				t.Errorf("format version = %d, want %d", b.Manifest.FormatVersion, FormatVersion)
+			}
+		})
+	}
+}
+
+func TestImportRejectsInvalidBundles(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries [][2]string
+	}{
+		{"missing manifest", [][2]string{{conversationEntry, `{"id": "conv"}`}}},
+		{"missing conversation", [][2]string{{manifestEntry, `{"format_version": 2}`}}},
+		{"newer format", [][2]string{{manifestEntry, `{"format_version": 99}`}, {conversationEntry, `{"id": "conv"}`}}},
+		{"file escaping the workspace", [][2]string{{manifestEntry, `{"format_version": 2}`}, {conversationEntry, `{"id": "conv"}`}, {"files/../../x", "x"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := importBytes(zipOf(t, tt.entries...)); err == nil {
+				t.Error("Import() error = nil, want an error")
+			}
+		})
+	}
+}
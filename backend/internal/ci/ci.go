@@ -0,0 +1,295 @@
+package ci
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Target é o sistema de CI para o qual o workflow é gerado
+type Target struct {
+	Name  string `json:"name"`
+	Label string `json:"label"`
+	File  string `json:"file"`
+}
+
+var targets = []Target{
+	{Name: "github", Label: "GitHub Actions", File: ".github/workflows/ci.yml"},
+	{Name: "gitlab", Label: "GitLab CI", File: ".gitlab-ci.yml"},
+	{Name: "makefile", Label: "Makefile", File: "Makefile"},
+}
+
+// Palavras que identificam cada alvo na resposta do usuário
+var targetKeywords = map[string][]string{
+	"github":   {"github", "actions"},
+	"gitlab":   {"gitlab"},
+	"makefile": {"makefile", "make"},
+}
+
+// ParseTarget identifica o alvo escolhido pelo usuário em texto livre
+func ParseTarget(text string) (Target, error) {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z')
+	})
+	for _, target := range targets {
+		for _, keyword := range targetKeywords[target.Name] {
+			for _, word := range words {
+				if word == keyword {
+					return target, nil
+				}
+			}
+		}
+	}
+
+	labels := make([]string, len(targets))
+	for i, target := range targets {
+		labels[i] = target.Label
+	}
+	return Target{}, fmt.Errorf("unknown CI target %q, expected one of: %s", strings.TrimSpace(text), strings.Join(labels, ", "))
+}
+
+// Stack é uma parte do projeto com o seu próprio gerenciador de dependências
+type Stack struct {
+	Language string `json:"language"`
+	Dir      string `json:"dir"`
+	Manifest string `json:"manifest"`
+}
+
+var manifests = map[string]string{
+	"go.mod":           "Go",
+	"package.json":     "Node.js",
+	"requirements.txt": "Python",
+	"pyproject.toml":   "Python",
+}
+
+// DetectStacks encontra as stacks do projeto pelos arquivos de manifesto
+func DetectStacks(files []string) []Stack {
+	seen := make(map[string]bool)
+	var stacks []Stack
+	for _, f := range files {
+		language, ok := manifests[path.Base(f)]
+		if !ok || strings.Contains(f, "node_modules/") {
+			continue
+		}
+		dir := path.Dir(f)
+		if seen[language+":"+dir] {
+			continue
+		}
+		seen[language+":"+dir] = true
+		stacks = append(stacks, Stack{Language: language, Dir: dir, Manifest: path.Base(f)})
+	}
+	sort.Slice(stacks, func(i, j int) bool { return stacks[i].Dir < stacks[j].Dir })
+	return stacks
+}
+
+// Describe lista as stacks para o prompt
+func Describe(stacks []Stack) string {
+	if len(stacks) == 0 {
+		return "No package manifest was found in the project."
+	}
+	var sb strings.Builder
+	for _, stack := range stacks {
+		fmt.Fprintf(&sb, "- %s project in %s (%s)\n", stack.Language, stack.Dir, stack.Manifest)
+	}
+	return sb.String()
+}
+
+// Validate faz a validação estrutural do workflow gerado para o alvo
+func Validate(target Target, content string) error {
+	var err error
+	switch target.Name {
+	case "github":
+		err = validateGitHub(content)
+	case "gitlab":
+		err = validateGitLab(content)
+	case "makefile":
+		err = validateMakefile(content)
+	default:
+		err = fmt.Errorf("unknown CI target %q", target.Name)
+	}
+	if err != nil {
+		return err
+	}
+
+	// O workflow precisa cobrir build, lint e testes
+	lower := strings.ToLower(content)
+	for _, stage := range []string{"build", "lint", "test"} {
+		if !strings.Contains(lower, stage) {
+			return fmt.Errorf("workflow has no %s step", stage)
+		}
+	}
+	return nil
+}
+
+func validateGitHub(content string) error {
+	var workflow map[string]interface{}
+	if err := yaml.Unmarshal([]byte(content), &workflow); err != nil {
+		return err
+	}
+	if workflow["on"] == nil {
+		return fmt.Errorf("workflow has no trigger (on)")
+	}
+
+	jobs, ok := workflow["jobs"].(map[string]interface{})
+	if !ok || len(jobs) == 0 {
+		return fmt.Errorf("workflow has no jobs")
+	}
+	for _, name := range sortedKeys(jobs) {
+		job, ok := jobs[name].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("job %s must be a mapping", name)
+		}
+		if job["runs-on"] == nil && job["uses"] == nil {
+			return fmt.Errorf("job %s has no runs-on", name)
+		}
+		if err := checkNeeds(name, job["needs"], jobs); err != nil {
+			return err
+		}
+		if job["uses"] != nil {
+			continue
+		}
+
+		steps, ok := job["steps"].([]interface{})
+		if !ok || len(steps) == 0 {
+			return fmt.Errorf("job %s has no steps", name)
+		}
+		for i, s := range steps {
+			step, ok := s.(map[string]interface{})
+			if !ok || (step["run"] == nil && step["uses"] == nil) {
+				return fmt.Errorf("step %d of job %s needs run or uses", i+1, name)
+			}
+		}
+	}
+	return nil
+}
+
+// Chaves globais do .gitlab-ci.yml que não são jobs
+var gitlabReserved = map[string]bool{
+	"stages": true, "variables": true, "default": true, "include": true, "workflow": true,
+	"image": true, "services": true, "cache": true, "before_script": true, "after_script": true,
+}
+
+func validateGitLab(content string) error {
+	var config map[string]interface{}
+	if err := yaml.Unmarshal([]byte(content), &config); err != nil {
+		return err
+	}
+
+	stages := map[string]bool{}
+	if list, ok := config["stages"].([]interface{}); ok {
+		for _, stage := range list {
+			stages[fmt.Sprint(stage)] = true
+		}
+	} else {
+		for _, stage := range []string{".pre", "build", "test", "deploy", ".post"} {
+			stages[stage] = true
+		}
+	}
+
+	jobs := make(map[string]interface{})
+	for name, value := range config {
+		if gitlabReserved[name] || strings.HasPrefix(name, ".") {
+			continue
+		}
+		jobs[name] = value
+	}
+	if len(jobs) == 0 {
+		return fmt.Errorf("pipeline has no jobs")
+	}
+
+	for _, name := range sortedKeys(jobs) {
+		job, ok := jobs[name].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("job %s must be a mapping", name)
+		}
+		if job["script"] == nil && job["trigger"] == nil && job["extends"] == nil {
+			return fmt.Errorf("job %s has no script", name)
+		}
+		stage := "test"
+		if s, ok := job["stage"].(string); ok {
+			stage = s
+		}
+		if !stages[stage] {
+			return fmt.Errorf("job %s uses stage %s, which is not in stages", name, stage)
+		}
+		if err := checkNeeds(name, job["needs"], jobs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var makeTargetPattern = regexp.MustCompile(`^([A-Za-z0-9_./-]+(?:\s+[A-Za-z0-9_./-]+)*)\s*::?(?:[^=]|$)`)
+
+func validateMakefile(content string) error {
+	targets := make(map[string]bool)
+	inRule := false
+
+	for i, line := range strings.Split(content, "\n") {
+		switch {
+		case strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "#"):
+			continue
+		case strings.HasPrefix(line, "\t"):
+			if !inRule {
+				return fmt.Errorf("line %d: recipe line outside of a rule", i+1)
+			}
+		case strings.HasPrefix(line, " "):
+			if inRule {
+				return fmt.Errorf("line %d: recipe lines must start with a tab", i+1)
+			}
+			return fmt.Errorf("line %d: unexpected indentation", i+1)
+		default:
+			match := makeTargetPattern.FindStringSubmatch(line)
+			inRule = match != nil
+			// Alvos especiais como .PHONY só declaram os outros, não os definem
+			if match != nil && !strings.HasPrefix(match[1], ".") {
+				for _, name := range strings.Fields(match[1]) {
+					targets[name] = true
+				}
+			}
+		}
+	}
+
+	for _, required := range []string{"build", "lint", "test"} {
+		if !targets[required] {
+			return fmt.Errorf("Makefile has no %s target", required)
+		}
+	}
+	return nil
+}
+
+// checkNeeds confere que as dependências do job existem
+func checkNeeds(name string, needs interface{}, jobs map[string]interface{}) error {
+	var list []interface{}
+	switch v := needs.(type) {
+	case nil:
+		return nil
+	case string:
+		list = []interface{}{v}
+	case []interface{}:
+		list = v
+	}
+	for _, need := range list {
+		needName := fmt.Sprint(need)
+		if m, ok := need.(map[string]interface{}); ok {
+			needName = fmt.Sprint(m["job"])
+		}
+		if _, ok := jobs[needName]; !ok {
+			return fmt.Errorf("job %s needs unknown job %s", name, needName)
+		}
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,134 @@
+package ci
+
+import (
+	"strings"
+	"testing"
+)
+
+const githubWorkflow = `name: CI
+on: [push]
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - run: go build ./...
+  lint:
+    runs-on: ubuntu-latest
+    needs: build
+    steps:
+      - run: go vet ./...
+  test:
+    runs-on: ubuntu-latest
+    needs: [build, lint]
+    steps:
+      - run: go test ./...
+`
+
+const gitlabPipeline = `stages: [build, lint, test]
+image: golang:1.22
+build:
+  stage: build
+  script: [go build ./...]
+lint:
+  stage: lint
+  script: [go vet ./...]
+test:
+  stage: test
+  needs: [build]
+  script: [go test ./...]
+`
+
+const makefile = ".PHONY: build lint test\n\nGO := go\n\nbuild:\n\t$(GO) build ./...\n\nlint:\n\t$(GO) vet ./...\n\ntest: build\n\t$(GO) test ./...\n"
+
+func TestValidate(t *testing.T) {
+	github, _ := ParseTarget("GitHub Actions")
+	gitlab, _ := ParseTarget("gitlab")
+	makeTarget, _ := ParseTarget("a Makefile")
+
+	tests := []struct {
+		name    string
+		target  Target
+		content string
+		wantErr string
+	}{
+		{name: "github", target: github, content: githubWorkflow},
+		{name: "github without trigger", target: github, content: strings.Replace(githubWorkflow, "on: [push]\n", "", 1), wantErr: "no trigger"},
+		{name: "github job without runs-on", target: github, content: strings.Replace(githubWorkflow, "  lint:\n    runs-on: ubuntu-latest\n", "  lint:\n", 1), wantErr: "job lint has no runs-on"},
+		{name: "github unknown need", target: github, content: strings.Replace(githubWorkflow, "needs: build", "needs: compile", 1), wantErr: "unknown job compile"},
+		{name: "github step without run", target: github, content: strings.Replace(githubWorkflow, "- run: go vet ./...", "- name: lint", 1), wantErr: "step 1 of job lint"},
+		{name: "github without a lint step", target: github, content: strings.ReplaceAll(githubWorkflow, "lint", "check"), wantErr: "no lint step"},
+		{name: "github invalid yaml", target: github, content: "jobs: [", wantErr: "yaml"},
+		{name: "gitlab", target: gitlab, content: gitlabPipeline},
+		{name: "gitlab undeclared stage", target: gitlab, content: strings.Replace(gitlabPipeline, "stage: lint", "stage: quality", 1), wantErr: "stage quality"},
+		{name: "gitlab job without script", target: gitlab, content: strings.Replace(gitlabPipeline, "  script: [go vet ./...]\n", "", 1), wantErr: "job lint has no script"},
+		{name: "gitlab without jobs", target: gitlab, content: "stages: [build]\nimage: golang\n", wantErr: "no jobs"},
+		{name: "makefile", target: makeTarget, content: makefile},
+		{name: "makefile recipe with spaces", target: makeTarget, content: strings.Replace(makefile, "\t$(GO) vet", "    $(GO) vet", 1), wantErr: "must start with a tab"},
+		{name: "makefile missing target", target: makeTarget, content: strings.Replace(makefile, "lint:\n", "check:\n", 1), wantErr: "no lint target"},
+		{name: "makefile targets only in .PHONY", target: makeTarget, content: ".PHONY: build lint test\n", wantErr: "no build target"},
+		{name: "unknown target", target: Target{Name: "jenkins"}, content: "build lint test", wantErr: "unknown CI target"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.target, tt.content)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{text: "GitHub Actions", want: "github"},
+		{text: "let's use gitlab, please", want: "gitlab"},
+		{text: "Make", want: "makefile"},
+		{text: "makefiles", wantErr: true},
+		{text: "Jenkins", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := ParseTarget(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTarget() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.Name != tt.want {
+				t.Errorf("ParseTarget() = %q, want %q", got.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectStacks(t *testing.T) {
+	stacks := DetectStacks([]string{
+		"frontend/package.json",
+		"backend/go.mod",
+		"frontend/node_modules/react/package.json",
+		"ml/requirements.txt",
+		"ml/pyproject.toml",
+	})
+	want := []Stack{
+		{Language: "Go", Dir: "backend", Manifest: "go.mod"},
+		{Language: "Node.js", Dir: "frontend", Manifest: "package.json"},
+		{Language: "Python", Dir: "ml", Manifest: "requirements.txt"},
+	}
+	if len(stacks) != len(want) {
+		t.Fatalf("DetectStacks() = %+v, want %+v", stacks, want)
+	}
+	for i := range want {
+		if stacks[i] != want[i] {
+			t.Errorf("DetectStacks()[%d] = %+v, want %+v", i, stacks[i], want[i])
+		}
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"io/ioutil"
 	"log"
 	"net/http"
+
+	"backend-ai-sdlc/internal/models"
 )
 
 const claudeAPIURL = "https://api.anthropic.com/v1/messages"
@@ -30,6 +32,16 @@ type ChatResponse struct {
 	Content []struct {
 		Text string `json:"text"`
 	} `json:"content"`
+	Usage struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// Completion é o texto retornado pelo Claude junto com o consumo de tokens
+type Completion struct {
+	Text  string
+	Usage models.Usage
 }
 
 func NewClient(apiKey string) *Client {
@@ -37,6 +49,14 @@ func NewClient(apiKey string) *Client {
 }
 
 func (c *Client) GetResponse(prompt string) (string, error) {
+	completion, err := c.GetCompletion(prompt)
+	if err != nil {
+		return "", err
+	}
+	return completion.Text, nil
+}
+
+func (c *Client) GetCompletion(prompt string) (*Completion, error) {
 	chatReq := ChatRequest{
 		Model: "claude-3-sonnet-20240229",
 		Messages: []Message{
@@ -47,12 +67,12 @@ func (c *Client) GetResponse(prompt string) (string, error) {
 
 	requestBody, err := json.Marshal(chatReq)
 	if err != nil {
-		return "", fmt.Errorf("error marshaling request body: %v", err)
+		return nil, fmt.Errorf("error marshaling request body: %v", err)
 	}
 
 	req, err := http.NewRequest("POST", claudeAPIURL, bytes.NewBuffer(requestBody))
 	if err != nil {
-		return "", fmt.Errorf("error creating request: %v", err)
+		return nil, fmt.Errorf("error creating request: %v", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -62,29 +82,36 @@ func (c *Client) GetResponse(prompt string) (string, error) {
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("error calling Claude API: %v", err)
+		return nil, fmt.Errorf("error calling Claude API: %v", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("error reading response body: %v", err)
+		return nil, fmt.Errorf("error reading response body: %v", err)
 	}
 
 	log.Printf("Claude API Response: %s", string(body))
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("Claude API returned non-200 status code: %d, body: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("Claude API returned non-200 status code: %d, body: %s", resp.StatusCode, string(body))
 	}
 
 	var chatResp ChatResponse
 	if err := json.Unmarshal(body, &chatResp); err != nil {
-		return "", fmt.Errorf("error unmarshaling response: %v", err)
+		return nil, fmt.Errorf("error unmarshaling response: %v", err)
 	}
 
 	if len(chatResp.Content) == 0 || chatResp.Content[0].Text == "" {
-		return "", fmt.Errorf("no content in response")
+		return nil, fmt.Errorf("no content in response")
 	}
 
-	return chatResp.Content[0].Text, nil
+	return &Completion{
+		Text: chatResp.Content[0].Text,
+		Usage: models.Usage{
+			Requests:     1,
+			InputTokens:  chatResp.Usage.InputTokens,
+			OutputTokens: chatResp.Usage.OutputTokens,
+		},
+	}, nil
 }
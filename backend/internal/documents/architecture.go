@@ -0,0 +1,216 @@
+package documents
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"backend-ai-sdlc/internal/models"
+)
+
+// Palavras-chave aceitas na primeira linha de um diagrama Mermaid
+var mermaidKeywords = []string{"graph", "flowchart", "sequenceDiagram", "classDiagram", "componentDiagram", "C4Context", "C4Container", "C4Component", "C4Dynamic", "erDiagram", "stateDiagram"}
+
+var slugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+func applyArchitecture(conv *models.Conversation, text string) error {
+	var arch models.Architecture
+	if err := decode(text, &arch); err != nil {
+		return fmt.Errorf("invalid architecture document: %v", err)
+	}
+	if err := validateArchitecture(&arch); err != nil {
+		return fmt.Errorf("invalid architecture document: %v", err)
+	}
+	conv.Architecture = &arch
+	return nil
+}
+
+func validateArchitecture(arch *models.Architecture) error {
+	if arch.Stack.Backend == "" && arch.Stack.Frontend == "" {
+		return fmt.Errorf("stack has no backend or frontend")
+	}
+	if len(arch.Components) == 0 {
+		return fmt.Errorf("no components")
+	}
+	if len(arch.Decisions) == 0 {
+		return fmt.Errorf("no architecture decisions")
+	}
+
+	components := make(map[string]bool)
+	for _, c := range arch.Components {
+		if c.Name == "" || c.Responsibility == "" {
+			return fmt.Errorf("component without name or responsibility")
+		}
+		components[c.Name] = true
+	}
+	for _, c := range arch.Components {
+		for _, dep := range c.DependsOn {
+			if !components[dep] {
+				return fmt.Errorf("component %s depends on unknown component %s", c.Name, dep)
+			}
+		}
+	}
+
+	for _, d := range arch.Decisions {
+		if d.ID == "" || d.Title == "" || d.Decision == "" {
+			return fmt.Errorf("decision without id, title or decision")
+		}
+	}
+
+	if len(arch.Diagrams) == 0 {
+		return fmt.Errorf("no diagrams")
+	}
+	for _, d := range arch.Diagrams {
+		if err := validateDiagram(d); err != nil {
+			return fmt.Errorf("diagram %q: %v", d.Name, err)
+		}
+	}
+	return nil
+}
+
+func validateDiagram(d models.Diagram) error {
+	source := strings.TrimSpace(d.Source)
+	if d.Name == "" || source == "" {
+		return fmt.Errorf("missing name or source")
+	}
+
+	switch d.Format {
+	case "mermaid":
+		firstLine := strings.TrimSpace(strings.SplitN(source, "\n", 2)[0])
+		for _, keyword := range mermaidKeywords {
+			if strings.HasPrefix(firstLine, keyword) {
+				return nil
+			}
+		}
+		return fmt.Errorf("mermaid source must start with a diagram type, got %q", firstLine)
+	case "plantuml":
+		if !strings.HasPrefix(source, "@startuml") || !strings.HasSuffix(source, "@enduml") {
+			return fmt.Errorf("plantuml source must be enclosed in @startuml/@enduml")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported format %q, expected mermaid or plantuml", d.Format)
+	}
+}
+
+// architectureSummary é a versão curta usada como restrição nos prompts de estrutura e de arquivos
+func architectureSummary(conv *models.Conversation) string {
+	arch := conv.Architecture
+	if arch == nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Stack:\n")
+	writeStack(&sb, arch.Stack)
+
+	sb.WriteString("\nComponents:\n")
+	for _, c := range arch.Components {
+		fmt.Fprintf(&sb, "- %s", c.Name)
+		if c.Technology != "" {
+			fmt.Fprintf(&sb, " (%s)", c.Technology)
+		}
+		fmt.Fprintf(&sb, ": %s", c.Responsibility)
+		if len(c.DependsOn) > 0 {
+			fmt.Fprintf(&sb, " (depends on %s)", strings.Join(c.DependsOn, ", "))
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("\nDecisions:\n")
+	for _, d := range arch.Decisions {
+		fmt.Fprintf(&sb, "- %s %s: %s\n", d.ID, d.Title, d.Decision)
+	}
+	return sb.String()
+}
+
+func architectureFiles(conv *models.Conversation) map[string]string {
+	arch := conv.Architecture
+	files := make(map[string]string)
+
+	var sb strings.Builder
+	sb.WriteString("# Architecture\n\n## Stack\n\n")
+	writeStack(&sb, arch.Stack)
+
+	sb.WriteString("\n## Components\n\n| Component | Responsibility | Technology | Depends on |\n|---|---|---|---|\n")
+	for _, c := range arch.Components {
+		fmt.Fprintf(&sb, "| %s | %s | %s | %s |\n", c.Name, c.Responsibility, c.Technology, strings.Join(c.DependsOn, ", "))
+	}
+
+	sb.WriteString("\n## Decisions\n\n")
+	for i, d := range arch.Decisions {
+		name := adrFileName(i, d)
+		fmt.Fprintf(&sb, "- [%s: %s](adr/%s)\n", d.ID, d.Title, name)
+		files[docsDir+"adr/"+name] = adrMarkdown(d)
+	}
+
+	sb.WriteString("\n## Diagrams\n")
+	used := make(map[string]bool)
+	for i, d := range arch.Diagrams {
+		ext := ".mmd"
+		if d.Format == "plantuml" {
+			ext = ".puml"
+		}
+		diagramPath := "diagrams/" + diagramFileName(i, d, used) + ext
+		files[docsDir+diagramPath] = strings.TrimSpace(d.Source) + "\n"
+
+		fmt.Fprintf(&sb, "\n### %s\n\n", d.Name)
+		if d.Format == "mermaid" {
+			fmt.Fprintf(&sb, "```mermaid\n%s\n```\n", strings.TrimSpace(d.Source))
+		} else {
+			fmt.Fprintf(&sb, "Source: [%s](%s)\n", diagramPath, diagramPath)
+		}
+	}
+
+	files[docsDir+"architecture.md"] = sb.String()
+	return files
+}
+
+func adrMarkdown(d models.Decision) string {
+	status := d.Status
+	if status == "" {
+		status = "Accepted"
+	}
+	return fmt.Sprintf("# %s: %s\n\n## Status\n\n%s\n\n## Context\n\n%s\n\n## Decision\n\n%s\n\n## Consequences\n\n%s\n",
+		d.ID, d.Title, status, d.Context, d.Decision, d.Consequences)
+}
+
+func adrFileName(i int, d models.Decision) string {
+	if name := slug(d.Title); name != "" {
+		return fmt.Sprintf("%04d-%s.md", i+1, name)
+	}
+	return fmt.Sprintf("%04d.md", i+1)
+}
+
+// diagramFileName deriva o nome do arquivo do diagrama, sem extensão. Nomes sem
+// letras ou números viram "diagram-<n>" e nomes repetidos ganham um sufixo,
+// para que um diagrama não sobrescreva o outro.
+func diagramFileName(i int, d models.Diagram, used map[string]bool) string {
+	base := slug(d.Name)
+	if base == "" {
+		base = fmt.Sprintf("diagram-%d", i+1)
+	}
+	name := base
+	for n := 2; used[name]; n++ {
+		name = fmt.Sprintf("%s-%d", base, n)
+	}
+	used[name] = true
+	return name
+}
+
+func writeStack(sb *strings.Builder, stack models.Stack) {
+	for _, item := range []struct{ label, value string }{
+		{"Backend", stack.Backend},
+		{"Frontend", stack.Frontend},
+		{"Database", stack.Database},
+		{"Other", strings.Join(stack.Other, ", ")},
+	} {
+		if item.value != "" {
+			fmt.Fprintf(sb, "- %s: %s\n", item.label, item.value)
+		}
+	}
+}
+
+func slug(s string) string {
+	return strings.Trim(slugPattern.ReplaceAllString(strings.ToLower(s), "-"), "-")
+}
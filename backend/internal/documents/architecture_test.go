@@ -0,0 +1,111 @@
+package documents
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"backend-ai-sdlc/internal/models"
+)
+
+func validArchitecture() models.Architecture {
+	return models.Architecture{
+		Stack: models.Stack{Backend: "Go", Frontend: "React"},
+		Components: []models.Component{
+			{Name: "api", Responsibility: "Serves the REST API"},
+			{Name: "web", Responsibility: "User interface", DependsOn: []string{"api"}},
+		},
+		Decisions: []models.Decision{{ID: "ADR-1", Title: "Use Go", Decision: "The API is written in Go"}},
+		Diagrams:  []models.Diagram{{Name: "Components", Format: "mermaid", Source: "graph TD\n  web --> api"}},
+	}
+}
+
+func TestValidateArchitecture(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(a *models.Architecture)
+		wantErr string
+	}{
+		{"valid", func(a *models.Architecture) {}, ""},
+		{"frontend only", func(a *models.Architecture) { a.Stack.Backend = "" }, ""},
+		{"empty stack", func(a *models.Architecture) { a.Stack = models.Stack{} }, "stack"},
+		{"no components", func(a *models.Architecture) { a.Components = nil }, "no components"},
+		{"component without responsibility", func(a *models.Architecture) { a.Components[0].Responsibility = "" }, "responsibility"},
+		{"unknown dependency", func(a *models.Architecture) { a.Components[1].DependsOn = []string{"db"} }, "unknown component db"},
+		{"no decisions", func(a *models.Architecture) { a.Decisions = nil }, "no architecture decisions"},
+		{"decision without id", func(a *models.Architecture) { a.Decisions[0].ID = "" }, "decision"},
+		{"no diagrams", func(a *models.Architecture) { a.Diagrams = nil }, "no diagrams"},
+		{"invalid diagram", func(a *models.Architecture) { a.Diagrams[0].Format = "svg" }, `diagram "Components"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			arch := validArchitecture()
+			tt.change(&arch)
+			err := validateArchitecture(&arch)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateArchitecture() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateArchitecture() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateDiagram(t *testing.T) {
+	tests := []struct {
+		name    string
+		diagram models.Diagram
+		wantErr bool
+	}{
+		{"mermaid flowchart", models.Diagram{Name: "Flow", Format: "mermaid", Source: "flowchart LR\n  a --> b"}, false},
+		{"mermaid sequence with leading blank lines", models.Diagram{Name: "Login", Format: "mermaid", Source: "\n\nsequenceDiagram\n  a->>b: hi"}, false},
+		{"mermaid without a diagram type", models.Diagram{Name: "Flow", Format: "mermaid", Source: "a --> b"}, true},
+		{"plantuml", models.Diagram{Name: "Deploy", Format: "plantuml", Source: "@startuml\nnode api\n@enduml\n"}, false},
+		{"plantuml without @enduml", models.Diagram{Name: "Deploy", Format: "plantuml", Source: "@startuml\nnode api"}, true},
+		{"unsupported format", models.Diagram{Name: "Deploy", Format: "graphviz", Source: "digraph { a -> b }"}, true},
+		{"missing name", models.Diagram{Format: "mermaid", Source: "graph TD"}, true},
+		{"blank source", models.Diagram{Name: "Flow", Format: "mermaid", Source: "  \n"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateDiagram(tt.diagram); (err != nil) != tt.wantErr {
+				t.Errorf("validateDiagram() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDiagramFileNames(t *testing.T) {
+	arch := validArchitecture()
+	arch.Decisions = append(arch.Decisions, models.Decision{ID: "ADR-2", Title: "???", Decision: "x"})
+	arch.Diagrams = []models.Diagram{
+		{Name: "Components", Format: "mermaid", Source: "graph TD"},
+		{Name: "components!", Format: "mermaid", Source: "flowchart LR"},
+		{Name: "???", Format: "mermaid", Source: "erDiagram"},
+		{Name: "Components", Format: "plantuml", Source: "@startuml\n@enduml"},
+	}
+
+	var paths []string
+	for filePath := range architectureFiles(&models.Conversation{Architecture: &arch}) {
+		paths = append(paths, filePath)
+	}
+	sort.Strings(paths)
+
+	want := []string{
+		docsDir + "adr/0001-use-go.md",
+		docsDir + "adr/0002.md",
+		docsDir + "architecture.md",
+		docsDir + "diagrams/components-2.mmd",
+		docsDir + "diagrams/components-3.puml",
+		docsDir + "diagrams/components.mmd",
+		docsDir + "diagrams/diagram-3.mmd",
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("architectureFiles() paths = %v, want %v", paths, want)
+	}
+}
@@ -0,0 +1,98 @@
+package documents
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"backend-ai-sdlc/internal/models"
+)
+
+// Kind descreve um documento tipado produzido por uma fase do pipeline
+type Kind struct {
+	// Apply valida o documento em JSON e o guarda na conversa
+	Apply func(conv *models.Conversation, text string) error
+	// Markdown renderiza o documento guardado como contexto para os prompts
+	Markdown func(conv *models.Conversation) string
+	// Files são os arquivos gravados no projeto gerado, pelo caminho a partir
+	// da raiz da estrutura, como "/docs/requirements.md"
+	Files func(conv *models.Conversation) map[string]string
+}
+
+// docsDir é o diretório dos documentos no projeto gerado
+const docsDir = "/docs/"
+
+var kinds = map[string]Kind{
+	"requirements": {
+		Apply:    applyRequirements,
+		Markdown: requirementsMarkdown,
+		Files: func(conv *models.Conversation) map[string]string {
+			return map[string]string{docsDir + "requirements.md": requirementsMarkdown(conv)}
+		},
+	},
+	"architecture": {
+		Apply:    applyArchitecture,
+		Markdown: architectureSummary,
+		Files:    architectureFiles,
+	},
+	"api": {
+		Apply: applyAPISpec,
+		Markdown: func(conv *models.Conversation) string {
+			return conv.APISpec
+		},
+		Files: func(conv *models.Conversation) map[string]string {
+			return map[string]string{docsDir + "openapi.json": conv.APISpec}
+		},
+	},
+}
+
+func Lookup(name string) (Kind, bool) {
+	kind, ok := kinds[name]
+	return kind, ok
+}
+
+// Names lista os tipos de documento registrados
+func Names() []string {
+	names := make([]string, 0, len(kinds))
+	for name := range kinds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Markdown renderiza todos os documentos já guardados na conversa, por tipo
+func Markdown(conv *models.Conversation) map[string]string {
+	rendered := make(map[string]string)
+	for name, kind := range kinds {
+		if md := kind.Markdown(conv); md != "" {
+			rendered[name] = md
+		}
+	}
+	return rendered
+}
+
+// Files reúne os arquivos de todos os documentos guardados na conversa
+func Files(conv *models.Conversation) map[string]string {
+	files := make(map[string]string)
+	for _, kind := range kinds {
+		if kind.Markdown(conv) == "" {
+			continue
+		}
+		for filePath, content := range kind.Files(conv) {
+			files[filePath] = content
+		}
+	}
+	return files
+}
+
+// decode extrai e decodifica o objeto JSON da resposta do modelo
+func decode(text string, v interface{}) error {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return fmt.Errorf("no JSON object found")
+	}
+	return json.Unmarshal([]byte(text[start:end+1]), v)
+}
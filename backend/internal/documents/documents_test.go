@@ -0,0 +1,43 @@
+package documents
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"backend-ai-sdlc/internal/models"
+)
+
+func TestFilesUnderDocs(t *testing.T) {
+	conv := &models.Conversation{
+		Requirements: &models.Requirements{
+			Functional: []models.Requirement{{ID: "FR-1", Description: "List tasks"}},
+		},
+		Architecture: &models.Architecture{
+			Stack:     models.Stack{Backend: "Go"},
+			Decisions: []models.Decision{{ID: "ADR-1", Title: "Use Go", Decision: "Go"}},
+			Diagrams:  []models.Diagram{{Name: "Components", Format: "mermaid", Source: "graph TD; a-->b"}},
+		},
+	}
+
+	files := Files(conv)
+	var paths []string
+	for filePath := range files {
+		paths = append(paths, filePath)
+	}
+	sort.Strings(paths)
+
+	for _, want := range []string{"/docs/requirements.md", "/docs/architecture.md"} {
+		if _, ok := files[want]; !ok {
+			t.Errorf("Files() = %v, missing %s", paths, want)
+		}
+	}
+	for _, filePath := range paths {
+		if !strings.HasPrefix(filePath, "/docs/") {
+			t.Errorf("Files() path %q is not under /docs/", filePath)
+		}
+	}
+	if _, ok := files["/docs/openapi.json"]; ok {
+		t.Error("Files() wrote the API spec before it was confirmed")
+	}
+}
@@ -0,0 +1,254 @@
+package documents
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"backend-ai-sdlc/internal/models"
+)
+
+var httpMethods = map[string]bool{
+	"get": true, "put": true, "post": true, "delete": true,
+	"options": true, "head": true, "patch": true, "trace": true,
+}
+
+// Campos de um path item que não são operações
+var pathItemFields = map[string]bool{
+	"summary": true, "description": true, "servers": true, "parameters": true, "$ref": true,
+}
+
+var pathParamPattern = regexp.MustCompile(`\{([^}/]+)\}`)
+
+// Trechos de caminho que identificam handlers do backend e clientes de API do frontend
+var contractMarkers = []string{"handler", "controller", "route", "router", "endpoint", "api", "client", "service"}
+
+var codeExtensions = map[string]bool{
+	".go": true, ".js": true, ".jsx": true, ".ts": true, ".tsx": true, ".vue": true, ".py": true,
+}
+
+func applyAPISpec(conv *models.Conversation, text string) error {
+	var spec map[string]interface{}
+	if err := decode(text, &spec); err != nil {
+		return fmt.Errorf("invalid OpenAPI spec: %v", err)
+	}
+	if err := validateOpenAPI(spec); err != nil {
+		return fmt.Errorf("invalid OpenAPI spec: %v", err)
+	}
+
+	normalized, err := json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding OpenAPI spec: %v", err)
+	}
+	conv.APISpec = string(normalized)
+	return nil
+}
+
+// validateOpenAPI faz a validação estrutural de uma spec OpenAPI 3
+func validateOpenAPI(spec map[string]interface{}) error {
+	version, _ := spec["openapi"].(string)
+	if !strings.HasPrefix(version, "3.") {
+		return fmt.Errorf("openapi version must be 3.x, got %q", version)
+	}
+
+	info, ok := spec["info"].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("missing info object")
+	}
+	for _, field := range []string{"title", "version"} {
+		if s, _ := info[field].(string); s == "" {
+			return fmt.Errorf("info.%s is required", field)
+		}
+	}
+
+	paths, ok := spec["paths"].(map[string]interface{})
+	if !ok || len(paths) == 0 {
+		return fmt.Errorf("paths must define at least one endpoint")
+	}
+
+	operationIDs := make(map[string]string)
+	for _, route := range sortedKeys(paths) {
+		if err := validatePathItem(route, paths[route], operationIDs); err != nil {
+			return err
+		}
+	}
+
+	return validateRefs(spec, spec)
+}
+
+func validatePathItem(route string, value interface{}, operationIDs map[string]string) error {
+	if !strings.HasPrefix(route, "/") {
+		return fmt.Errorf("path %q must start with /", route)
+	}
+	item, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("path %s must be an object", route)
+	}
+
+	shared := declaredPathParams(item["parameters"])
+	hasOperation := false
+
+	for _, key := range sortedKeys(item) {
+		if pathItemFields[key] || strings.HasPrefix(key, "x-") {
+			continue
+		}
+		if !httpMethods[key] {
+			return fmt.Errorf("path %s has unknown field %q", route, key)
+		}
+		hasOperation = true
+		where := strings.ToUpper(key) + " " + route
+
+		op, ok := item[key].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s must be an object", where)
+		}
+		responses, ok := op["responses"].(map[string]interface{})
+		if !ok || len(responses) == 0 {
+			return fmt.Errorf("%s must declare at least one response", where)
+		}
+		for code := range responses {
+			if !validResponseCode(code) {
+				return fmt.Errorf("%s has invalid response code %q", where, code)
+			}
+		}
+
+		if id, _ := op["operationId"].(string); id != "" {
+			if other, exists := operationIDs[id]; exists {
+				return fmt.Errorf("operationId %s is used by both %s and %s", id, other, where)
+			}
+			operationIDs[id] = where
+		}
+
+		declared := declaredPathParams(op["parameters"])
+		for _, match := range pathParamPattern.FindAllStringSubmatch(route, -1) {
+			name := match[1]
+			if !shared[name] && !declared[name] && !declared["$ref"] && !shared["$ref"] {
+				return fmt.Errorf("%s does not declare path parameter %s", where, name)
+			}
+		}
+	}
+
+	if !hasOperation && item["$ref"] == nil {
+		return fmt.Errorf("path %s has no operations", route)
+	}
+	return nil
+}
+
+// declaredPathParams devolve os parâmetros "in: path" declarados; "$ref" marca
+// parâmetros referenciados, que não são resolvidos aqui
+func declaredPathParams(value interface{}) map[string]bool {
+	declared := make(map[string]bool)
+	params, _ := value.([]interface{})
+	for _, p := range params {
+		param, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if _, isRef := param["$ref"]; isRef {
+			declared["$ref"] = true
+			continue
+		}
+		if in, _ := param["in"].(string); in == "path" {
+			if name, _ := param["name"].(string); name != "" {
+				declared[name] = true
+			}
+		}
+	}
+	return declared
+}
+
+func validResponseCode(code string) bool {
+	if code == "default" {
+		return true
+	}
+	if len(code) == 3 && strings.HasSuffix(code, "XX") {
+		return code[0] >= '1' && code[0] <= '5'
+	}
+	n, err := strconv.Atoi(code)
+	return err == nil && n >= 100 && n <= 599
+}
+
+// validateRefs confere que toda referência local ("#/...") aponta para um nó existente
+func validateRefs(root map[string]interface{}, node interface{}) error {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		if ref, ok := v["$ref"].(string); ok && strings.HasPrefix(ref, "#") {
+			if !resolvePointer(root, strings.TrimPrefix(ref, "#")) {
+				return fmt.Errorf("unresolved reference %s", ref)
+			}
+		}
+		for _, key := range sortedKeys(v) {
+			if err := validateRefs(root, v[key]); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if err := validateRefs(root, item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resolvePointer resolve um JSON pointer (RFC 6901) a partir da raiz da spec
+func resolvePointer(root map[string]interface{}, pointer string) bool {
+	if pointer == "" {
+		return true
+	}
+	var current interface{} = root
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		if unescaped, err := url.PathUnescape(token); err == nil {
+			token = unescaped
+		}
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+
+		switch v := current.(type) {
+		case map[string]interface{}:
+			next, ok := v[token]
+			if !ok {
+				return false
+			}
+			current = next
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) {
+				return false
+			}
+			current = v[i]
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// IsContractFile indica se o arquivo é um handler do backend ou um cliente de
+// API do frontend, que recebem a spec OpenAPI como contexto
+func IsContractFile(filePath string) bool {
+	lower := strings.ToLower(filePath)
+	if !codeExtensions[path.Ext(lower)] {
+		return false
+	}
+	for _, marker := range contractMarkers {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,105 @@
+package documents
+
+import (
+	"fmt"
+	"strings"
+
+	"backend-ai-sdlc/internal/models"
+)
+
+func applyRequirements(conv *models.Conversation, text string) error {
+	var req models.Requirements
+	if err := decode(text, &req); err != nil {
+		return fmt.Errorf("invalid requirements document: %v", err)
+	}
+	if err := validateRequirements(&req); err != nil {
+		return fmt.Errorf("invalid requirements document: %v", err)
+	}
+	conv.Requirements = &req
+	return nil
+}
+
+func validateRequirements(req *models.Requirements) error {
+	if len(req.Functional) == 0 {
+		return fmt.Errorf("no functional requirements")
+	}
+	if len(req.UserStories) == 0 {
+		return fmt.Errorf("no user stories")
+	}
+
+	ids := make(map[string]bool)
+	for _, list := range [][]models.Requirement{req.Functional, req.NonFunctional} {
+		for _, r := range list {
+			if r.ID == "" || r.Description == "" {
+				return fmt.Errorf("requirement without id or description")
+			}
+			if ids[r.ID] {
+				return fmt.Errorf("duplicate id %s", r.ID)
+			}
+			ids[r.ID] = true
+		}
+	}
+	for _, story := range req.UserStories {
+		if story.ID == "" || story.IWant == "" {
+			return fmt.Errorf("user story without id or goal")
+		}
+		if ids[story.ID] {
+			return fmt.Errorf("duplicate id %s", story.ID)
+		}
+		ids[story.ID] = true
+		if len(story.AcceptanceCriteria) == 0 {
+			return fmt.Errorf("user story %s has no acceptance criteria", story.ID)
+		}
+	}
+	return nil
+}
+
+func requirementsMarkdown(conv *models.Conversation) string {
+	req := conv.Requirements
+	if req == nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# Requirements\n\n## Functional requirements\n\n")
+	writeRequirements(&sb, req.Functional)
+
+	if len(req.NonFunctional) > 0 {
+		sb.WriteString("\n## Non-functional requirements\n\n")
+		writeRequirements(&sb, req.NonFunctional)
+	}
+
+	sb.WriteString("\n## User stories\n")
+	for _, story := range req.UserStories {
+		title := story.Title
+		if title == "" {
+			title = story.IWant
+		}
+		fmt.Fprintf(&sb, "\n### %s: %s\n\n", story.ID, title)
+		fmt.Fprintf(&sb, "As a %s, I want %s", story.AsA, story.IWant)
+		if story.SoThat != "" {
+			fmt.Fprintf(&sb, ", so that %s", story.SoThat)
+		}
+		sb.WriteString(".\n\n**Acceptance criteria**\n\n")
+		for _, criterion := range story.AcceptanceCriteria {
+			fmt.Fprintf(&sb, "- [ ] %s\n", criterion)
+		}
+	}
+	return sb.String()
+}
+
+func writeRequirements(sb *strings.Builder, list []models.Requirement) {
+	for _, r := range list {
+		fmt.Fprintf(sb, "- **%s**", r.ID)
+		var tags []string
+		for _, tag := range []string{r.Category, r.Priority} {
+			if tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+		if len(tags) > 0 {
+			fmt.Fprintf(sb, " (%s)", strings.Join(tags, ", "))
+		}
+		fmt.Fprintf(sb, ": %s\n", r.Description)
+	}
+}
@@ -0,0 +1,174 @@
+package events
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Event é uma mensagem publicada em uma conversa. ID cresce a cada evento da
+// conversa e é o Last-Event-ID usado para retomar o stream.
+type Event struct {
+	ID      uint64          `json:"id"`
+	Type    string          `json:"type"`
+	Content json.RawMessage `json:"content,omitempty"`
+	Time    time.Time       `json:"time"`
+}
+
+// Hub distribui os eventos de cada conversa aos seus assinantes e guarda os
+// mais recentes para que um assinante que se reconecta receba o que perdeu.
+type Hub struct {
+	historySize int
+	bufferSize  int
+
+	mu     sync.Mutex
+	topics map[string]*topic
+}
+
+type topic struct {
+	seq     uint64
+	history []Event
+	subs    map[*Subscription]struct{}
+}
+
+// Subscription recebe os eventos de uma conversa em C. Um assinante que não
+// consome os eventos a tempo é desconectado: C é fechado e Dropped retorna true.
+type Subscription struct {
+	C <-chan Event
+
+	ch             chan Event
+	hub            *Hub
+	conversationID string
+	dropped        bool
+	closed         bool
+}
+
+// NewHub cria um hub que guarda historySize eventos por conversa e um buffer
+// de bufferSize eventos por assinante
+func NewHub(historySize, bufferSize int) *Hub {
+	return &Hub{
+		historySize: historySize,
+		bufferSize:  bufferSize,
+		topics:      make(map[string]*topic),
+	}
+}
+
+// Publish numera o evento, guarda-o no histórico da conversa e o entrega aos assinantes
+func (h *Hub) Publish(conversationID, eventType string, content interface{}) (Event, error) {
+	data, err := json.Marshal(content)
+	if err != nil {
+		return Event{}, err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	t := h.topic(conversationID)
+	t.seq++
+	event := Event{ID: t.seq, Type: eventType, Content: data, Time: time.Now()}
+
+	t.history = append(t.history, event)
+	if over := len(t.history) - h.historySize; over > 0 {
+		t.history = append([]Event(nil), t.history[over:]...)
+	}
+
+	for sub := range t.subs {
+		select {
+		case sub.ch <- event:
+		default:
+			// O assinante lento é desconectado e pode retomar do último evento recebido
+			sub.dropped = true
+			h.remove(t, sub)
+		}
+	}
+	return event, nil
+}
+
+// Subscribe assina os eventos da conversa posteriores a lastID. Os eventos já
+// publicados depois de lastID voltam em replay; complete é false quando alguns
+// deles já saíram do histórico.
+func (h *Hub) Subscribe(conversationID string, lastID uint64) (sub *Subscription, replay []Event, complete bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	t := h.topic(conversationID)
+
+	complete = true
+	if lastID > t.seq {
+		// Um id maior que o último publicado vem de antes de o servidor reiniciar
+		lastID, complete = 0, false
+	}
+	if lastID < t.seq {
+		if len(t.history) == 0 || t.history[0].ID > lastID+1 {
+			complete = false
+		}
+		for _, event := range t.history {
+			if event.ID > lastID {
+				replay = append(replay, event)
+			}
+		}
+	}
+
+	ch := make(chan Event, h.bufferSize)
+	sub = &Subscription{C: ch, ch: ch, hub: h, conversationID: conversationID}
+	t.subs[sub] = struct{}{}
+	return sub, replay, complete
+}
+
+// LastID retorna o id do último evento publicado na conversa
+func (h *Hub) LastID(conversationID string) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if t, ok := h.topics[conversationID]; ok {
+		return t.seq
+	}
+	return 0
+}
+
+// Remove descarta o histórico da conversa e encerra as suas assinaturas. É
+// chamado quando a conversa é removida, para que os eventos dela, com o
+// conteúdo dos arquivos gerados, não fiquem em memória.
+func (h *Hub) Remove(conversationID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	t, ok := h.topics[conversationID]
+	if !ok {
+		return
+	}
+	for sub := range t.subs {
+		h.remove(t, sub)
+	}
+	delete(h.topics, conversationID)
+}
+
+// Close cancela a assinatura
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	if t, ok := s.hub.topics[s.conversationID]; ok {
+		s.hub.remove(t, s)
+	}
+}
+
+// Dropped indica se a assinatura foi encerrada por não acompanhar os eventos
+func (s *Subscription) Dropped() bool {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	return s.dropped
+}
+
+func (h *Hub) topic(conversationID string) *topic {
+	t, ok := h.topics[conversationID]
+	if !ok {
+		t = &topic{subs: make(map[*Subscription]struct{})}
+		h.topics[conversationID] = t
+	}
+	return t
+}
+
+func (h *Hub) remove(t *topic, sub *Subscription) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	delete(t.subs, sub)
+	close(sub.ch)
+}
@@ -0,0 +1,95 @@
+package events
+
+import "testing"
+
+func publish(t *testing.T, h *Hub, conversationID string, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if _, err := h.Publish(conversationID, "status_update", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSubscribeReplay(t *testing.T) {
+	tests := []struct {
+		name         string
+		published    int
+		lastID       uint64
+		wantReplay   []uint64
+		wantComplete bool
+	}{
+		{name: "up to date", published: 3, lastID: 3, wantComplete: true},
+		{name: "missed events", published: 3, lastID: 1, wantReplay: []uint64{2, 3}, wantComplete: true},
+		{name: "history trimmed", published: 6, lastID: 1, wantReplay: []uint64{3, 4, 5, 6}, wantComplete: false},
+		{name: "id from before a restart", published: 2, lastID: 9, wantReplay: []uint64{1, 2}, wantComplete: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHub(4, 8)
+			publish(t, h, "conv", tt.published)
+
+			sub, replay, complete := h.Subscribe("conv", tt.lastID)
+			defer sub.Close()
+			if complete != tt.wantComplete {
+				t.Errorf("complete = %v, want %v", complete, tt.wantComplete)
+			}
+			var ids []uint64
+			for _, event := range replay {
+				ids = append(ids, event.ID)
+			}
+			if len(ids) != len(tt.wantReplay) {
+				t.Fatalf("replay = %v, want %v", ids, tt.wantReplay)
+			}
+			for i := range ids {
+				if ids[i] != tt.wantReplay[i] {
+					t.Fatalf("replay = %v, want %v", ids, tt.wantReplay)
+				}
+			}
+		})
+	}
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	h := NewHub(10, 1)
+	sub, _, _ := h.Subscribe("conv", 0)
+	publish(t, h, "conv", 2)
+
+	<-sub.C
+	if _, open := <-sub.C; open {
+		t.Fatal("subscription is still open")
+	}
+	if !sub.Dropped() {
+		t.Error("Dropped() = false for a slow subscriber")
+	}
+}
+
+func TestRemove(t *testing.T) {
+	h := NewHub(10, 8)
+	publish(t, h, "conv", 3)
+	publish(t, h, "other", 1)
+	sub, _, _ := h.Subscribe("conv", 3)
+
+	h.Remove("conv")
+
+	if _, open := <-sub.C; open {
+		t.Fatal("subscription of the removed conversation is still open")
+	}
+	if sub.Dropped() {
+		t.Error("Dropped() = true for a removed conversation")
+	}
+	sub.Close()
+	if got := h.LastID("conv"); got != 0 {
+		t.Errorf("LastID() after Remove = %d, want 0", got)
+	}
+	if got := h.LastID("other"); got != 1 {
+		t.Errorf("LastID() of another conversation = %d, want 1", got)
+	}
+	h.mu.Lock()
+	_, exists := h.topics["conv"]
+	h.mu.Unlock()
+	if exists {
+		t.Error("topic of the removed conversation is still in memory")
+	}
+}
@@ -0,0 +1,174 @@
+package gitrepo
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"backend-ai-sdlc/internal/workspace"
+)
+
+// Commit é um commit do repositório do workspace
+type Commit struct {
+	Hash    string    `json:"hash"`
+	Date    time.Time `json:"date"`
+	Subject string    `json:"subject"`
+	Body    string    `json:"body"`
+}
+
+// Repo é o repositório git de um workspace, operado pelo binário git local
+type Repo struct {
+	gitDir   string
+	workTree string
+}
+
+var revisionPattern = regexp.MustCompile(`^[0-9a-fA-F]{4,40}$`)
+
+// Available indica se o binário git está instalado
+func Available() bool {
+	_, err := exec.LookPath("git")
+	return err == nil
+}
+
+func Open(workTree string) *Repo {
+	return &Repo{gitDir: workspace.GitDir(workTree), workTree: workTree}
+}
+
+// Commit grava o estado atual do workspace. Retorna um hash vazio quando não há mudanças.
+func (r *Repo) Commit(message string) (string, error) {
+	if err := r.ensureInit(); err != nil {
+		return "", err
+	}
+	if _, err := r.run("add", "-A"); err != nil {
+		return "", err
+	}
+	// diff --cached --quiet sai com erro quando há mudanças no índice
+	if _, err := r.run("diff", "--cached", "--quiet"); err == nil {
+		return "", nil
+	}
+	// A mensagem vai pela entrada padrão: passos longos não cabem em um argumento
+	if _, err := r.runInput(message, "commit", "-q", "-F", "-"); err != nil {
+		return "", err
+	}
+	hash, err := r.run("rev-parse", "HEAD")
+	return strings.TrimSpace(hash), err
+}
+
+// Log lista os commits, do mais recente para o mais antigo
+func (r *Repo) Log(limit int) ([]Commit, error) {
+	commits := []Commit{}
+	if !r.initialized() {
+		return commits, nil
+	}
+
+	args := []string{"log", "--format=%H%x1f%aI%x1f%s%x1f%b%x1e"}
+	if limit > 0 {
+		args = append(args, fmt.Sprintf("-n%d", limit))
+	}
+	output, err := r.run(args...)
+	if err != nil {
+		// Repositório ainda sem commits
+		if strings.Contains(err.Error(), "does not have any commits") {
+			return commits, nil
+		}
+		return nil, err
+	}
+
+	for _, record := range strings.Split(output, "\x1e") {
+		fields := strings.Split(strings.TrimSpace(record), "\x1f")
+		if len(fields) != 4 {
+			continue
+		}
+		date, _ := time.Parse(time.RFC3339, fields[1])
+		commits = append(commits, Commit{
+			Hash:    fields[0],
+			Date:    date,
+			Subject: fields[2],
+			Body:    strings.TrimSpace(fields[3]),
+		})
+	}
+	return commits, nil
+}
+
+// Diff retorna o diff unificado entre dois commits
+func (r *Repo) Diff(from, to string) (string, error) {
+	if err := checkRevision(from); err != nil {
+		return "", err
+	}
+	if err := checkRevision(to); err != nil {
+		return "", err
+	}
+	return r.run("diff", from, to, "--")
+}
+
+// Checkout restaura o workspace para o estado de um commit anterior e registra
+// a restauração como um novo commit, sem reescrever o histórico
+func (r *Repo) Checkout(revision string) (string, error) {
+	if err := checkRevision(revision); err != nil {
+		return "", err
+	}
+	subject, err := r.run("log", "-1", "--format=%s", revision)
+	if err != nil {
+		return "", err
+	}
+	if _, err := r.run("restore", "--source="+revision, "--staged", "--worktree", "--", "."); err != nil {
+		return "", err
+	}
+
+	short := revision
+	if len(short) > 12 {
+		short = short[:12]
+	}
+	return r.Commit(fmt.Sprintf("Check out %s\n\nRestores the workspace to: %s", short, strings.TrimSpace(subject)))
+}
+
+func (r *Repo) initialized() bool {
+	_, err := os.Stat(r.gitDir)
+	return err == nil
+}
+
+func (r *Repo) ensureInit() error {
+	if r.initialized() {
+		return nil
+	}
+	if err := os.MkdirAll(r.gitDir, os.ModePerm); err != nil {
+		return fmt.Errorf("error creating repository directory: %v", err)
+	}
+	_, err := r.run("init", "-q")
+	return err
+}
+
+func (r *Repo) run(args ...string) (string, error) {
+	return r.runInput("", args...)
+}
+
+func (r *Repo) runInput(stdin string, args ...string) (string, error) {
+	base := []string{
+		"--git-dir", r.gitDir, "--work-tree", r.workTree,
+		"-c", "user.name=AI SDLC", "-c", "user.email=ai-sdlc@localhost", "-c", "commit.gpgsign=false",
+	}
+	cmd := exec.Command("git", append(base, args...)...)
+	cmd.Dir = r.workTree
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return stdout.String(), fmt.Errorf("git %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+func checkRevision(revision string) error {
+	if !revisionPattern.MatchString(revision) {
+		return fmt.Errorf("invalid commit %q", revision)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package gitrepo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCommitLongMessage(t *testing.T) {
+	if !Available() {
+		t.Skip("git is not installed")
+	}
+	workTree := filepath.Join(t.TempDir(), "conv")
+	if err := os.MkdirAll(workTree, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workTree, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Maior que o limite de 128 KiB de um argumento no Linux
+	message := "Step 1: generate files\n\n" + strings.Repeat("prompt line\n", 20000)
+	repo := Open(workTree)
+	hash, err := repo.Commit(message)
+	if err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if hash == "" {
+		t.Fatal("Commit() returned an empty hash")
+	}
+
+	commits, err := repo.Log(0)
+	if err != nil {
+		t.Fatalf("Log() error = %v", err)
+	}
+	if len(commits) != 1 || commits[0].Subject != "Step 1: generate files" {
+		t.Fatalf("Log() = %+v, want one commit with the step subject", commits)
+	}
+
+	// Sem mudanças não há commit
+	if hash, err := repo.Commit("nothing"); err != nil || hash != "" {
+		t.Errorf("Commit() without changes = %q, %v, want no commit", hash, err)
+	}
+}
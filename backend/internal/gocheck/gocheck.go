@@ -0,0 +1,411 @@
+package gocheck
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Diagnostic é um problema encontrado em um arquivo Go do workspace
+type Diagnostic struct {
+	Path    string `json:"path"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+	// Tool é a etapa que encontrou o problema: gofmt, parser, types, build ou vet
+	Tool string `json:"tool"`
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s (%s)", d.Path, d.Line, d.Column, d.Message, d.Tool)
+}
+
+// Options controla as verificações que dependem do toolchain local
+type Options struct {
+	// Build roda go build e go vet no módulo, quando o binário go está disponível
+	Build   bool
+	Timeout time.Duration
+}
+
+// OptionsFromEnv lê as opções das variáveis GO_CHECK_*
+func OptionsFromEnv() (Options, error) {
+	opts := Options{Timeout: 2 * time.Minute}
+
+	if v := os.Getenv("GO_CHECK_BUILD"); v != "" {
+		build, err := strconv.ParseBool(v)
+		if err != nil {
+			return opts, fmt.Errorf("invalid GO_CHECK_BUILD: %v", err)
+		}
+		opts.Build = build
+	}
+	if v := os.Getenv("GO_CHECK_TIMEOUT"); v != "" {
+		timeout, err := time.ParseDuration(v)
+		if err != nil {
+			return opts, fmt.Errorf("invalid GO_CHECK_TIMEOUT: %v", err)
+		}
+		opts.Timeout = timeout
+	}
+
+	return opts, nil
+}
+
+var modulePattern = regexp.MustCompile(`(?m)^module\s+"?([^\s"]+)"?`)
+
+// Saída do go build/vet: arquivo.go:linha[:coluna]: mensagem
+var toolOutputPattern = regexp.MustCompile(`^(.+?\.go):(\d+)(?::(\d+))?: (.*)$`)
+
+// Files lista os arquivos .go do workspace, relativos a ele, ignorando vendor e testdata
+func Files(workspace string) ([]string, error) {
+	var files []string
+	err := filepath.Walk(workspace, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			name := info.Name()
+			if p != workspace && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(p, ".go") {
+			rel, err := filepath.Rel(workspace, p)
+			if err != nil {
+				return err
+			}
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	sort.Strings(files)
+	return files, err
+}
+
+// Format aplica o gofmt ao código
+func Format(src []byte) ([]byte, error) {
+	return format.Source(src)
+}
+
+// Check faz o parse e a checagem de tipos de todos os pacotes Go do workspace
+// e, se habilitado, roda go build e go vet em cada módulo
+func Check(workspace string, opts Options) ([]Diagnostic, error) {
+	files, err := Files(workspace)
+	if err != nil {
+		return nil, fmt.Errorf("error listing Go files: %v", err)
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	c := &checker{
+		workspace: workspace,
+		fset:      token.NewFileSet(),
+		std:       importer.Default(),
+		dirs:      make(map[string][]string),
+		packages:  make(map[string]*types.Package),
+		checking:  make(map[string]bool),
+		checked:   make(map[string]bool),
+		modules:   findModules(workspace),
+	}
+	for _, f := range files {
+		c.dirs[path.Dir(f)] = append(c.dirs[path.Dir(f)], f)
+	}
+
+	dirs := make([]string, 0, len(c.dirs))
+	for dir := range c.dirs {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		c.checkDir(dir)
+	}
+
+	if opts.Build && len(c.diagnostics) == 0 {
+		if _, err := exec.LookPath("go"); err == nil {
+			for _, moduleDir := range sortedKeys(c.modules) {
+				c.diagnostics = append(c.diagnostics, runTool(workspace, moduleDir, opts.Timeout, "build")...)
+				c.diagnostics = append(c.diagnostics, runTool(workspace, moduleDir, opts.Timeout, "vet")...)
+			}
+		}
+	}
+
+	sort.SliceStable(c.diagnostics, func(i, j int) bool {
+		a, b := c.diagnostics[i], c.diagnostics[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Line < b.Line
+	})
+	return c.diagnostics, nil
+}
+
+type checker struct {
+	workspace   string
+	fset        *token.FileSet
+	std         types.Importer
+	dirs        map[string][]string
+	packages    map[string]*types.Package
+	checking    map[string]bool
+	checked     map[string]bool
+	modules     map[string]string // diretório do módulo -> caminho do módulo
+	diagnostics []Diagnostic
+}
+
+// checkDir faz o parse dos arquivos do diretório e a checagem de tipos do pacote
+func (c *checker) checkDir(dir string) *types.Package {
+	if c.checked[dir] {
+		return c.packages[dir]
+	}
+	c.checked[dir] = true
+	c.checking[dir] = true
+	defer delete(c.checking, dir)
+
+	var parsed []*ast.File
+	syntaxErrors := false
+	for _, rel := range c.dirs[dir] {
+		src, err := os.ReadFile(filepath.Join(c.workspace, filepath.FromSlash(rel)))
+		if err != nil {
+			c.add(Diagnostic{Path: rel, Message: err.Error(), Tool: "parser"})
+			syntaxErrors = true
+			continue
+		}
+
+		file, err := parser.ParseFile(c.fset, rel, src, parser.AllErrors)
+		if err != nil {
+			syntaxErrors = true
+			if list, ok := err.(scanner.ErrorList); ok {
+				for _, e := range list {
+					c.add(Diagnostic{Path: rel, Line: e.Pos.Line, Column: e.Pos.Column, Message: e.Msg, Tool: "parser"})
+				}
+			} else {
+				c.add(Diagnostic{Path: rel, Message: err.Error(), Tool: "parser"})
+			}
+			continue
+		}
+
+		if formatted, err := format.Source(src); err == nil && !bytes.Equal(formatted, src) {
+			c.add(Diagnostic{Path: rel, Line: 1, Column: 1, Message: "file is not gofmt-formatted", Tool: "gofmt"})
+		}
+
+		// Testes ficam fora da checagem de tipos: pacotes _test externos exigiriam um segundo passe
+		if !strings.HasSuffix(rel, "_test.go") {
+			parsed = append(parsed, file)
+		}
+	}
+	if syntaxErrors || len(parsed) == 0 {
+		return nil
+	}
+
+	name := parsed[0].Name.Name
+	for _, file := range parsed[1:] {
+		if file.Name.Name != name {
+			pos := c.fset.Position(file.Name.Pos())
+			c.add(Diagnostic{Path: pos.Filename, Line: pos.Line, Column: pos.Column,
+				Message: fmt.Sprintf("package %s conflicts with package %s in the same directory", file.Name.Name, name), Tool: "types"})
+			return nil
+		}
+	}
+
+	conf := types.Config{
+		Importer: importerFunc(c.importPackage),
+		Error: func(err error) {
+			typeErr, ok := err.(types.Error)
+			if !ok {
+				c.add(Diagnostic{Path: dir, Message: err.Error(), Tool: "types"})
+				return
+			}
+			// Dependências externas não são resolvidas aqui; o go/types segue com um pacote falso
+			if strings.HasPrefix(typeErr.Msg, "could not import") && !c.isLocalImportError(typeErr.Msg) {
+				return
+			}
+			pos := typeErr.Fset.Position(typeErr.Pos)
+			c.add(Diagnostic{Path: pos.Filename, Line: pos.Line, Column: pos.Column, Message: typeErr.Msg, Tool: "types"})
+		},
+	}
+	pkg, _ := conf.Check(c.importPathFor(dir), c.fset, parsed, nil)
+	c.packages[dir] = pkg
+	return pkg
+}
+
+// importPackage resolve pacotes do próprio módulo checando o diretório
+// correspondente; o restante vem do importer padrão
+func (c *checker) importPackage(importPath string) (*types.Package, error) {
+	if dir, ok := c.localDir(importPath); ok {
+		if c.checking[dir] {
+			return nil, fmt.Errorf("import cycle through %s", importPath)
+		}
+		if _, exists := c.dirs[dir]; !exists {
+			return nil, fmt.Errorf("local package %s does not exist in the project", importPath)
+		}
+		if pkg := c.checkDir(dir); pkg != nil {
+			return pkg, nil
+		}
+		return nil, fmt.Errorf("local package %s has errors", importPath)
+	}
+	return c.std.Import(importPath)
+}
+
+// localDir traduz o caminho de import para um diretório do workspace,
+// preferindo o módulo com o caminho mais longo
+func (c *checker) localDir(importPath string) (string, bool) {
+	best, found := "", false
+	bestLen := -1
+	for moduleDir, modulePath := range c.modules {
+		if (importPath == modulePath || strings.HasPrefix(importPath, modulePath+"/")) && len(modulePath) > bestLen {
+			best = path.Join(moduleDir, strings.TrimPrefix(importPath, modulePath))
+			bestLen, found = len(modulePath), true
+		}
+	}
+	return best, found
+}
+
+// importPathFor retorna o caminho de import do diretório pelo módulo mais interno que o contém
+func (c *checker) importPathFor(dir string) string {
+	importPath := dir
+	bestLen := -1
+	for moduleDir, modulePath := range c.modules {
+		if rel, ok := relDir(moduleDir, dir); ok && len(moduleDir) > bestLen {
+			importPath = path.Join(modulePath, rel)
+			bestLen = len(moduleDir)
+		}
+	}
+	return importPath
+}
+
+// isLocalImportError indica se a falha de import é de um pacote do próprio projeto
+func (c *checker) isLocalImportError(msg string) bool {
+	fields := strings.Fields(strings.TrimPrefix(msg, "could not import"))
+	if len(fields) == 0 {
+		return false
+	}
+	dir, ok := c.localDir(fields[0])
+	// Pacotes locais com erros já têm os próprios diagnósticos
+	return ok && !c.checked[dir]
+}
+
+func (c *checker) add(d Diagnostic) {
+	c.diagnostics = append(c.diagnostics, d)
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) {
+	return f(path)
+}
+
+// findModules encontra os go.mod do workspace e o caminho de cada módulo
+func findModules(workspace string) map[string]string {
+	modules := make(map[string]string)
+	filepath.Walk(workspace, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || info.Name() != "go.mod" {
+			return nil
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil
+		}
+		if match := modulePattern.FindSubmatch(data); match != nil {
+			rel, _ := filepath.Rel(workspace, filepath.Dir(p))
+			modules[filepath.ToSlash(rel)] = string(match[1])
+		}
+		return nil
+	})
+	return modules
+}
+
+// runTool roda go build ou go vet no módulo e converte a saída em diagnósticos
+func runTool(workspace, moduleDir string, timeout time.Duration, tool string) []Diagnostic {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "go", tool, "./...")
+	cmd.Dir = filepath.Join(workspace, filepath.FromSlash(moduleDir))
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod")
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		return nil
+	}
+
+	diagnostics := parseToolOutput(moduleDir, tool, output)
+	// Falhas sem arquivo associado (dependências, go.mod) ficam no go.mod do módulo
+	if len(diagnostics) == 0 {
+		message := strings.TrimSpace(string(output))
+		if ctx.Err() != nil {
+			message = fmt.Sprintf("go %s timed out after %s", tool, timeout)
+		}
+		diagnostics = append(diagnostics, Diagnostic{Path: path.Join(moduleDir, "go.mod"), Message: message, Tool: tool})
+	}
+	return diagnostics
+}
+
+// parseToolOutput converte as linhas arquivo.go:linha[:coluna]: mensagem da
+// saída do go build ou go vet em diagnósticos com caminhos relativos ao workspace
+func parseToolOutput(moduleDir, tool string, output []byte) []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, line := range strings.Split(string(output), "\n") {
+		match := toolOutputPattern.FindStringSubmatch(strings.TrimPrefix(strings.TrimSpace(line), "vet: "))
+		if match == nil {
+			continue
+		}
+		lineNumber, _ := strconv.Atoi(match[2])
+		column, _ := strconv.Atoi(match[3])
+		diagnostics = append(diagnostics, Diagnostic{
+			Path:    path.Join(moduleDir, path.Clean(filepath.ToSlash(match[1]))),
+			Line:    lineNumber,
+			Column:  column,
+			Message: match[4],
+			Tool:    tool,
+		})
+	}
+	return diagnostics
+}
+
+// ByFile agrupa os diagnósticos por arquivo
+func ByFile(diagnostics []Diagnostic) map[string][]Diagnostic {
+	grouped := make(map[string][]Diagnostic)
+	for _, d := range diagnostics {
+		grouped[d.Path] = append(grouped[d.Path], d)
+	}
+	return grouped
+}
+
+func relDir(base, dir string) (string, bool) {
+	if base == "." {
+		return dir, true
+	}
+	if dir == base {
+		return "", true
+	}
+	if strings.HasPrefix(dir, base+"/") {
+		return strings.TrimPrefix(dir, base+"/"), true
+	}
+	return "", false
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
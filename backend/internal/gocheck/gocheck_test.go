@@ -0,0 +1,189 @@
+package gocheck
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const goMod = "module example.com/app\n\ngo 1.21\n"
+
+func writeWorkspace(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		full := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestCheck(t *testing.T) {
+	greet := "package greet\n\nfunc Hello() string { return \"hello\" }\n"
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []string // caminho:linha (ferramenta) de cada diagnóstico
+	}{
+		{
+			name: "valid module with a local package",
+			files: map[string]string{
+				"backend/go.mod":              goMod,
+				"backend/main.go":             "package main\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/app/internal/greet\"\n)\n\nfunc main() { fmt.Println(greet.Hello()) }\n",
+				"backend/internal/greet/g.go": greet,
+				"backend/vendor/x/broken.go":  "package x\n\nfunc {\n",
+				"backend/testdata/broken.go":  "package x\n\nfunc {\n",
+			},
+		},
+		{
+			name: "external dependencies are not resolved",
+			files: map[string]string{
+				"go.mod":  goMod,
+				"main.go": "package main\n\nimport \"github.com/google/uuid\"\n\nfunc main() { _ = uuid.New() }\n",
+			},
+		},
+		{
+			name: "syntax error",
+			files: map[string]string{
+				"go.mod":  goMod,
+				"main.go": "package main\n\nfunc main() {\n\tif {\n\t}\n}\n",
+			},
+			want: []string{"main.go:4 (parser)"},
+		},
+		{
+			name: "type error",
+			files: map[string]string{
+				"go.mod":  goMod,
+				"main.go": "package main\n\nfunc main() {\n\tundefinedCall()\n}\n",
+			},
+			want: []string{"main.go:4 (types)"},
+		},
+		{
+			name: "missing local package",
+			files: map[string]string{
+				"go.mod":  goMod,
+				"main.go": "package main\n\nimport \"example.com/app/internal/missing\"\n\nfunc main() { missing.Run() }\n",
+			},
+			want: []string{"main.go:3 (types)"},
+		},
+		{
+			name: "not gofmt-formatted",
+			files: map[string]string{
+				"go.mod":  goMod,
+				"main.go": "package main\nfunc main()  {}\n",
+			},
+			want: []string{"main.go:1 (gofmt)"},
+		},
+		{
+			name: "conflicting package names",
+			files: map[string]string{
+				"go.mod": goMod,
+				"a.go":   "package main\n",
+				"b.go":   "package other\n",
+			},
+			want: []string{"b.go:1 (types)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagnostics, err := Check(writeWorkspace(t, tt.files), Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, d := range diagnostics {
+				got = append(got, d.Path+":"+strconv.Itoa(d.Line)+" ("+d.Tool+")")
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check() = %v, want %v", diagnostics, tt.want)
+			}
+		})
+	}
+}
+
+func TestModulePaths(t *testing.T) {
+	dir := writeWorkspace(t, map[string]string{
+		"backend/go.mod":        goMod,
+		"backend/tools/go.mod":  "module \"example.com/tools\"\n",
+		"frontend/package.json": "{}",
+	})
+	c := &checker{modules: findModules(dir)}
+	if want := map[string]string{"backend": "example.com/app", "backend/tools": "example.com/tools"}; !reflect.DeepEqual(c.modules, want) {
+		t.Fatalf("findModules() = %v, want %v", c.modules, want)
+	}
+
+	importPaths := map[string]string{
+		"backend":                "example.com/app",
+		"backend/internal/greet": "example.com/app/internal/greet",
+		"backend/tools/cmd":      "example.com/tools/cmd",
+		"frontend/wasm":          "frontend/wasm",
+	}
+	for dir, want := range importPaths {
+		if got := c.importPathFor(dir); got != want {
+			t.Errorf("importPathFor(%q) = %q, want %q", dir, got, want)
+		}
+	}
+
+	localDirs := map[string]string{
+		"example.com/app/internal/greet": "backend/internal/greet",
+		"example.com/tools/cmd":          "backend/tools/cmd",
+		"example.com/app":                "backend",
+	}
+	for importPath, want := range localDirs {
+		if got, ok := c.localDir(importPath); !ok || got != want {
+			t.Errorf("localDir(%q) = %q, %v, want %q", importPath, got, ok, want)
+		}
+	}
+	for _, importPath := range []string{"fmt", "github.com/google/uuid", "example.com/application"} {
+		if got, ok := c.localDir(importPath); ok {
+			t.Errorf("localDir(%q) = %q, want no local directory", importPath, got)
+		}
+	}
+}
+
+func TestParseToolOutput(t *testing.T) {
+	output := "# example.com/app/internal/store\n" +
+		"internal/store/store.go:12:2: undefined: sql\n" +
+		"vet: ./main.go:7:14: fmt.Printf format %d has arg name of wrong type string\n" +
+		"internal/store/store.go:30: missing return\n" +
+		"note: module requires Go 1.24\n"
+
+	got := parseToolOutput("backend", "vet", []byte(output))
+	want := []Diagnostic{
+		{Path: "backend/internal/store/store.go", Line: 12, Column: 2, Message: "undefined: sql", Tool: "vet"},
+		{Path: "backend/main.go", Line: 7, Column: 14, Message: "fmt.Printf format %d has arg name of wrong type string", Tool: "vet"},
+		{Path: "backend/internal/store/store.go", Line: 30, Message: "missing return", Tool: "vet"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseToolOutput() = %+v, want %+v", got, want)
+	}
+}
+
+func TestRunTool(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go toolchain not available")
+	}
+	dir := writeWorkspace(t, map[string]string{
+		"backend/go.mod":  goMod,
+		"backend/main.go": "package main\n\nfunc main() {\n\tvar unused int\n}\n",
+	})
+
+	diagnostics := runTool(dir, "backend", time.Minute, "build")
+	if len(diagnostics) != 1 {
+		t.Fatalf("runTool() = %+v, want one diagnostic", diagnostics)
+	}
+	if d := diagnostics[0]; d.Path != "backend/main.go" || d.Line != 4 || d.Tool != "build" || !strings.Contains(d.Message, "unused") {
+		t.Errorf("diagnostic = %+v", d)
+	}
+}
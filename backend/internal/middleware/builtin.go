@@ -0,0 +1,329 @@
+package middleware
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"backend-ai-sdlc/internal/multifile"
+)
+
+func init() {
+	Register("style_guide", newStyleGuide)
+	Register("redact_pii", newRedactPII)
+	Register("strip_fences", newStripFences)
+	Register("max_length", newMaxLength)
+}
+
+// styleGuides são as convenções de cada linguagem acrescentadas aos prompts de código
+var styleGuides = map[string]string{
+	"go":         "Format the code with gofmt, return errors instead of panicking and wrap them with fmt.Errorf and %w, keep exported identifiers documented and prefer the standard library.",
+	"javascript": "Use ES modules, const and let instead of var, async/await instead of promise chains, strict equality and two-space indentation.",
+	"typescript": "Enable strict typing, avoid any, declare interfaces for data shapes, use async/await and two-space indentation.",
+	"python":     "Follow PEP 8, add type hints to public functions, use f-strings and raise specific exceptions.",
+	"vue":        "Use single-file components with <script setup> and the Composition API, and keep the template free of complex logic.",
+}
+
+var extensionLanguages = map[string]string{
+	".go":  "go",
+	".js":  "javascript",
+	".jsx": "javascript",
+	".mjs": "javascript",
+	".ts":  "typescript",
+	".tsx": "typescript",
+	".py":  "python",
+	".vue": "vue",
+}
+
+var stackLanguages = map[string]string{
+	"go":         "go",
+	"golang":     "go",
+	"node":       "javascript",
+	"node.js":    "javascript",
+	"nodejs":     "javascript",
+	"express":    "javascript",
+	"react":      "javascript",
+	"javascript": "javascript",
+	"typescript": "typescript",
+	"angular":    "typescript",
+	"python":     "python",
+	"fastapi":    "python",
+	"flask":      "python",
+	"django":     "python",
+	"vue":        "vue",
+	"vue.js":     "vue",
+}
+
+var stackWord = regexp.MustCompile(`[a-z0-9.]+`)
+
+// newStyleGuide acrescenta ao prompt o guia de estilo da linguagem do arquivo
+// ou, nos prompts sem arquivo, das linguagens da stack. As opções substituem o
+// guia de uma linguagem, como {"go": "Use chi for routing."}.
+func newStyleGuide(options map[string]string) (Middleware, error) {
+	guides := make(map[string]string, len(styleGuides))
+	for language, guide := range styleGuides {
+		guides[language] = guide
+	}
+	for language, guide := range options {
+		if _, ok := styleGuides[language]; !ok {
+			return Middleware{}, fmt.Errorf("unknown language %q", language)
+		}
+		guides[language] = guide
+	}
+
+	return Middleware{
+		Prompt: func(ctx Context, prompt string) (string, error) {
+			languages := fileLanguages(ctx)
+			if len(languages) == 0 {
+				return prompt, nil
+			}
+			var sb strings.Builder
+			sb.WriteString(prompt)
+			sb.WriteString("\n\nStyle guide:\n")
+			for _, language := range languages {
+				fmt.Fprintf(&sb, "- %s: %s\n", language, guides[language])
+			}
+			return sb.String(), nil
+		},
+	}, nil
+}
+
+// fileLanguages retorna a linguagem do arquivo do prompt ou as linguagens da stack
+func fileLanguages(ctx Context) []string {
+	if ctx.Path != "" {
+		if language, ok := extensionLanguages[strings.ToLower(path.Ext(ctx.Path))]; ok {
+			return []string{language}
+		}
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	for _, word := range stackWord.FindAllString(strings.ToLower(ctx.Stack), -1) {
+		if language, ok := stackLanguages[strings.TrimRight(word, ".")]; ok {
+			seen[language] = true
+		}
+	}
+	languages := make([]string, 0, len(seen))
+	for language := range seen {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+type piiPattern struct {
+	pattern *regexp.Regexp
+	// valid descarta números com o formato certo que não são documentos reais
+	valid func(match string) bool
+}
+
+var piiPatterns = map[string]piiPattern{
+	"email": {pattern: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)},
+	// Só números com código do país ou DDD entre parênteses: sequências soltas
+	// de dígitos em código, versões e datas não são telefones
+	"phone": {pattern: regexp.MustCompile(`(?:\+\d{1,3}[ -]?\(?\d{2,3}\)?|\(\d{2,3}\))[ -]?\d{4,5}-?\d{4}\b`), valid: validPhone},
+	"card":  {pattern: regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`), valid: luhn},
+	"cpf":   {pattern: regexp.MustCompile(`\b\d{3}\.\d{3}\.\d{3}-\d{2}\b`), valid: validCPF},
+}
+
+// newRedactPII substitui e-mails, telefones, cartões e CPFs do prompt por
+// marcadores. Opções: "types" restringe os tipos (separados por vírgula) e
+// "responses" = "true" também limpa as respostas.
+func newRedactPII(options map[string]string) (Middleware, error) {
+	types := make([]string, 0, len(piiPatterns))
+	for name := range piiPatterns {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+
+	for key := range options {
+		if key != "types" && key != "responses" {
+			return Middleware{}, fmt.Errorf("unknown option %q", key)
+		}
+	}
+	if v, ok := options["types"]; ok {
+		types = nil
+		for _, name := range strings.Split(v, ",") {
+			name = strings.TrimSpace(name)
+			if _, ok := piiPatterns[name]; !ok {
+				return Middleware{}, fmt.Errorf("unknown PII type %q", name)
+			}
+			types = append(types, name)
+		}
+	}
+	responses, err := boolOption(options, "responses")
+	if err != nil {
+		return Middleware{}, err
+	}
+
+	redact := func(_ Context, text string) (string, error) {
+		for _, name := range types {
+			p := piiPatterns[name]
+			marker := "[REDACTED " + strings.ToUpper(name) + "]"
+			text = p.pattern.ReplaceAllStringFunc(text, func(match string) string {
+				if p.valid != nil && !p.valid(match) {
+					return match
+				}
+				return marker
+			})
+		}
+		return text, nil
+	}
+
+	m := Middleware{Prompt: redact}
+	if responses {
+		m.Response = redact
+	}
+	return m, nil
+}
+
+// luhn valida o dígito verificador de um número de cartão
+func luhn(number string) bool {
+	digits := onlyDigits(number)
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	for i := range digits {
+		d := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// validPhone descarta números curtos ou longos demais para um telefone com DDD
+func validPhone(phone string) bool {
+	n := len(onlyDigits(phone))
+	return n >= 10 && n <= 13
+}
+
+// validCPF confere os dois dígitos verificadores de um CPF
+func validCPF(cpf string) bool {
+	digits := onlyDigits(cpf)
+	if strings.Count(digits, digits[:1]) == len(digits) {
+		return false
+	}
+	for _, n := range []int{9, 10} {
+		sum := 0
+		for i := 0; i < n; i++ {
+			sum += int(digits[i]-'0') * (n + 1 - i)
+		}
+		check := sum * 10 % 11 % 10
+		if check != int(digits[n]-'0') {
+			return false
+		}
+	}
+	return true
+}
+
+func onlyDigits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// newStripFences remove o bloco de código que envolve a resposta inteira,
+// mantendo os blocos internos, como os de um README
+func newStripFences(options map[string]string) (Middleware, error) {
+	if len(options) > 0 {
+		return Middleware{}, fmt.Errorf("strip_fences takes no options")
+	}
+	return Middleware{
+		Response: func(_ Context, response string) (string, error) {
+			return multifile.Unwrap(response), nil
+		},
+	}, nil
+}
+
+// newMaxLength limita o tamanho, em caracteres, dos prompts ("prompt_chars")
+// e das respostas ("response_chars"). Um prompt longo demais é recusado; uma
+// resposta longa demais é recusada ou, com "mode" = "truncate", cortada na
+// última linha completa.
+func newMaxLength(options map[string]string) (Middleware, error) {
+	var promptChars, responseChars int
+	truncate := false
+	for key, v := range options {
+		switch key {
+		case "prompt_chars", "response_chars":
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				return Middleware{}, fmt.Errorf("invalid %s %q", key, v)
+			}
+			if key == "prompt_chars" {
+				promptChars = n
+			} else {
+				responseChars = n
+			}
+		case "mode":
+			if v != "reject" && v != "truncate" {
+				return Middleware{}, fmt.Errorf("invalid mode %q, expected reject or truncate", v)
+			}
+			truncate = v == "truncate"
+		default:
+			return Middleware{}, fmt.Errorf("unknown option %q", key)
+		}
+	}
+	if promptChars == 0 && responseChars == 0 {
+		return Middleware{}, fmt.Errorf("max_length requires prompt_chars or response_chars")
+	}
+
+	var m Middleware
+	if promptChars > 0 {
+		m.Prompt = func(_ Context, prompt string) (string, error) {
+			if n := utf8.RuneCountInString(prompt); n > promptChars {
+				return "", fmt.Errorf("prompt has %d characters, the limit is %d", n, promptChars)
+			}
+			return prompt, nil
+		}
+	}
+	if responseChars > 0 {
+		m.Response = func(_ Context, response string) (string, error) {
+			count := utf8.RuneCountInString(response)
+			if count <= responseChars {
+				return response, nil
+			}
+			if !truncate {
+				return "", fmt.Errorf("response has %d characters, the limit is %d", count, responseChars)
+			}
+			// Corta depois do caractere responseChars, que nunca fica no meio de uma sequência UTF-8
+			n, chars := 0, 0
+			for i := range response {
+				if chars == responseChars {
+					n = i
+					break
+				}
+				chars++
+			}
+			cut := response[:n]
+			if i := strings.LastIndexByte(cut, '\n'); i > 0 {
+				cut = cut[:i+1]
+			}
+			return cut, nil
+		}
+	}
+	return m, nil
+}
+
+func boolOption(options map[string]string, key string) (bool, error) {
+	v, ok := options[key]
+	if !ok {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s %q", key, v)
+	}
+	return b, nil
+}
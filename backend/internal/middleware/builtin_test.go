@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedactPhone(t *testing.T) {
+	m, err := newRedactPII(map[string]string{"types": "phone"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"call +55 11 98765-4321 today", "call [REDACTED PHONE] today"},
+		{"call +5511987654321", "call [REDACTED PHONE]"},
+		{"call (11) 98765-4321", "call [REDACTED PHONE]"},
+		{"call (021)3456-7890", "call [REDACTED PHONE]"},
+		{"version 1.2024.0001", "version 1.2024.0001"},
+		{"ports 80 8080-9090", "ports 80 8080-9090"},
+		{"id 10 123456789", "id 10 123456789"},
+		{"date 12 2024-0101", "date 12 2024-0101"},
+		{"ip 192.168.10.1", "ip 192.168.10.1"},
+		{"f(10) 1234", "f(10) 1234"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := m.Prompt(Context{}, tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("redact(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaxLengthCountsCharacters(t *testing.T) {
+	tests := []struct {
+		name    string
+		options map[string]string
+		prompt  string
+		wantErr bool
+	}{
+		{"ascii within the limit", map[string]string{"prompt_chars": "5"}, "abcde", false},
+		{"multibyte within the limit", map[string]string{"prompt_chars": "5"}, "ação!", false},
+		{"emoji within the limit", map[string]string{"prompt_chars": "2"}, "🙂🙂", false},
+		{"over the limit", map[string]string{"prompt_chars": "4"}, "ação!", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := newMaxLength(tt.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := m.Prompt(Context{}, tt.prompt); (err != nil) != tt.wantErr {
+				t.Errorf("Prompt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMaxLengthTruncate(t *testing.T) {
+	m, err := newMaxLength(map[string]string{"response_chars": "8", "mode": "truncate"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		response string
+		want     string
+	}{
+		{"çãõ\nçãõ\n", "çãõ\nçãõ\n"},
+		{"ção\nação\nmais\n", "ção\n"},
+		{"çççççççççç", "çççççççç"},
+	}
+	for _, tt := range tests {
+		got, err := m.Response(Context{}, tt.response)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("Response(%q) = %q, want %q", tt.response, got, tt.want)
+		}
+		if !strings.HasPrefix(tt.response, got) {
+			t.Errorf("Response(%q) = %q is not a prefix", tt.response, got)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Context descreve a chamada ao Claude que passa pela cadeia
+type Context struct {
+	ConversationID string
+	// Phase e Kind identificam o template de prompt, como "files" e "file_prompt"
+	Phase string
+	Kind  string
+	// Path é o arquivo gerado pela chamada, vazio nos prompts da fase
+	Path string
+	// Stack resume a stack do projeto, quando já conhecida
+	Stack string
+}
+
+// PromptFunc altera o prompt antes do envio ao Claude
+type PromptFunc func(ctx Context, prompt string) (string, error)
+
+// ResponseFunc altera a resposta do Claude antes que o engine a use
+type ResponseFunc func(ctx Context, response string) (string, error)
+
+// Middleware atua antes do prompt, depois da resposta ou nos dois pontos
+type Middleware struct {
+	Prompt   PromptFunc
+	Response ResponseFunc
+}
+
+// Factory cria um middleware com as opções configuradas no pipeline
+type Factory func(options map[string]string) (Middleware, error)
+
+// Config é um middleware na cadeia de uma fase. Kinds restringe os tipos de
+// prompt em que ele atua; vazio, ele atua em todos.
+type Config struct {
+	Name    string            `json:"name"`
+	Kinds   []string          `json:"kinds,omitempty"`
+	Options map[string]string `json:"options,omitempty"`
+}
+
+var (
+	mu        sync.RWMutex
+	factories = make(map[string]Factory)
+)
+
+// Register torna um middleware disponível para os pipelines pelo nome
+func Register(name string, factory Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, exists := factories[name]; exists {
+		panic(fmt.Sprintf("middleware %q already registered", name))
+	}
+	factories[name] = factory
+}
+
+func Lookup(name string) (Factory, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	factory, ok := factories[name]
+	return factory, ok
+}
+
+// Names lista os middlewares registrados
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Chain é uma sequência de middlewares, aplicados na ordem da configuração
+// tanto no prompt quanto na resposta. Uma Chain nil não altera nada.
+type Chain struct {
+	links []link
+}
+
+type link struct {
+	name  string
+	kinds map[string]bool
+	Middleware
+}
+
+// Build cria os middlewares da configuração, falhando em nomes ou opções inválidas
+func Build(configs []Config) (*Chain, error) {
+	chain := &Chain{}
+	for _, config := range configs {
+		factory, ok := Lookup(config.Name)
+		if !ok {
+			return nil, fmt.Errorf("unknown middleware %q, expected one of %v", config.Name, Names())
+		}
+		m, err := factory(config.Options)
+		if err != nil {
+			return nil, fmt.Errorf("middleware %q: %v", config.Name, err)
+		}
+
+		l := link{name: config.Name, Middleware: m}
+		if len(config.Kinds) > 0 {
+			l.kinds = make(map[string]bool, len(config.Kinds))
+			for _, kind := range config.Kinds {
+				l.kinds[kind] = true
+			}
+		}
+		chain.links = append(chain.links, l)
+	}
+	return chain, nil
+}
+
+// Names lista os middlewares da cadeia, na ordem em que são aplicados
+func (c *Chain) Names() []string {
+	if c == nil {
+		return nil
+	}
+	names := make([]string, len(c.links))
+	for i, l := range c.links {
+		names[i] = l.name
+	}
+	return names
+}
+
+// Prompt passa o prompt pelos middlewares de pré-prompt da cadeia
+func (c *Chain) Prompt(ctx Context, prompt string) (string, error) {
+	if c == nil {
+		return prompt, nil
+	}
+	var err error
+	for _, l := range c.links {
+		if l.Prompt == nil || !l.applies(ctx) {
+			continue
+		}
+		if prompt, err = l.Prompt(ctx, prompt); err != nil {
+			return "", fmt.Errorf("middleware %s: %v", l.name, err)
+		}
+	}
+	return prompt, nil
+}
+
+// Response passa a resposta pelos middlewares de pós-resposta da cadeia
+func (c *Chain) Response(ctx Context, response string) (string, error) {
+	if c == nil {
+		return response, nil
+	}
+	var err error
+	for _, l := range c.links {
+		if l.Response == nil || !l.applies(ctx) {
+			continue
+		}
+		if response, err = l.Response(ctx, response); err != nil {
+			return "", fmt.Errorf("middleware %s: %v", l.name, err)
+		}
+	}
+	return response, nil
+}
+
+func (l link) applies(ctx Context) bool {
+	return l.kinds == nil || l.kinds[ctx.Kind]
+}
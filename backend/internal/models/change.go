@@ -0,0 +1,19 @@
+package models
+
+// ChangeSet é uma mudança pedida depois da geração, aguardando a confirmação
+// do usuário. Cada arquivo guarda o conteúdo do workspace no momento da
+// proposta, para que a mudança só seja aplicada se o arquivo não mudou.
+type ChangeSet struct {
+	Request string       `json:"request"`
+	Files   []FileChange `json:"files"`
+}
+
+type FileChange struct {
+	Path    string `json:"path"`
+	Base    string `json:"base"`
+	Content string `json:"content"`
+	Created bool   `json:"created,omitempty"`
+	Deleted bool   `json:"deleted,omitempty"`
+	// Regenerated indica que o diff não se aplicou e o arquivo foi gerado inteiro
+	Regenerated bool `json:"regenerated,omitempty"`
+}
@@ -1,5 +1,11 @@
 package models
 
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
 type Message struct {
 	Role    string `json:"role"`
 	Content string `json:"content"`
@@ -11,21 +17,111 @@ type GetMessagesResponse struct {
 }
 
 type Conversation struct {
-	ID             string
-	Steps          []Step
-	ProjectCreated bool
+	ID             string                     `json:"id"`
+	Steps          []Step                     `json:"steps"`
+	Phase          string                     `json:"phase"`
+	State          State                      `json:"state"`
+	ProjectCreated bool                       `json:"project_created"`
+	Description    string                     `json:"description,omitempty"`
+	Structure      string                     `json:"structure,omitempty"`
+	Requirements   *Requirements              `json:"requirements,omitempty"`
+	Architecture   *Architecture              `json:"architecture,omitempty"`
+	APISpec        string                     `json:"api_spec,omitempty"`
+	Outputs        map[string]string          `json:"outputs,omitempty"`
+	Revisions      int                        `json:"revisions"`
+	InvalidFiles   map[string]string          `json:"invalid_files,omitempty"`
+	Findings       []Finding                  `json:"findings,omitempty"`
+	SecurityIssues map[string][]SecurityIssue `json:"security_issues,omitempty"`
+	CITarget       string                     `json:"ci_target,omitempty"`
+	Template       string                     `json:"template,omitempty"`
+	PendingChange  *ChangeSet                 `json:"pending_change,omitempty"`
+	Workspace      string                     `json:"workspace,omitempty"`
+	Prompts        []PromptRecord             `json:"prompts,omitempty"`
+	Usage          Usage                      `json:"usage"`
+	Pinned         bool                       `json:"pinned"`
+	CreatedAt      time.Time                  `json:"created_at"`
+	UpdatedAt      time.Time                  `json:"updated_at"`
+}
+
+// Clone retorna uma cópia profunda da conversa. O storage guarda e entrega
+// cópias, para que um passo em execução não altere a conversa que outras
+// requisições estão lendo.
+func (c *Conversation) Clone() *Conversation {
+	data, err := json.Marshal(c)
+	if err != nil {
+		panic(fmt.Sprintf("error copying conversation %s: %v", c.ID, err))
+	}
+	clone := &Conversation{}
+	if err := json.Unmarshal(data, clone); err != nil {
+		panic(fmt.Sprintf("error copying conversation %s: %v", c.ID, err))
+	}
+	return clone
 }
 
+// State é o estado explícito da conversa dentro do pipeline
+type State string
+
+const (
+	// StateAwaitingDescription aguarda a entrada em texto da fase atual
+	StateAwaitingDescription State = "awaiting_description"
+	// StateStructureProposed aguarda YES/NO para a saída proposta pela fase
+	StateStructureProposed State = "structure_proposed"
+	// StateAwaitingFeedback aguarda o feedback do usuário para revisar a saída recusada
+	StateAwaitingFeedback State = "awaiting_feedback"
+	// StateGenerating indica que os arquivos do projeto estão sendo gerados
+	StateGenerating State = "generating"
+	// StateAwaitingReview aguarda YES/NO para os arquivos gerados
+	StateAwaitingReview State = "awaiting_review"
+	// StateAwaitingStart aguarda YES para executar uma fase opcional ou NO para pulá-la
+	StateAwaitingStart State = "awaiting_start"
+	// StateComplete indica que o pipeline terminou; mensagens seguem para a fase de follow-up
+	StateComplete State = "complete"
+)
+
 type Step struct {
 	Number   int    `json:"number"`
 	Input    string `json:"input"`
 	Response string `json:"response"`
+	// Commit é o hash do commit do workspace gravado ao fim do passo
+	Commit string `json:"commit,omitempty"`
+	// PromptVersions guarda a versão de cada template de prompt usado no passo
+	PromptVersions map[string]string `json:"prompt_versions,omitempty"`
+}
+
+// PromptRecord guarda o prompt exato enviado ao Claude em cada chamada
+type PromptRecord struct {
+	Step int    `json:"step"`
+	Path string `json:"path,omitempty"`
+	// Template e Version identificam o template de prompt usado, como "files/file_prompt"
+	Template string `json:"template,omitempty"`
+	Version  string `json:"version,omitempty"`
+	Prompt   string `json:"prompt"`
+	Usage    Usage  `json:"usage"`
+}
+
+// Usage acumula o consumo de tokens reportado pela API do Claude
+type Usage struct {
+	Requests     int `json:"requests"`
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
 }
 
+func (u *Usage) Add(other Usage) {
+	u.Requests += other.Requests
+	u.InputTokens += other.InputTokens
+	u.OutputTokens += other.OutputTokens
+}
+
+// ChatResume é o Type da mensagem que retoma os eventos de uma conversa após
+// uma reconexão, a partir do último seq recebido em LastSeq
+const ChatResume = "resume"
+
 type ChatRequest struct {
 	ConversationID string `json:"conversation_id"`
 	Message        string `json:"message"`
 	IsConfirmation bool   `json:"is_confirmation"`
+	Type           string `json:"type,omitempty"`
+	LastSeq        uint64 `json:"last_seq,omitempty"`
 }
 
 type ChatResponse struct {
@@ -33,6 +129,7 @@ type ChatResponse struct {
 	Message              string `json:"message"`
 	StepNumber           int    `json:"step_number"`
 	RequiresConfirmation bool   `json:"requires_confirmation"`
+	State                State  `json:"state"`
 }
 
 // FrontendResponse pode ser o mesmo que ChatResponse se a estrutura for idêntica
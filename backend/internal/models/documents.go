@@ -0,0 +1,64 @@
+package models
+
+// Requirements é o documento de requisitos produzido na fase de requisitos
+type Requirements struct {
+	Functional    []Requirement `json:"functional_requirements"`
+	NonFunctional []Requirement `json:"non_functional_requirements"`
+	UserStories   []UserStory   `json:"user_stories"`
+}
+
+type Requirement struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	Category    string `json:"category,omitempty"`
+	Priority    string `json:"priority,omitempty"`
+}
+
+type UserStory struct {
+	ID                 string   `json:"id"`
+	Title              string   `json:"title"`
+	AsA                string   `json:"as_a"`
+	IWant              string   `json:"i_want"`
+	SoThat             string   `json:"so_that"`
+	AcceptanceCriteria []string `json:"acceptance_criteria"`
+}
+
+// Architecture é o desenho acordado na fase de arquitetura: stack, componentes,
+// decisões (ADRs) e diagramas em Mermaid ou PlantUML
+type Architecture struct {
+	Stack      Stack       `json:"stack"`
+	Components []Component `json:"components"`
+	Decisions  []Decision  `json:"decisions"`
+	Diagrams   []Diagram   `json:"diagrams"`
+}
+
+type Stack struct {
+	Backend  string   `json:"backend"`
+	Frontend string   `json:"frontend"`
+	Database string   `json:"database,omitempty"`
+	Other    []string `json:"other,omitempty"`
+}
+
+type Component struct {
+	Name           string   `json:"name"`
+	Responsibility string   `json:"responsibility"`
+	Technology     string   `json:"technology,omitempty"`
+	DependsOn      []string `json:"depends_on,omitempty"`
+}
+
+// Decision é um architecture decision record
+type Decision struct {
+	ID           string `json:"id"`
+	Title        string `json:"title"`
+	Status       string `json:"status"`
+	Context      string `json:"context"`
+	Decision     string `json:"decision"`
+	Consequences string `json:"consequences"`
+}
+
+type Diagram struct {
+	Name   string `json:"name"`
+	Kind   string `json:"kind"`
+	Format string `json:"format"`
+	Source string `json:"source"`
+}
@@ -0,0 +1,23 @@
+package models
+
+// Finding é um problema encontrado na revisão de código de um arquivo gerado.
+// Original guarda as linhas revisadas, para que a correção só seja aplicada
+// se o arquivo não mudou desde a revisão. Replacement nulo indica um achado
+// sem correção; uma string vazia remove as linhas.
+type Finding struct {
+	ID          string  `json:"id"`
+	Path        string  `json:"path"`
+	StartLine   int     `json:"start_line"`
+	EndLine     int     `json:"end_line"`
+	Severity    string  `json:"severity"`
+	Category    string  `json:"category"`
+	Message     string  `json:"message"`
+	Original    string  `json:"original"`
+	Replacement *string `json:"replacement"`
+	Status      string  `json:"status"`
+}
+
+const (
+	FindingOpen    = "open"
+	FindingApplied = "applied"
+)
@@ -0,0 +1,11 @@
+package models
+
+// SecurityIssue é um segredo ou padrão inseguro encontrado em um arquivo gerado
+type SecurityIssue struct {
+	Line     int    `json:"line"`
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	// Match é o trecho encontrado, com segredos mascarados
+	Match string `json:"match"`
+}
@@ -0,0 +1,180 @@
+package multifile
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// File é um arquivo de uma resposta com vários arquivos
+type File struct {
+	Path    string
+	Content string
+}
+
+// separator reconhece "--- [caminho] ---", com ou sem colchetes, crases ou o prefixo "File:";
+// o caminho precisa começar por algo além de "-", senão uma régua "----------" viraria separador
+var separator = regexp.MustCompile("^-{3,}\\s*\\[?\\s*(?:(?i:file(?:\\s+path)?):\\s*)?`?([^\\s\\[\\]`-][^\\s\\[\\]`]*)`?\\s*\\]?\\s*-{3,}\\s*$")
+
+// fence reconhece a abertura ou o fechamento de um bloco de código
+var fence = regexp.MustCompile("^\\s*(`{3,}|~{3,})\\s*(\\S*)")
+
+// Parse lê uma resposta no formato "--- [caminho] ---" seguido do conteúdo de
+// cada arquivo. Texto antes do primeiro separador é ignorado, o bloco de código
+// que envolve um arquivo inteiro é removido e separadores dentro de blocos de
+// código não iniciam um novo arquivo. Se um caminho aparece mais de uma vez,
+// vale o último conteúdo. Arquivos com caminho inválido são descartados e
+// retornados em skipped, para que sejam gerados de outra forma.
+func Parse(text string) (result []File, skipped []string, err error) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	files, balanced := split(lines, true)
+	if !balanced {
+		// Com os blocos de código desbalanceados, a contagem de cercas não é confiável
+		files, _ = split(lines, false)
+	}
+	if len(files) == 0 {
+		return nil, nil, fmt.Errorf("no \"--- [path] ---\" separators found")
+	}
+
+	index := make(map[string]int)
+	for _, file := range files {
+		filePath, err := cleanPath(file.Path)
+		if err != nil {
+			skipped = append(skipped, file.Path)
+			continue
+		}
+		file.Path = filePath
+		if i, ok := index[filePath]; ok {
+			result[i] = file
+			continue
+		}
+		index[filePath] = len(result)
+		result = append(result, file)
+	}
+	if len(result) == 0 {
+		return nil, skipped, fmt.Errorf("no valid file paths found")
+	}
+	return result, skipped, nil
+}
+
+// split divide as linhas nos separadores. Com trackFences, separadores dentro
+// de blocos de código são tratados como conteúdo; balanced indica se todos os
+// blocos abertos foram fechados.
+func split(lines []string, trackFences bool) (files []File, balanced bool) {
+	var current *File
+	var body []string
+	depth := 0
+
+	flush := func() {
+		if current != nil {
+			current.Content = unwrap(body)
+			files = append(files, *current)
+		}
+	}
+
+	for _, line := range lines {
+		if match := separator.FindStringSubmatch(line); match != nil && (depth == 0 || !trackFences) {
+			flush()
+			current = &File{Path: match[1]}
+			body = nil
+			depth = 0
+			continue
+		}
+
+		if match := fence.FindStringSubmatch(line); match != nil && trackFences {
+			// Uma cerca com linguagem só pode abrir um bloco; uma cerca vazia fecha o bloco aberto
+			if match[2] != "" || depth == 0 {
+				depth++
+			} else {
+				depth--
+			}
+		}
+		if current != nil {
+			body = append(body, line)
+		}
+	}
+	flush()
+
+	return files, depth == 0
+}
+
+// Unwrap aplica a um único arquivo a mesma limpeza feita em cada arquivo de Parse
+func Unwrap(text string) string {
+	return unwrap(strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n"))
+}
+
+// unwrap remove as linhas vazias das pontas e o bloco de código que envolve o
+// arquivo inteiro, preservando os blocos internos (como os de um README)
+func unwrap(body []string) string {
+	start, end := 0, len(body)
+	for start < end && strings.TrimSpace(body[start]) == "" {
+		start++
+	}
+	for end > start && strings.TrimSpace(body[end-1]) == "" {
+		end--
+	}
+	body = body[start:end]
+
+	if len(body) >= 2 {
+		open := fence.FindStringSubmatch(body[0])
+		closing := strings.TrimSpace(body[len(body)-1])
+		if open != nil && strings.TrimSpace(body[0]) == open[1]+open[2] && closes(closing, open[1]) && opensOuter(body) {
+			body = body[1 : len(body)-1]
+		}
+	}
+
+	if len(body) == 0 {
+		return ""
+	}
+	return strings.Join(body, "\n") + "\n"
+}
+
+// closes indica se a linha fecha um bloco aberto pela cerca open
+func closes(line, open string) bool {
+	if len(line) < len(open) || line[0] != open[0] {
+		return false
+	}
+	return strings.Trim(line, string(open[0])) == ""
+}
+
+// opensOuter confirma que a primeira cerca envolve o arquivo inteiro, e não
+// apenas um bloco no começo de um arquivo que termina com outro bloco
+func opensOuter(body []string) bool {
+	depth := 0
+	for i, line := range body {
+		match := fence.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		if match[2] != "" || depth == 0 {
+			depth++
+		} else {
+			depth--
+		}
+		if depth == 0 {
+			return i == len(body)-1
+		}
+	}
+	return false
+}
+
+// cleanPath normaliza o caminho a partir da raiz do projeto, rejeitando
+// segmentos ".." e separadores do Windows
+func cleanPath(filePath string) (string, error) {
+	trimmed := strings.TrimSpace(filePath)
+	if strings.Contains(trimmed, `\`) {
+		return "", fmt.Errorf("invalid file path %q", filePath)
+	}
+	for _, segment := range strings.Split(trimmed, "/") {
+		if segment == ".." {
+			return "", fmt.Errorf("invalid file path %q", filePath)
+		}
+	}
+	cleaned := path.Clean("/" + trimmed)
+	if cleaned == "/" {
+		return "", fmt.Errorf("invalid file path %q", filePath)
+	}
+	return cleaned, nil
+}
@@ -0,0 +1,118 @@
+package multifile
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    []File
+		skipped []string
+		wantErr bool
+	}{
+		{
+			name: "separators",
+			text: "Here are the files:\n--- [backend/main.go] ---\npackage main\n\n--- [README.md] ---\n# App\n",
+			want: []File{{"/backend/main.go", "package main\n"}, {"/README.md", "# App\n"}},
+		},
+		{
+			name: "separator variants",
+			text: "--- backend/go.mod ---\nmodule app\n----- [File: `web/index.html`] -----\n<html></html>\n",
+			want: []File{{"/backend/go.mod", "module app\n"}, {"/web/index.html", "<html></html>\n"}},
+		},
+		{
+			name: "fence around the whole file is removed",
+			text: "--- [main.go] ---\n```go\npackage main\n```\n",
+			want: []File{{"/main.go", "package main\n"}},
+		},
+		{
+			name: "separator inside a code block is content",
+			text: "--- [README.md] ---\n# Format\n```text\n--- [example.txt] ---\n```\n",
+			want: []File{{"/README.md", "# Format\n```text\n--- [example.txt] ---\n```\n"}},
+		},
+		{
+			name: "unbalanced fences fall back to plain separators",
+			text: "--- [a.go] ---\n```go\npackage a\n--- [b.go] ---\npackage b\n",
+			want: []File{{"/a.go", "```go\npackage a\n"}, {"/b.go", "package b\n"}},
+		},
+		{
+			name: "last content of a repeated path wins",
+			text: "--- [a.go] ---\nfirst\n--- [/a.go] ---\nsecond\n",
+			want: []File{{"/a.go", "second\n"}},
+		},
+		{
+			name: "dots inside a name are allowed",
+			text: "--- [docs/a..b.txt] ---\ntext\n",
+			want: []File{{"/docs/a..b.txt", "text\n"}},
+		},
+		{
+			name: "horizontal rule is content",
+			text: "--- [README.md] ---\n# App\n----------\nFooter\n",
+			want: []File{{"/README.md", "# App\n----------\nFooter\n"}},
+		},
+		{
+			name:    "invalid paths are skipped",
+			text:    "--- [../etc/passwd] ---\nroot\n--- [main.go] ---\npackage main\n--- [src/../../x] ---\nx\n--- [win\\path.txt] ---\ny\n",
+			want:    []File{{"/main.go", "package main\n"}},
+			skipped: []string{"../etc/passwd", "src/../../x", `win\path.txt`},
+		},
+		{
+			name:    "only invalid paths",
+			text:    "--- [../x] ---\nx\n",
+			skipped: []string{"../x"},
+			wantErr: true,
+		},
+		{
+			name:    "no separators",
+			text:    "package main\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, skipped, err := Parse(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() files = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(skipped, tt.skipped) {
+				t.Errorf("Parse() skipped = %q, want %q", skipped, tt.skipped)
+			}
+		})
+	}
+}
+
+func TestCleanPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{path: "main.go", want: "/main.go"},
+		{path: " /src//main.go ", want: "/src/main.go"},
+		{path: "src/./main.go", want: "/src/main.go"},
+		{path: "a..b.txt", want: "/a..b.txt"},
+		{path: "..hidden", want: "/..hidden"},
+		{path: "/", wantErr: true},
+		{path: "..", wantErr: true},
+		{path: "src/../main.go", wantErr: true},
+		{path: `src\main.go`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := cleanPath(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("cleanPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("cleanPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
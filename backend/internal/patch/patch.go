@@ -0,0 +1,403 @@
+package patch
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DevNull é o caminho usado no diff para arquivos criados ou removidos
+const DevNull = "/dev/null"
+
+// Line é uma linha de um hunk: ' ' para contexto, '-' removida, '+' adicionada
+type Line struct {
+	Kind byte
+	Text string
+}
+
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Lines    []Line
+}
+
+// FileDiff são as mudanças de um arquivo. Err é preenchido quando os hunks do
+// arquivo estão malformados, para que o arquivo possa ser regenerado inteiro.
+type FileDiff struct {
+	OldPath string
+	NewPath string
+	Hunks   []Hunk
+	Err     error
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// Path retorna o caminho do arquivo afetado, relativo à raiz do projeto
+func (f FileDiff) Path() string {
+	if f.NewPath == DevNull {
+		return f.OldPath
+	}
+	return f.NewPath
+}
+
+// Created indica que o diff cria o arquivo
+func (f FileDiff) Created() bool {
+	return f.OldPath == DevNull
+}
+
+// Deleted indica que o diff remove o arquivo
+func (f FileDiff) Deleted() bool {
+	return f.NewPath == DevNull
+}
+
+// Contains indica se o texto tem um diff unificado
+func Contains(text string) bool {
+	lines := strings.Split(text, "\n")
+	for i := 0; i+1 < len(lines); i++ {
+		if strings.HasPrefix(lines[i], "--- ") && strings.HasPrefix(lines[i+1], "+++ ") {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse lê os arquivos de um diff unificado. Texto fora do diff, como
+// explicações e cercas de Markdown, é ignorado.
+func Parse(text string) ([]FileDiff, error) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	var files []FileDiff
+	for i := 0; i < len(lines); i++ {
+		if !strings.HasPrefix(lines[i], "--- ") || i+1 >= len(lines) || !strings.HasPrefix(lines[i+1], "+++ ") {
+			continue
+		}
+
+		file := FileDiff{
+			OldPath: diffPath(lines[i][4:], "a/"),
+			NewPath: diffPath(lines[i+1][4:], "b/"),
+		}
+		i += 2
+
+		// Os hunks vão até o próximo cabeçalho de arquivo ou o fim do diff
+		end := i
+		for end < len(lines) && !endOfFile(lines, end) {
+			end++
+		}
+		file.Hunks, file.Err = parseHunks(lines[i:end])
+		if file.Err == nil {
+			file.Err = file.validate()
+		}
+		files = append(files, file)
+		i = end - 1
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no unified diff found")
+	}
+	return files, nil
+}
+
+// endOfFile indica se a linha começa o diff de outro arquivo ou fecha o bloco de código
+func endOfFile(lines []string, i int) bool {
+	line := lines[i]
+	switch {
+	case strings.HasPrefix(line, "```"), strings.HasPrefix(line, "diff --git "):
+		return true
+	case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+		return true
+	}
+	return false
+}
+
+func parseHunks(lines []string) ([]Hunk, error) {
+	var hunks []Hunk
+	for i := 0; i < len(lines); i++ {
+		match := hunkHeader.FindStringSubmatch(lines[i])
+		if match == nil {
+			// Linhas como "index ..." ou "new file mode" entre os cabeçalhos
+			if len(hunks) == 0 || strings.TrimSpace(lines[i]) == "" {
+				continue
+			}
+			return nil, fmt.Errorf("unexpected line outside of a hunk: %q", lines[i])
+		}
+
+		hunk := Hunk{
+			OldStart: atoi(match[1], 0),
+			OldLines: atoi(match[2], 1),
+			NewStart: atoi(match[3], 0),
+			NewLines: atoi(match[4], 1),
+		}
+
+		old, added := 0, 0
+		for i+1 < len(lines) && (old < hunk.OldLines || added < hunk.NewLines) {
+			line := lines[i+1]
+			if strings.HasPrefix(line, `\`) {
+				// "\ No newline at end of file"
+				i++
+				continue
+			}
+			if line == "" {
+				// Editores e modelos costumam remover o espaço das linhas de contexto vazias
+				line = " "
+			}
+
+			kind := line[0]
+			switch kind {
+			case ' ':
+				old++
+				added++
+			case '-':
+				old++
+			case '+':
+				added++
+			default:
+				return nil, fmt.Errorf("hunk at line %d: invalid line %q", hunk.OldStart, line)
+			}
+			hunk.Lines = append(hunk.Lines, Line{Kind: kind, Text: line[1:]})
+			i++
+		}
+
+		if old != hunk.OldLines || added != hunk.NewLines {
+			return nil, fmt.Errorf("hunk at line %d: expected %d old and %d new lines, found %d and %d",
+				hunk.OldStart, hunk.OldLines, hunk.NewLines, old, added)
+		}
+		if !hunk.changes() {
+			return nil, fmt.Errorf("hunk at line %d has no changes", hunk.OldStart)
+		}
+		hunks = append(hunks, hunk)
+	}
+
+	if len(hunks) == 0 {
+		return nil, fmt.Errorf("no hunks found")
+	}
+	return hunks, nil
+}
+
+// CheckPaths verifica se os caminhos do diff são relativos e ficam dentro do
+// projeto. Um diff com caminho inválido não pode ser aplicado nem regenerado.
+func (f FileDiff) CheckPaths() error {
+	if f.OldPath == DevNull && f.NewPath == DevNull {
+		return fmt.Errorf("both paths are %s", DevNull)
+	}
+	for _, p := range []string{f.OldPath, f.NewPath} {
+		if p == DevNull {
+			continue
+		}
+		if err := checkPath(p); err != nil {
+			return err
+		}
+	}
+	if !f.Created() && !f.Deleted() && f.OldPath != f.NewPath {
+		return fmt.Errorf("renaming %s to %s is not supported", f.OldPath, f.NewPath)
+	}
+	return nil
+}
+
+// validate verifica os caminhos do arquivo e a coerência dos hunks com a criação ou remoção
+func (f FileDiff) validate() error {
+	if err := f.CheckPaths(); err != nil {
+		return err
+	}
+
+	for _, hunk := range f.Hunks {
+		for _, line := range hunk.Lines {
+			if f.Created() && line.Kind != '+' {
+				return fmt.Errorf("new file %s can only add lines", f.NewPath)
+			}
+			if f.Deleted() && line.Kind != '-' {
+				return fmt.Errorf("deleted file %s can only remove lines", f.OldPath)
+			}
+		}
+	}
+	return nil
+}
+
+func checkPath(p string) error {
+	if p == "" || strings.HasPrefix(p, "/") || strings.Contains(p, `\`) {
+		return fmt.Errorf("invalid path %q", p)
+	}
+	if clean := path.Clean(p); clean != p || clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+		return fmt.Errorf("invalid path %q", p)
+	}
+	return nil
+}
+
+// Apply aplica os hunks ao conteúdo atual do arquivo. Cada hunk é procurado na
+// linha indicada e, se o modelo errou a numeração, na posição mais próxima em
+// que o contexto coincide.
+func (f FileDiff) Apply(content string) (string, error) {
+	if f.Err != nil {
+		return "", f.Err
+	}
+	if f.Created() {
+		if content != "" {
+			return "", fmt.Errorf("%s already exists", f.NewPath)
+		}
+		var sb strings.Builder
+		for _, hunk := range f.Hunks {
+			for _, line := range hunk.Lines {
+				sb.WriteString(line.Text)
+				sb.WriteByte('\n')
+			}
+		}
+		return sb.String(), nil
+	}
+
+	trailingNewline := content == "" || strings.HasSuffix(content, "\n")
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if content == "" {
+		lines = nil
+	}
+
+	// from evita que um hunk seja aplicado antes do anterior
+	from := 0
+	for _, hunk := range f.Hunks {
+		old, updated := hunk.split()
+		at := locate(lines, old, hunk.OldStart-1, from)
+		if at < 0 {
+			return "", fmt.Errorf("hunk at line %d does not match %s", hunk.OldStart, f.Path())
+		}
+
+		result := make([]string, 0, len(lines)-len(old)+len(updated))
+		result = append(result, lines[:at]...)
+		result = append(result, updated...)
+		result = append(result, lines[at+len(old):]...)
+		lines = result
+		from = at + len(updated)
+	}
+
+	if f.Deleted() {
+		if len(lines) > 0 {
+			return "", fmt.Errorf("diff removes %s but does not remove all of its lines", f.OldPath)
+		}
+		return "", nil
+	}
+
+	output := strings.Join(lines, "\n")
+	if trailingNewline && len(lines) > 0 {
+		output += "\n"
+	}
+	return output, nil
+}
+
+// String devolve o diff do arquivo no formato unificado
+func (f FileDiff) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", displayPath(f.OldPath, "a/"), displayPath(f.NewPath, "b/"))
+	for _, hunk := range f.Hunks {
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", hunk.OldStart, hunk.OldLines, hunk.NewStart, hunk.NewLines)
+		for _, line := range hunk.Lines {
+			sb.WriteByte(line.Kind)
+			sb.WriteString(line.Text)
+			sb.WriteByte('\n')
+		}
+	}
+	return sb.String()
+}
+
+// split separa as linhas esperadas no arquivo das linhas que as substituem
+func (h Hunk) split() (old, updated []string) {
+	for _, line := range h.Lines {
+		if line.Kind != '+' {
+			old = append(old, line.Text)
+		}
+		if line.Kind != '-' {
+			updated = append(updated, line.Text)
+		}
+	}
+	return old, updated
+}
+
+func (h Hunk) changes() bool {
+	for _, line := range h.Lines {
+		if line.Kind != ' ' {
+			return true
+		}
+	}
+	return false
+}
+
+// locate procura as linhas a partir de from, preferindo a posição mais próxima
+// de hint. Uma comparação que ignora espaços no fim das linhas é a última tentativa.
+func locate(lines, old []string, hint, from int) int {
+	if len(old) == 0 {
+		// Hunk que só adiciona linhas: vale a posição indicada
+		if hint < from {
+			hint = from
+		}
+		if hint > len(lines) {
+			hint = len(lines)
+		}
+		return hint
+	}
+
+	for _, equal := range []func(a, b string) bool{
+		func(a, b string) bool { return a == b },
+		func(a, b string) bool { return strings.TrimRight(a, " \t") == strings.TrimRight(b, " \t") },
+	} {
+		best := -1
+		for at := from; at+len(old) <= len(lines); at++ {
+			if !matches(lines[at:at+len(old)], old, equal) {
+				continue
+			}
+			if best < 0 || abs(at-hint) < abs(best-hint) {
+				best = at
+			}
+		}
+		if best >= 0 {
+			return best
+		}
+	}
+	return -1
+}
+
+func matches(lines, old []string, equal func(a, b string) bool) bool {
+	for i := range old {
+		if !equal(lines[i], old[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// diffPath remove o timestamp e o prefixo a/ ou b/ do caminho do cabeçalho
+func diffPath(header, prefix string) string {
+	p := strings.TrimSpace(header)
+	if i := strings.IndexByte(p, '\t'); i >= 0 {
+		p = p[:i]
+	}
+	if p == DevNull {
+		return p
+	}
+	p = strings.TrimPrefix(p, prefix)
+	return strings.TrimPrefix(p, "/")
+}
+
+func displayPath(p, prefix string) string {
+	if p == DevNull {
+		return p
+	}
+	return prefix + p
+}
+
+func atoi(s string, fallback int) int {
+	if s == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return fallback
+	}
+	return n
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
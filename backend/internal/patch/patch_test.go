@@ -0,0 +1,200 @@
+package patch
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		paths   []string
+		created []bool
+		deleted []bool
+		wantErr []bool
+	}{
+		{
+			name:    "modified file with explanation and fences",
+			text:    "Here is the change:\n```diff\n--- a/main.go\n+++ b/main.go\n@@ -1,2 +1,2 @@\n package main\n-var x = 1\n+var x = 2\n```\n",
+			paths:   []string{"main.go"},
+			created: []bool{false},
+			deleted: []bool{false},
+			wantErr: []bool{false},
+		},
+		{
+			name:    "created and deleted files",
+			text:    "--- /dev/null\n+++ b/new.txt\n@@ -0,0 +1,1 @@\n+hello\n--- a/old.txt\n+++ /dev/null\n@@ -1,1 +0,0 @@\n-bye\n",
+			paths:   []string{"new.txt", "old.txt"},
+			created: []bool{true, false},
+			deleted: []bool{false, true},
+			wantErr: []bool{false, false},
+		},
+		{
+			name:    "hunk with wrong line count",
+			text:    "--- a/a.txt\n+++ b/a.txt\n@@ -1,3 +1,3 @@\n-a\n+b\n",
+			paths:   []string{"a.txt"},
+			created: []bool{false},
+			deleted: []bool{false},
+			wantErr: []bool{true},
+		},
+		{
+			name:    "path outside of the project",
+			text:    "--- a/../../etc/x\n+++ /dev/null\n@@ -1,1 +0,0 @@\n-x\n",
+			paths:   []string{"../../etc/x"},
+			created: []bool{false},
+			deleted: []bool{true},
+			wantErr: []bool{true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := Parse(tt.text)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if len(files) != len(tt.paths) {
+				t.Fatalf("Parse() returned %d files, want %d", len(files), len(tt.paths))
+			}
+			for i, f := range files {
+				if f.Path() != tt.paths[i] {
+					t.Errorf("file %d: Path() = %q, want %q", i, f.Path(), tt.paths[i])
+				}
+				if f.Created() != tt.created[i] {
+					t.Errorf("file %d: Created() = %v, want %v", i, f.Created(), tt.created[i])
+				}
+				if f.Deleted() != tt.deleted[i] {
+					t.Errorf("file %d: Deleted() = %v, want %v", i, f.Deleted(), tt.deleted[i])
+				}
+				if (f.Err != nil) != tt.wantErr[i] {
+					t.Errorf("file %d: Err = %v, wantErr %v", i, f.Err, tt.wantErr[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseWithoutDiff(t *testing.T) {
+	if _, err := Parse("just an answer"); err == nil {
+		t.Fatal("Parse() error = nil, want an error")
+	}
+}
+
+func TestCheckPaths(t *testing.T) {
+	tests := []struct {
+		name    string
+		oldPath string
+		newPath string
+		wantErr bool
+	}{
+		{"relative path", "src/main.go", "src/main.go", false},
+		{"dots inside a name", "a..b.txt", "a..b.txt", false},
+		{"created file", DevNull, "src/new.go", false},
+		{"parent directory", "../x", "../x", true},
+		{"escaping after clean", "src/../../x", DevNull, true},
+		{"current directory", ".", ".", true},
+		{"absolute path", DevNull, "/etc/passwd", true},
+		{"backslash", `a\b`, `a\b`, true},
+		{"both dev null", DevNull, DevNull, true},
+		{"rename", "a.go", "b.go", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := FileDiff{OldPath: tt.oldPath, NewPath: tt.newPath}.CheckPaths()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckPaths() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		diff    string
+		content string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "replace a line",
+			diff:    "--- a/f\n+++ b/f\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+			content: "a\nb\nc\n",
+			want:    "a\nB\nc\n",
+		},
+		{
+			name:    "wrong line numbers are located by context",
+			diff:    "--- a/f\n+++ b/f\n@@ -10,2 +10,2 @@\n c\n-d\n+D\n",
+			content: "a\nb\nc\nd\n",
+			want:    "a\nb\nc\nD\n",
+		},
+		{
+			name:    "trailing whitespace is tolerated",
+			diff:    "--- a/f\n+++ b/f\n@@ -1,2 +1,2 @@\n a\n-b\n+B\n",
+			content: "a  \nb\n",
+			want:    "a\nB\n",
+		},
+		{
+			name:    "keeps missing trailing newline",
+			diff:    "--- a/f\n+++ b/f\n@@ -1,1 +1,1 @@\n-a\n+b\n",
+			content: "a",
+			want:    "b",
+		},
+		{
+			name:    "create file",
+			diff:    "--- /dev/null\n+++ b/f\n@@ -0,0 +1,2 @@\n+x\n+y\n",
+			content: "",
+			want:    "x\ny\n",
+		},
+		{
+			name:    "create existing file",
+			diff:    "--- /dev/null\n+++ b/f\n@@ -0,0 +1,1 @@\n+x\n",
+			content: "old\n",
+			wantErr: true,
+		},
+		{
+			name:    "delete file",
+			diff:    "--- a/f\n+++ /dev/null\n@@ -1,2 +0,0 @@\n-a\n-b\n",
+			content: "a\nb\n",
+			want:    "",
+		},
+		{
+			name:    "partial delete",
+			diff:    "--- a/f\n+++ /dev/null\n@@ -1,1 +0,0 @@\n-a\n",
+			content: "a\nb\n",
+			wantErr: true,
+		},
+		{
+			name:    "context does not match",
+			diff:    "--- a/f\n+++ b/f\n@@ -1,2 +1,2 @@\n x\n-y\n+z\n",
+			content: "a\nb\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := Parse(tt.diff)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			got, err := files[0].Apply(tt.content)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("Apply() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStringRoundTrip(t *testing.T) {
+	diff := "--- a/f\n+++ b/f\n@@ -1,2 +1,2 @@\n a\n-b\n+B\n"
+	files, err := Parse(diff)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got := files[0].String(); got != diff {
+		t.Errorf("String() = %q, want %q", got, diff)
+	}
+}
@@ -0,0 +1,452 @@
+package pipeline
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+
+	"backend-ai-sdlc/internal/documents"
+	"backend-ai-sdlc/internal/middleware"
+	"backend-ai-sdlc/internal/models"
+)
+
+// OutputType indica o que uma fase produz e como o engine a executa
+type OutputType string
+
+const (
+	// OutputJSONStructure é a estrutura de diretórios e arquivos do projeto, em JSON
+	OutputJSONStructure OutputType = "json_structure"
+	// OutputFileSet gera o conteúdo de cada arquivo da estrutura; não depende de entrada do usuário
+	OutputFileSet OutputType = "file_set"
+	// OutputDocument é uma resposta em texto livre
+	OutputDocument OutputType = "document"
+	// OutputTestSuite gera um arquivo de testes para cada arquivo-fonte já gerado
+	OutputTestSuite OutputType = "test_suite"
+	// OutputReview revisa cada arquivo gerado e produz achados estruturados
+	OutputReview OutputType = "review"
+	// OutputCI gera o workflow de CI para o alvo escolhido pelo usuário
+	OutputCI OutputType = "ci"
+	// OutputPatch pede as mudanças nos arquivos já gerados como um diff unificado,
+	// aplicado após a confirmação do usuário
+	OutputPatch OutputType = "patch"
+)
+
+// PromptKind identifica um dos templates de prompt de uma fase
+type PromptKind string
+
+const (
+	PromptMain     PromptKind = "prompt"
+	PromptRevision PromptKind = "revision_prompt"
+	PromptFile     PromptKind = "file_prompt"
+	PromptFix      PromptKind = "fix_prompt"
+	PromptBulk     PromptKind = "bulk_prompt"
+)
+
+var PromptKinds = []PromptKind{PromptMain, PromptRevision, PromptFile, PromptFix, PromptBulk}
+
+// Transitions define a próxima fase após YES/NO, ou após a fase terminar
+// quando ela não exige confirmação. Skip é a fase seguinte quando o usuário
+// pula uma fase opcional. Uma transição vazia encerra o pipeline.
+type Transitions struct {
+	Yes         string `json:"yes,omitempty"`
+	No          string `json:"no,omitempty"`
+	Next        string `json:"next,omitempty"`
+	Skip        string `json:"skip,omitempty"`
+	YesMessage  string `json:"yes_message,omitempty"`
+	NoMessage   string `json:"no_message,omitempty"`
+	SkipMessage string `json:"skip_message,omitempty"`
+}
+
+// Phase é uma etapa do pipeline. Scaffold escolhe um template de projeto pela
+// stack e estende a sua árvore; BulkPrompt gera todos os arquivos em uma única
+// resposta no formato "--- [caminho] ---" quando o projeto tem até BulkMaxFiles arquivos.
+// Middlewares são aplicados aos prompts e respostas da fase, depois dos do pipeline.
+type Phase struct {
+	ID                   string              `json:"id"`
+	Name                 string              `json:"name"`
+	Output               OutputType          `json:"output"`
+	RequiresConfirmation bool                `json:"requires_confirmation"`
+	Auto                 bool                `json:"auto,omitempty"`
+	Optional             bool                `json:"optional,omitempty"`
+	Scaffold             bool                `json:"scaffold,omitempty"`
+	StartPrompt          string              `json:"start_prompt,omitempty"`
+	InputPrompt          string              `json:"input_prompt,omitempty"`
+	Document             string              `json:"document,omitempty"`
+	Prompt               string              `json:"prompt,omitempty"`
+	RevisionPrompt       string              `json:"revision_prompt,omitempty"`
+	FilePrompt           string              `json:"file_prompt,omitempty"`
+	FixPrompt            string              `json:"fix_prompt,omitempty"`
+	FixRounds            int                 `json:"fix_rounds,omitempty"`
+	BulkPrompt           string              `json:"bulk_prompt,omitempty"`
+	BulkMaxFiles         int                 `json:"bulk_max_files,omitempty"`
+	Message              string              `json:"message,omitempty"`
+	ConfirmationPrompt   string              `json:"confirmation_prompt,omitempty"`
+	Middlewares          []middleware.Config `json:"middlewares,omitempty"`
+	Transitions          Transitions         `json:"transitions"`
+
+	prompt         *template.Template
+	revisionPrompt *template.Template
+	filePrompt     *template.Template
+	fixPrompt      *template.Template
+	bulkPrompt     *template.Template
+	chain          *middleware.Chain
+}
+
+// Definition é um fluxo de SDLC completo, carregado de um arquivo JSON.
+// Followup é a fase que responde às mensagens depois que o pipeline termina.
+// Middlewares são aplicados a todas as fases, antes dos middlewares de cada uma.
+type Definition struct {
+	Name        string              `json:"name"`
+	Version     int                 `json:"version"`
+	Start       string              `json:"start"`
+	Followup    string              `json:"followup"`
+	Middlewares []middleware.Config `json:"middlewares,omitempty"`
+	Phases      []Phase             `json:"phases"`
+
+	phases      map[string]*Phase
+	transitions map[models.State]map[Input][]models.State
+}
+
+// PromptData são as variáveis disponíveis nos templates de prompt.
+// Previous é a saída anterior da fase, usada nos prompts de revisão, e
+// Documents traz os documentos já confirmados em Markdown, por tipo.
+type PromptData struct {
+	Input       string
+	Description string
+	Previous    string
+	Documents   map[string]string
+	Step        int
+	Time        string
+	Structure   string
+	FilePath    string
+	AppName     string
+	// ContractFile indica que FilePath é um handler do backend ou um cliente de API do frontend
+	ContractFile bool
+	// Source, TestPath e Framework descrevem o arquivo-fonte nas fases de testes
+	Source    string
+	TestPath  string
+	Framework string
+	// Diagnostics são os erros de compilação do arquivo, usados no fix_prompt
+	Diagnostics string
+	// CITarget e Stacks descrevem o alvo de CI escolhido e as stacks detectadas no projeto
+	CITarget string
+	Stacks   string
+	// Files traz o conteúdo atual dos arquivos do workspace, e Patch o diff que
+	// não se aplicou ao arquivo regenerado, nas fases de mudança
+	Files string
+	Patch string
+	// Template descreve o template de projeto escolhido e os arquivos que ele já fornece
+	Template string
+	// Tree lista os arquivos da estrutura, Stack resume a stack do projeto e
+	// PriorFiles lista os arquivos já gravados no workspace
+	Tree       string
+	Stack      string
+	PriorFiles string
+}
+
+//go:embed default.json
+var defaultDefinition []byte
+
+// Default retorna o pipeline padrão embutido no binário
+func Default() (*Definition, error) {
+	return Parse(defaultDefinition)
+}
+
+// Load lê um pipeline de um arquivo JSON
+func Load(path string) (*Definition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading pipeline file: %v", err)
+	}
+	return Parse(data)
+}
+
+func Parse(data []byte) (*Definition, error) {
+	var def Definition
+	if err := json.Unmarshal(data, &def); err != nil {
+		return nil, fmt.Errorf("error parsing pipeline definition: %v", err)
+	}
+	if err := def.compile(); err != nil {
+		return nil, fmt.Errorf("invalid pipeline %q: %v", def.Name, err)
+	}
+	return &def, nil
+}
+
+func (d *Definition) Phase(id string) (*Phase, bool) {
+	phase, ok := d.phases[id]
+	return phase, ok
+}
+
+// compile valida as fases e transições e faz o parse dos templates
+func (d *Definition) compile() error {
+	if len(d.Phases) == 0 {
+		return fmt.Errorf("no phases defined")
+	}
+
+	d.phases = make(map[string]*Phase, len(d.Phases))
+	for i := range d.Phases {
+		phase := &d.Phases[i]
+		if phase.ID == "" {
+			return fmt.Errorf("phase %d has no id", i)
+		}
+		if _, exists := d.phases[phase.ID]; exists {
+			return fmt.Errorf("duplicate phase id %q", phase.ID)
+		}
+		d.phases[phase.ID] = phase
+	}
+
+	if d.Start == "" {
+		d.Start = d.Phases[0].ID
+	}
+	if start, ok := d.phases[d.Start]; !ok || start.Generates() {
+		return fmt.Errorf("start phase %q not found or not interactive", d.Start)
+	}
+	if followup, ok := d.phases[d.Followup]; !ok || followup.Generates() {
+		return fmt.Errorf("followup phase %q not found or not interactive", d.Followup)
+	}
+
+	for _, phase := range d.phases {
+		if err := phase.compile(); err != nil {
+			return fmt.Errorf("phase %q: %v", phase.ID, err)
+		}
+		if err := phase.buildChain(d.Middlewares); err != nil {
+			return fmt.Errorf("phase %q: %v", phase.ID, err)
+		}
+		for _, target := range []string{phase.Transitions.Yes, phase.Transitions.No, phase.Transitions.Next, phase.Transitions.Skip} {
+			if _, ok := d.phases[target]; target != "" && !ok {
+				return fmt.Errorf("phase %q: transition to unknown phase %q", phase.ID, target)
+			}
+		}
+	}
+	d.transitions = buildTransitions(d)
+	return nil
+}
+
+func (p *Phase) compile() error {
+	switch p.Output {
+	case OutputJSONStructure, OutputDocument, OutputCI:
+		if p.Prompt == "" {
+			return fmt.Errorf("output %s requires a prompt", p.Output)
+		}
+	case OutputFileSet, OutputTestSuite, OutputReview:
+		if p.FilePrompt == "" {
+			return fmt.Errorf("output %s requires a file_prompt", p.Output)
+		}
+	case OutputPatch:
+		// O file_prompt regenera o arquivo inteiro quando o diff não se aplica
+		if p.Prompt == "" || p.FilePrompt == "" {
+			return fmt.Errorf("output %s requires a prompt and a file_prompt", p.Output)
+		}
+	default:
+		return fmt.Errorf("unknown output type %q", p.Output)
+	}
+
+	if p.Scaffold && p.Output != OutputJSONStructure {
+		return fmt.Errorf("output %s does not support scaffold", p.Output)
+	}
+	if p.Optional && p.StartPrompt == "" {
+		return fmt.Errorf("optional phase requires a start_prompt")
+	}
+
+	if p.Document != "" {
+		if p.Output != OutputDocument {
+			return fmt.Errorf("document %q requires output %s", p.Document, OutputDocument)
+		}
+		if _, ok := documents.Lookup(p.Document); !ok {
+			return fmt.Errorf("unknown document %q, expected one of %v", p.Document, documents.Names())
+		}
+	}
+
+	var err error
+	if p.Prompt != "" {
+		if p.prompt, err = template.New(p.ID).Parse(p.Prompt); err != nil {
+			return fmt.Errorf("error parsing prompt: %v", err)
+		}
+	}
+	if p.RevisionPrompt != "" {
+		if p.Generates() {
+			return fmt.Errorf("output %s does not support revision_prompt", p.Output)
+		}
+		if p.revisionPrompt, err = template.New(p.ID + "_revision").Parse(p.RevisionPrompt); err != nil {
+			return fmt.Errorf("error parsing revision_prompt: %v", err)
+		}
+	}
+	if p.FilePrompt != "" {
+		if p.filePrompt, err = template.New(p.ID + "_file").Parse(p.FilePrompt); err != nil {
+			return fmt.Errorf("error parsing file_prompt: %v", err)
+		}
+	}
+	if p.FixPrompt != "" {
+		if !p.Generates() {
+			return fmt.Errorf("output %s does not support fix_prompt", p.Output)
+		}
+		if p.fixPrompt, err = template.New(p.ID + "_fix").Parse(p.FixPrompt); err != nil {
+			return fmt.Errorf("error parsing fix_prompt: %v", err)
+		}
+	}
+	if p.FixRounds < 0 || (p.FixRounds > 0 && p.fixPrompt == nil) {
+		return fmt.Errorf("fix_rounds requires a fix_prompt and cannot be negative")
+	}
+	if p.BulkPrompt != "" {
+		if p.Output != OutputFileSet {
+			return fmt.Errorf("output %s does not support bulk_prompt", p.Output)
+		}
+		if p.bulkPrompt, err = template.New(p.ID + "_bulk").Parse(p.BulkPrompt); err != nil {
+			return fmt.Errorf("error parsing bulk_prompt: %v", err)
+		}
+	}
+	if p.BulkMaxFiles < 0 || (p.BulkMaxFiles > 0 && p.bulkPrompt == nil) {
+		return fmt.Errorf("bulk_max_files requires a bulk_prompt and cannot be negative")
+	}
+	return nil
+}
+
+// buildChain monta a cadeia de middlewares do pipeline seguida da cadeia da fase
+func (p *Phase) buildChain(common []middleware.Config) error {
+	configs := append(append([]middleware.Config{}, common...), p.Middlewares...)
+	for _, config := range configs {
+		for _, kind := range config.Kinds {
+			if !knownKind(PromptKind(kind)) {
+				return fmt.Errorf("middleware %q: unknown prompt kind %q, expected one of %v", config.Name, kind, PromptKinds)
+			}
+		}
+	}
+	chain, err := middleware.Build(configs)
+	if err != nil {
+		return err
+	}
+	p.chain = chain
+	return nil
+}
+
+func knownKind(kind PromptKind) bool {
+	for _, k := range PromptKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Chain retorna os middlewares aplicados às chamadas ao Claude da fase
+func (p *Phase) Chain() *middleware.Chain {
+	return p.chain
+}
+
+// Generates indica se a fase percorre os arquivos do projeto sem depender de entrada do usuário
+func (p *Phase) Generates() bool {
+	return p.Output == OutputFileSet || p.Output == OutputTestSuite || p.Output == OutputReview
+}
+
+// Revisable indica se um NO leva a um ciclo de revisão em vez da transição "no"
+func (p *Phase) Revisable() bool {
+	return p.revisionPrompt != nil
+}
+
+// Fixable indica se os arquivos Go gerados pela fase passam pelo ciclo de compilação e correção
+func (p *Phase) Fixable() bool {
+	return p.fixPrompt != nil && p.FixRounds > 0
+}
+
+// Bulk indica se os arquivos do projeto são gerados em uma única resposta
+func (p *Phase) Bulk(files int) bool {
+	return p.bulkPrompt != nil && files > 0 && files <= p.BulkMaxFiles
+}
+
+// Next retorna a fase seguinte após a resposta do usuário
+func (p *Phase) Next(confirmed bool) (string, string) {
+	if confirmed {
+		return p.Transitions.Yes, p.Transitions.YesMessage
+	}
+	return p.Transitions.No, p.Transitions.NoMessage
+}
+
+// Render monta o prompt do tipo indicado com os dados da conversa
+func (p *Phase) Render(kind PromptKind, data PromptData) (string, error) {
+	return render(p.template(kind), data)
+}
+
+// Source retorna o texto do template do tipo indicado, ou "" se a fase não o define
+func (p *Phase) Source(kind PromptKind) string {
+	switch kind {
+	case PromptMain:
+		return p.Prompt
+	case PromptRevision:
+		return p.RevisionPrompt
+	case PromptFile:
+		return p.FilePrompt
+	case PromptFix:
+		return p.FixPrompt
+	case PromptBulk:
+		return p.BulkPrompt
+	}
+	return ""
+}
+
+func (p *Phase) template(kind PromptKind) *template.Template {
+	switch kind {
+	case PromptMain:
+		return p.prompt
+	case PromptRevision:
+		return p.revisionPrompt
+	case PromptFile:
+		return p.filePrompt
+	case PromptFix:
+		return p.fixPrompt
+	case PromptBulk:
+		return p.bulkPrompt
+	}
+	return nil
+}
+
+// PromptVersion identifica a versão dos prompts embutidos no pipeline
+func (d *Definition) PromptVersion() string {
+	return fmt.Sprintf("%s@%d", d.Name, d.Version)
+}
+
+// Prompts retorna os templates de prompt de todas as fases, pelo nome "fase/tipo"
+func (d *Definition) Prompts() map[string]string {
+	prompts := make(map[string]string)
+	for _, phase := range d.phases {
+		for _, kind := range PromptKinds {
+			if source := phase.Source(kind); source != "" {
+				prompts[PromptName(phase.ID, kind)] = source
+			}
+		}
+	}
+	return prompts
+}
+
+// CheckPrompt verifica se um template externo substitui um prompt definido no
+// pipeline e se ele usa apenas variáveis de PromptData
+func (d *Definition) CheckPrompt(name string, tmpl *template.Template) error {
+	phaseID, kind, _ := strings.Cut(name, "/")
+	phase, ok := d.phases[phaseID]
+	if !ok || phase.Source(PromptKind(kind)) == "" {
+		return fmt.Errorf("pipeline %q has no prompt %q", d.Name, name)
+	}
+	if err := tmpl.Execute(io.Discard, PromptData{}); err != nil {
+		return fmt.Errorf("error rendering prompt: %v", err)
+	}
+	return nil
+}
+
+// PromptName é o nome de um template de prompt fora do pipeline, como "files/file_prompt"
+func PromptName(phaseID string, kind PromptKind) string {
+	return phaseID + "/" + string(kind)
+}
+
+func render(tmpl *template.Template, data PromptData) (string, error) {
+	if tmpl == nil {
+		return "", fmt.Errorf("no prompt template defined")
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("error rendering prompt: %v", err)
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,200 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+
+	"backend-ai-sdlc/internal/models"
+)
+
+// Input é o tipo de mensagem que leva a conversa de um estado para outro
+type Input string
+
+const (
+	InputMessage Input = "message"
+	InputYes     Input = "yes"
+	InputNo      Input = "no"
+	// InputDone é interno: a geração de arquivos terminou
+	InputDone Input = "done"
+)
+
+// InvalidTransitionError é devolvido ao cliente quando a mensagem não é aceita no estado atual
+type InvalidTransitionError struct {
+	State    models.State `json:"state"`
+	Input    Input        `json:"input"`
+	Accepted []Input      `json:"accepted"`
+}
+
+func (e *InvalidTransitionError) Error() string {
+	accepted := make([]string, len(e.Accepted))
+	for i, input := range e.Accepted {
+		accepted[i] = string(input)
+	}
+	return fmt.Sprintf("input %q is not accepted in state %s; accepted inputs: %s", e.Input, e.State, strings.Join(accepted, ", "))
+}
+
+// InputFor classifica a mensagem do cliente
+func InputFor(chatReq models.ChatRequest) Input {
+	if !chatReq.IsConfirmation {
+		return InputMessage
+	}
+	if strings.EqualFold(strings.TrimSpace(chatReq.Message), "YES") {
+		return InputYes
+	}
+	return InputNo
+}
+
+// Accepted retorna as entradas do cliente aceitas no estado
+func (d *Definition) Accepted(state models.State) []Input {
+	var inputs []Input
+	for _, input := range []Input{InputMessage, InputYes, InputNo} {
+		if _, ok := d.transitions[state][input]; ok {
+			inputs = append(inputs, input)
+		}
+	}
+	return inputs
+}
+
+// CheckInput verifica se a entrada é aceita no estado atual
+func (d *Definition) CheckInput(state models.State, input Input) error {
+	if _, ok := d.transitions[state][input]; !ok {
+		return &InvalidTransitionError{State: state, Input: input, Accepted: d.Accepted(state)}
+	}
+	return nil
+}
+
+// Transition valida a transição de from para to causada pela entrada
+func (d *Definition) Transition(from models.State, input Input, to models.State) error {
+	targets, ok := d.transitions[from][input]
+	if !ok {
+		return &InvalidTransitionError{State: from, Input: input, Accepted: d.Accepted(from)}
+	}
+	for _, target := range targets {
+		if target == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("transition from %s to %s on %q is not allowed", from, to, input)
+}
+
+// RequiresConfirmation indica se o estado aguarda YES/NO
+func RequiresConfirmation(state models.State) bool {
+	switch state {
+	case models.StateStructureProposed, models.StateAwaitingReview, models.StateAwaitingStart:
+		return true
+	}
+	return false
+}
+
+// transitionBuilder deriva a tabela de transições das fases do pipeline,
+// percorrendo os estados em que cada fase pode deixar a conversa como o engine
+// faz. Só as arestas alcançáveis a partir da fase inicial entram na tabela.
+type transitionBuilder struct {
+	d       *Definition
+	table   map[models.State]map[Input][]models.State
+	visited map[string]bool
+}
+
+func buildTransitions(d *Definition) map[models.State]map[Input][]models.State {
+	b := &transitionBuilder{
+		d:       d,
+		table:   make(map[models.State]map[Input][]models.State),
+		visited: make(map[string]bool),
+	}
+	b.wait(d.phases[d.Start], models.StateAwaitingDescription)
+	return b.table
+}
+
+// add registra a aresta e as entradas aceitas no estado de destino, em que a conversa está na fase phase
+func (b *transitionBuilder) add(from models.State, input Input, to models.State, phase *Phase) {
+	if b.table[from] == nil {
+		b.table[from] = make(map[Input][]models.State)
+	}
+	targets := b.table[from][input]
+	known := false
+	for _, target := range targets {
+		known = known || target == to
+	}
+	if !known {
+		b.table[from][input] = append(targets, to)
+	}
+	b.wait(phase, to)
+}
+
+// wait registra as entradas que a fase aceita enquanto a conversa está no estado
+func (b *transitionBuilder) wait(phase *Phase, state models.State) {
+	key := phase.ID + "/" + string(state)
+	if b.visited[key] {
+		return
+	}
+	b.visited[key] = true
+
+	switch state {
+	case models.StateAwaitingDescription, models.StateComplete:
+		b.run(state, InputMessage, phase)
+	case models.StateGenerating:
+		b.run(state, InputDone, phase)
+	case models.StateAwaitingFeedback:
+		b.add(state, InputMessage, models.StateStructureProposed, phase)
+	case models.StateAwaitingStart:
+		b.enter(state, InputNo, phase.Transitions.Skip)
+		b.begin(state, InputYes, phase)
+	case models.StateStructureProposed, models.StateAwaitingReview:
+		b.enter(state, InputYes, phase.Transitions.Yes)
+		if phase.Output != OutputPatch && phase.Revisable() {
+			b.add(state, InputNo, models.StateAwaitingFeedback, phase)
+		} else {
+			b.enter(state, InputNo, phase.Transitions.No)
+		}
+	}
+}
+
+// enter segue Engine.enterPhase; uma fase vazia encerra o pipeline
+func (b *transitionBuilder) enter(from models.State, input Input, phaseID string) {
+	if phaseID == "" {
+		b.add(from, input, models.StateComplete, b.d.phases[b.d.Followup])
+		return
+	}
+	phase := b.d.phases[phaseID]
+	if phase.Optional {
+		b.add(from, input, models.StateAwaitingStart, phase)
+		return
+	}
+	b.begin(from, input, phase)
+}
+
+// begin segue Engine.beginPhase
+func (b *transitionBuilder) begin(from models.State, input Input, phase *Phase) {
+	switch {
+	case phase.Generates():
+		b.add(from, input, models.StateGenerating, phase)
+	case phase.Auto:
+		b.run(from, input, phase)
+	default:
+		b.add(from, input, models.StateAwaitingDescription, phase)
+	}
+}
+
+// run segue Engine.runPhase
+func (b *transitionBuilder) run(from models.State, input Input, phase *Phase) {
+	if phase.Output == OutputCI {
+		// Um alvo de CI desconhecido é pedido de novo
+		b.add(from, input, models.StateAwaitingDescription, phase)
+	}
+	if phase.Output == OutputPatch {
+		b.add(from, input, models.StateAwaitingReview, phase)
+	}
+	if from == models.StateComplete {
+		b.add(from, input, models.StateComplete, phase)
+		return
+	}
+	if phase.RequiresConfirmation {
+		to := models.StateStructureProposed
+		if phase.Generates() {
+			to = models.StateAwaitingReview
+		}
+		b.add(from, input, to, phase)
+		return
+	}
+	b.enter(from, input, phase.Transitions.Next)
+}
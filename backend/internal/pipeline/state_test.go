@@ -0,0 +1,132 @@
+package pipeline
+
+import (
+	"errors"
+	"testing"
+
+	"backend-ai-sdlc/internal/models"
+)
+
+func TestDefaultTransitions(t *testing.T) {
+	d, err := Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		from  models.State
+		input Input
+		to    models.State
+		ok    bool
+	}{
+		{"description proposes the first document", models.StateAwaitingDescription, InputMessage, models.StateStructureProposed, true},
+		{"unknown CI target is asked again", models.StateAwaitingDescription, InputMessage, models.StateAwaitingDescription, true},
+		{"CI finishes the pipeline", models.StateAwaitingDescription, InputMessage, models.StateComplete, true},
+		{"confirmed document runs the next one", models.StateStructureProposed, InputYes, models.StateStructureProposed, true},
+		{"confirmed structure generates the files", models.StateStructureProposed, InputYes, models.StateGenerating, true},
+		{"rejected document asks for feedback", models.StateStructureProposed, InputNo, models.StateAwaitingFeedback, true},
+		{"feedback proposes a revision", models.StateAwaitingFeedback, InputMessage, models.StateStructureProposed, true},
+		{"generated files await confirmation", models.StateGenerating, InputDone, models.StateAwaitingReview, true},
+		{"review leads to the optional CI", models.StateGenerating, InputDone, models.StateAwaitingStart, true},
+		{"confirmed files offer the tests", models.StateAwaitingReview, InputYes, models.StateAwaitingStart, true},
+		{"rejected files hand over to the chat", models.StateAwaitingReview, InputNo, models.StateComplete, true},
+		{"optional phase starts", models.StateAwaitingStart, InputYes, models.StateGenerating, true},
+		{"CI asks for its target", models.StateAwaitingStart, InputYes, models.StateAwaitingDescription, true},
+		{"skipped phase offers the next", models.StateAwaitingStart, InputNo, models.StateAwaitingStart, true},
+		{"chat proposes a change", models.StateComplete, InputMessage, models.StateAwaitingReview, true},
+		{"chat answers a question", models.StateComplete, InputMessage, models.StateComplete, true},
+
+		{"description cannot skip to generation", models.StateAwaitingDescription, InputMessage, models.StateGenerating, false},
+		{"rejected document is not skipped", models.StateStructureProposed, InputNo, models.StateComplete, false},
+		{"confirmed document does not finish the pipeline", models.StateStructureProposed, InputYes, models.StateComplete, false},
+		{"generation does not restart itself", models.StateGenerating, InputDone, models.StateGenerating, false},
+		{"rejected files are not generated again", models.StateAwaitingReview, InputNo, models.StateGenerating, false},
+		{"finished pipeline does not restart", models.StateComplete, InputMessage, models.StateStructureProposed, false},
+		{"description is not a confirmation", models.StateAwaitingDescription, InputYes, models.StateStructureProposed, false},
+		{"feedback is not a confirmation", models.StateAwaitingFeedback, InputNo, models.StateStructureProposed, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := d.Transition(tt.from, tt.input, tt.to); (err == nil) != tt.ok {
+				t.Errorf("Transition(%s, %s, %s) error = %v, want ok %v", tt.from, tt.input, tt.to, err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestCheckInput(t *testing.T) {
+	d, err := Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		state    models.State
+		input    Input
+		accepted []Input
+	}{
+		{models.StateAwaitingDescription, InputYes, []Input{InputMessage}},
+		{models.StateStructureProposed, InputMessage, []Input{InputYes, InputNo}},
+		{models.StateAwaitingReview, InputMessage, []Input{InputYes, InputNo}},
+		{models.StateGenerating, InputMessage, nil},
+		{models.StateComplete, InputNo, []Input{InputMessage}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.state)+"/"+string(tt.input), func(t *testing.T) {
+			var invalid *InvalidTransitionError
+			if err := d.CheckInput(tt.state, tt.input); !errors.As(err, &invalid) {
+				t.Fatalf("CheckInput() error = %v, want *InvalidTransitionError", err)
+			}
+			if len(invalid.Accepted) != len(tt.accepted) {
+				t.Fatalf("Accepted = %v, want %v", invalid.Accepted, tt.accepted)
+			}
+			for i := range tt.accepted {
+				if invalid.Accepted[i] != tt.accepted[i] {
+					t.Errorf("Accepted = %v, want %v", invalid.Accepted, tt.accepted)
+				}
+			}
+		})
+	}
+}
+
+// Um pipeline sem fases opcionais nem de revisão não aceita as respostas que só elas usam
+func TestTransitionsFollowDefinition(t *testing.T) {
+	d, err := Parse([]byte(`{
+		"name": "short",
+		"start": "structure",
+		"followup": "structure",
+		"phases": [
+			{
+				"id": "structure",
+				"output": "json_structure",
+				"requires_confirmation": true,
+				"prompt": "{{.Input}}",
+				"transitions": {"yes": "files"}
+			},
+			{
+				"id": "files",
+				"output": "file_set",
+				"file_prompt": "{{.FilePath}}"
+			}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, state := range []models.State{models.StateAwaitingFeedback, models.StateAwaitingStart} {
+		if accepted := d.Accepted(state); len(accepted) != 0 {
+			t.Errorf("Accepted(%s) = %v, want none", state, accepted)
+		}
+	}
+	if err := d.Transition(models.StateStructureProposed, InputNo, models.StateAwaitingFeedback); err == nil {
+		t.Error("NO without a revision_prompt led to awaiting_feedback")
+	}
+	if err := d.Transition(models.StateStructureProposed, InputNo, models.StateComplete); err != nil {
+		t.Errorf("NO without a \"no\" transition: %v", err)
+	}
+	if err := d.Transition(models.StateGenerating, InputDone, models.StateComplete); err != nil {
+		t.Errorf("last phase does not finish the pipeline: %v", err)
+	}
+}
@@ -0,0 +1,247 @@
+package prompts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// Os templates ficam em <dir>/<fase>/<tipo>.tmpl, por exemplo
+// prompts/files/file_prompt.tmpl. A primeira linha marca a versão:
+//
+//	{{/* version: 3 */ -}}
+//
+// Sem a marca, a versão é derivada do conteúdo do arquivo.
+const extension = ".tmpl"
+
+var versionTag = regexp.MustCompile(`^\{\{-?\s*/\*\s*version:\s*([^\s*]+)\s*\*/\s*-?\}\}`)
+
+// Prompt é um prompt montado, com o template e a versão que o geraram
+type Prompt struct {
+	Name    string
+	Version string
+	Text    string
+}
+
+// Template é um template de prompt carregado do diretório
+type Template struct {
+	Name    string
+	Version string
+	Path    string
+	tmpl    *template.Template
+}
+
+func (t *Template) Execute(data interface{}) (string, error) {
+	var sb strings.Builder
+	if err := t.tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("error rendering prompt %s (version %s): %v", t.Name, t.Version, err)
+	}
+	return sb.String(), nil
+}
+
+// CheckFunc valida um template antes que ele substitua a versão em uso
+type CheckFunc func(name string, tmpl *template.Template) error
+
+// Store mantém os templates do diretório e os recarrega quando os arquivos mudam.
+// Se um arquivo alterado não for válido, o conjunto anterior continua em uso.
+type Store struct {
+	dir   string
+	check CheckFunc
+
+	mu          sync.RWMutex
+	templates   map[string]*Template
+	fingerprint string
+}
+
+func Open(dir string, check CheckFunc) (*Store, error) {
+	s := &Store{dir: dir, check: check, templates: make(map[string]*Template)}
+	if _, err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Lookup retorna o template pelo nome "fase/tipo"
+func (s *Store) Lookup(name string) (*Template, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	t, ok := s.templates[name]
+	return t, ok
+}
+
+// List retorna os templates carregados, ordenados pelo nome
+func (s *Store) List() []*Template {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	list := make([]*Template, 0, len(s.templates))
+	for _, t := range s.templates {
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Reload lê o diretório de novo se algum arquivo mudou. Retorna true quando
+// um novo conjunto de templates passou a valer.
+func (s *Store) Reload() (bool, error) {
+	fingerprint, err := s.scan()
+	if err != nil {
+		return false, err
+	}
+	s.mu.RLock()
+	unchanged := fingerprint == s.fingerprint
+	s.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	templates, err := s.load()
+	if err != nil {
+		return false, err
+	}
+
+	s.mu.Lock()
+	s.templates = templates
+	s.fingerprint = fingerprint
+	s.mu.Unlock()
+	return true, nil
+}
+
+// Watch verifica o diretório a cada intervalo até que stop seja fechado
+func (s *Store) Watch(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			reloaded, err := s.Reload()
+			if err != nil {
+				log.Printf("Error reloading prompt templates, keeping the current ones: %v", err)
+			} else if reloaded {
+				log.Printf("Reloaded %d prompt templates from %s", len(s.List()), s.dir)
+			}
+		}
+	}
+}
+
+// scan calcula uma impressão digital dos arquivos a partir de nome, tamanho e data
+func (s *Store) scan() (string, error) {
+	var entries []string
+	err := s.walk(func(name, path string, info fs.FileInfo) error {
+		entries = append(entries, fmt.Sprintf("%s:%d:%d", name, info.Size(), info.ModTime().UnixNano()))
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, "\n"), nil
+}
+
+func (s *Store) load() (map[string]*Template, error) {
+	templates := make(map[string]*Template)
+	err := s.walk(func(name, path string, _ fs.FileInfo) error {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("error reading prompt template %s: %v", path, err)
+		}
+
+		tmpl, err := template.New(name).Parse(string(content))
+		if err != nil {
+			return fmt.Errorf("error parsing prompt template %s: %v", path, err)
+		}
+		if s.check != nil {
+			if err := s.check(name, tmpl); err != nil {
+				return fmt.Errorf("prompt template %s: %v", path, err)
+			}
+		}
+
+		templates[name] = &Template{
+			Name:    name,
+			Version: version(content),
+			Path:    path,
+			tmpl:    tmpl,
+		}
+		return nil
+	})
+	return templates, err
+}
+
+// walk percorre os arquivos .tmpl do diretório com o nome "fase/tipo" de cada um
+func (s *Store) walk(fn func(name, path string, info fs.FileInfo) error) error {
+	return filepath.WalkDir(s.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("error reading prompt directory: %v", err)
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), extension) {
+			return nil
+		}
+		rel, err := filepath.Rel(s.dir, path)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return fn(strings.TrimSuffix(filepath.ToSlash(rel), extension), path, info)
+	})
+}
+
+// ReloadIntervalFromEnv lê de PROMPTS_RELOAD_INTERVAL o intervalo entre as
+// verificações do diretório de prompts
+func ReloadIntervalFromEnv() (time.Duration, error) {
+	interval := 5 * time.Second
+	if v := os.Getenv("PROMPTS_RELOAD_INTERVAL"); v != "" {
+		parsed, err := time.ParseDuration(v)
+		if err != nil || parsed <= 0 {
+			return interval, fmt.Errorf("invalid PROMPTS_RELOAD_INTERVAL: %q", v)
+		}
+		interval = parsed
+	}
+	return interval, nil
+}
+
+// Seed grava os templates em um diretório vazio ou inexistente, marcados com a
+// versão indicada, para que possam ser editados sem recompilar o servidor
+func Seed(dir string, templates map[string]string, version string) (bool, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("error reading prompt directory: %v", err)
+	}
+	if len(entries) > 0 {
+		return false, nil
+	}
+
+	for name, text := range templates {
+		path := filepath.Join(dir, filepath.FromSlash(name)+extension)
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			return false, fmt.Errorf("error creating prompt directory: %v", err)
+		}
+		content := fmt.Sprintf("{{/* version: %s */ -}}\n%s\n", version, text)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return false, fmt.Errorf("error writing prompt template: %v", err)
+		}
+	}
+	return true, nil
+}
+
+func version(content []byte) string {
+	if match := versionTag.FindSubmatch(content); match != nil {
+		return string(match[1])
+	}
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])[:12]
+}
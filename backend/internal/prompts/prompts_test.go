@@ -0,0 +1,176 @@
+package prompts
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+)
+
+// writeTemplate grava o template e adianta a data de modificação, para que a
+// impressão digital mude mesmo em sistemas de arquivos com pouca resolução
+func writeTemplate(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(name)+extension)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	var modTime time.Time
+	if info, err := os.Stat(path); err == nil {
+		modTime = info.ModTime().Add(time.Second)
+	} else {
+		modTime = time.Now()
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func render(t *testing.T, s *Store, name string) (string, string) {
+	t.Helper()
+	tmpl, ok := s.Lookup(name)
+	if !ok {
+		t.Fatalf("template %s not loaded", name)
+	}
+	text, err := tmpl.Execute(map[string]string{"Name": "app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tmpl.Version, text
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "files/file_prompt", "{{/* version: 1 */ -}}\nGenerate {{.Name}}\n")
+
+	s, err := Open(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version, text := render(t, s, "files/file_prompt"); version != "1" || text != "Generate app\n" {
+		t.Fatalf("loaded version %q = %q", version, text)
+	}
+	if reloaded, err := s.Reload(); err != nil || reloaded {
+		t.Errorf("Reload() without changes = %v, %v", reloaded, err)
+	}
+
+	writeTemplate(t, dir, "files/file_prompt", "{{/* version: 2 */ -}}\nGenerate the files of {{.Name}}\n")
+	if reloaded, err := s.Reload(); err != nil || !reloaded {
+		t.Fatalf("Reload() after an edit = %v, %v", reloaded, err)
+	}
+	if version, text := render(t, s, "files/file_prompt"); version != "2" || text != "Generate the files of app\n" {
+		t.Errorf("edited version %q = %q", version, text)
+	}
+
+	writeTemplate(t, dir, "files/file_prompt", "{{/* version: 3 */ -}}\nGenerate {{.Name\n")
+	if _, err := s.Reload(); err == nil {
+		t.Fatal("Reload() of a broken template error = nil")
+	}
+	if version, text := render(t, s, "files/file_prompt"); version != "2" || text != "Generate the files of app\n" {
+		t.Errorf("broken template replaced the loaded one: version %q = %q", version, text)
+	}
+}
+
+func TestReloadKeepsTemplatesRejectedByCheck(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "structure/start_prompt", "{{/* version: 1 */ -}}\nPropose {{.Name}}\n")
+	writeTemplate(t, dir, "files/file_prompt", "{{/* version: 1 */ -}}\nGenerate {{.Name}}\n")
+
+	check := func(name string, tmpl *template.Template) error {
+		if strings.Contains(tmpl.Root.String(), "Unknown") {
+			return errors.New("unknown field")
+		}
+		return nil
+	}
+	s, err := Open(dir, check)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeTemplate(t, dir, "files/file_prompt", "{{/* version: 2 */ -}}\nGenerate {{.Unknown}}\n")
+	if _, err := s.Reload(); err == nil {
+		t.Fatal("Reload() of a template rejected by the check error = nil")
+	}
+	for _, name := range []string{"files/file_prompt", "structure/start_prompt"} {
+		if version, _ := render(t, s, name); version != "1" {
+			t.Errorf("%s version = %q, want the previous set", name, version)
+		}
+	}
+}
+
+func TestVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"tag", "{{/* version: 3 */ -}}\ntext", "3"},
+		{"tag with trim markers", "{{- /* version: 2024-05-01 */ -}}\ntext", "2024-05-01"},
+		{"tag after the first line is content", "text\n{{/* version: 3 */}}", "sha256:"},
+		{"untagged", "Generate {{.Name}}\n", "sha256:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := version([]byte(tt.content))
+			if tt.want == "sha256:" {
+				if !strings.HasPrefix(got, "sha256:") || len(got) != len("sha256:")+12 {
+					t.Errorf("version() = %q, want a content hash", got)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("version() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if version([]byte("a")) == version([]byte("b")) {
+		t.Error("untagged templates with different content have the same version")
+	}
+}
+
+func TestUntaggedTemplateVersionFollowsContent(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "files/file_prompt", "Generate {{.Name}}\n")
+	s, err := Open(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, _ := render(t, s, "files/file_prompt")
+	if !strings.HasPrefix(before, "sha256:") {
+		t.Fatalf("untagged version = %q", before)
+	}
+
+	writeTemplate(t, dir, "files/file_prompt", "Generate every file of {{.Name}}\n")
+	if _, err := s.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := render(t, s, "files/file_prompt"); after == before || !strings.HasPrefix(after, "sha256:") {
+		t.Errorf("version after the edit = %q, before = %q", after, before)
+	}
+}
+
+func TestSeed(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "prompts")
+	seeded, err := Seed(dir, map[string]string{"files/file_prompt": "Generate {{.Name}}"}, "7")
+	if err != nil || !seeded {
+		t.Fatalf("Seed() into a missing directory = %v, %v", seeded, err)
+	}
+	s, err := Open(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version, text := render(t, s, "files/file_prompt"); version != "7" || text != "Generate app\n" {
+		t.Errorf("seeded version %q = %q", version, text)
+	}
+
+	if seeded, err := Seed(dir, map[string]string{"files/other": "x"}, "8"); err != nil || seeded {
+		t.Errorf("Seed() into a non-empty directory = %v, %v", seeded, err)
+	}
+}
@@ -0,0 +1,269 @@
+package retention
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"backend-ai-sdlc/internal/artifacts"
+	"backend-ai-sdlc/internal/models"
+	"backend-ai-sdlc/internal/storage"
+	"backend-ai-sdlc/internal/structure"
+	"backend-ai-sdlc/internal/workspace"
+)
+
+// Policy define os limites de retenção. Valores zero desativam o limite correspondente.
+type Policy struct {
+	IdleTTL                 time.Duration
+	MaxWorkspaceBytes       int64
+	MaxFilesPerConversation int
+	Interval                time.Duration
+}
+
+// PolicyFromEnv lê a política das variáveis RETENTION_*
+func PolicyFromEnv() (Policy, error) {
+	policy := Policy{Interval: 10 * time.Minute}
+
+	if v := os.Getenv("RETENTION_IDLE_TTL"); v != "" {
+		ttl, err := time.ParseDuration(v)
+		if err != nil {
+			return policy, fmt.Errorf("invalid RETENTION_IDLE_TTL: %v", err)
+		}
+		policy.IdleTTL = ttl
+	}
+	if v := os.Getenv("RETENTION_MAX_WORKSPACE_BYTES"); v != "" {
+		maxBytes, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return policy, fmt.Errorf("invalid RETENTION_MAX_WORKSPACE_BYTES: %v", err)
+		}
+		policy.MaxWorkspaceBytes = maxBytes
+	}
+	if v := os.Getenv("RETENTION_MAX_FILES"); v != "" {
+		maxFiles, err := strconv.Atoi(v)
+		if err != nil {
+			return policy, fmt.Errorf("invalid RETENTION_MAX_FILES: %v", err)
+		}
+		policy.MaxFilesPerConversation = maxFiles
+	}
+	if v := os.Getenv("RETENTION_INTERVAL"); v != "" {
+		interval, err := time.ParseDuration(v)
+		if err != nil {
+			return policy, fmt.Errorf("invalid RETENTION_INTERVAL: %v", err)
+		}
+		if interval <= 0 {
+			return policy, fmt.Errorf("invalid RETENTION_INTERVAL: must be positive")
+		}
+		policy.Interval = interval
+	}
+
+	return policy, nil
+}
+
+// Janitor aplica a política de retenção periodicamente. Conversas fixadas
+// (Pinned) nunca são removidas, e conversas travadas por um passo em execução
+// ficam para a próxima varredura.
+type Janitor struct {
+	policy        Policy
+	store         storage.Storage
+	workspaces    *workspace.Manager
+	artifactStore *artifacts.Store
+	locks         *storage.Locks
+	onRemove      []func(conversationID string)
+}
+
+func NewJanitor(policy Policy, store storage.Storage, workspaces *workspace.Manager, artifactStore *artifacts.Store, locks *storage.Locks) *Janitor {
+	return &Janitor{
+		policy:        policy,
+		store:         store,
+		workspaces:    workspaces,
+		artifactStore: artifactStore,
+		locks:         locks,
+	}
+}
+
+// OnRemove registra uma função chamada para cada conversa removida, para
+// liberar o que outros componentes guardam dela. Deve ser chamado antes de Run.
+func (j *Janitor) OnRemove(fn func(conversationID string)) {
+	j.onRemove = append(j.onRemove, fn)
+}
+
+// Run executa Sweep a cada intervalo até o contexto ser cancelado
+func (j *Janitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.policy.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			j.Sweep()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (j *Janitor) Sweep() {
+	now := time.Now()
+	removed := 0
+
+	// Remove as conversas ociosas há mais tempo que o TTL
+	if j.policy.IdleTTL > 0 {
+		for _, listed := range j.store.ListConversations() {
+			j.withConversation(listed.ID, func(conv *models.Conversation) {
+				if conv.Pinned || now.Sub(conv.UpdatedAt) < j.policy.IdleTTL {
+					return
+				}
+				log.Printf("Janitor: removing conversation %s, idle since %s", conv.ID, conv.UpdatedAt.Format(time.RFC3339))
+				j.removeConversation(conv)
+				removed++
+			})
+		}
+	}
+
+	// Mantém apenas os arquivos mais recentes de cada workspace
+	if j.policy.MaxFilesPerConversation > 0 {
+		for _, listed := range j.store.ListConversations() {
+			j.withConversation(listed.ID, func(conv *models.Conversation) {
+				if !conv.Pinned {
+					j.pruneFiles(conv)
+				}
+			})
+		}
+	}
+
+	// Remove as conversas menos usadas até o total em disco caber no limite
+	if j.policy.MaxWorkspaceBytes > 0 {
+		removed += j.enforceDiskUsage()
+	}
+
+	if removed > 0 && j.artifactStore != nil {
+		result, err := j.artifactStore.GC()
+		if err != nil {
+			log.Printf("Janitor: error collecting artifacts: %v", err)
+		} else {
+			log.Printf("Janitor: artifact GC removed %d blobs (%d bytes)", result.Removed, result.FreedBytes)
+		}
+	}
+}
+
+// withConversation executa fn com a versão atual da conversa, travada. Conversas
+// com um passo em execução são puladas.
+func (j *Janitor) withConversation(conversationID string, fn func(conv *models.Conversation)) {
+	if !j.locks.TryLock(conversationID) {
+		return
+	}
+	defer j.locks.Unlock(conversationID)
+	if conv, exists := j.store.GetConversation(conversationID); exists {
+		fn(conv)
+	}
+}
+
+func (j *Janitor) pruneFiles(conv *models.Conversation) {
+	if conv.Workspace == "" {
+		return
+	}
+
+	files, err := workspace.Files(conv.Workspace)
+	if err != nil {
+		log.Printf("Janitor: error listing workspace of %s: %v", conv.ID, err)
+		return
+	}
+
+	excess := len(files) - j.policy.MaxFilesPerConversation
+	var pruned []string
+	for _, f := range files[:max(excess, 0)] {
+		if err := os.Remove(filepath.Join(conv.Workspace, filepath.FromSlash(f.Path))); err != nil {
+			log.Printf("Janitor: error removing %s from %s: %v", f.Path, conv.ID, err)
+			continue
+		}
+		log.Printf("Janitor: removed %s from conversation %s (file limit %d)", f.Path, conv.ID, j.policy.MaxFilesPerConversation)
+		pruned = append(pruned, f.Path)
+	}
+	if len(pruned) == 0 {
+		return
+	}
+	if j.artifactStore != nil {
+		j.artifactStore.RecordRemoval(conv.ID, pruned)
+	}
+
+	// Os achados e a estrutura não podem continuar apontando para os arquivos removidos
+	for _, p := range pruned {
+		delete(conv.SecurityIssues, p)
+		delete(conv.InvalidFiles, "/"+p)
+	}
+	if conv.Structure != "" {
+		updated, err := structure.RemoveFiles(conv.Structure, pruned)
+		if err != nil {
+			log.Printf("Janitor: error removing pruned files from the structure of %s: %v", conv.ID, err)
+		} else {
+			conv.Structure = updated
+		}
+	}
+	j.store.UpdateConversation(conv)
+}
+
+func (j *Janitor) enforceDiskUsage() int {
+	type entry struct {
+		conv  *models.Conversation
+		usage int64
+	}
+
+	var entries []entry
+	var total int64
+	for _, conv := range j.store.ListConversations() {
+		if conv.Workspace == "" {
+			continue
+		}
+		usage, err := workspace.DiskUsage(conv.Workspace)
+		if err != nil {
+			log.Printf("Janitor: error measuring workspace of %s: %v", conv.ID, err)
+			continue
+		}
+		total += usage
+		if !conv.Pinned {
+			entries = append(entries, entry{conv, usage})
+		}
+	}
+
+	sort.Slice(entries, func(a, b int) bool {
+		return entries[a].conv.UpdatedAt.Before(entries[b].conv.UpdatedAt)
+	})
+
+	removed := 0
+	for _, e := range entries {
+		if total <= j.policy.MaxWorkspaceBytes {
+			break
+		}
+		j.withConversation(e.conv.ID, func(conv *models.Conversation) {
+			if conv.Pinned {
+				return
+			}
+			log.Printf("Janitor: removing conversation %s (%d bytes), workspace usage %d exceeds %d", conv.ID, e.usage, total, j.policy.MaxWorkspaceBytes)
+			j.removeConversation(conv)
+			total -= e.usage
+			removed++
+		})
+	}
+	return removed
+}
+
+func (j *Janitor) removeConversation(conv *models.Conversation) {
+	j.store.DeleteConversation(conv.ID)
+
+	if err := j.workspaces.Remove(conv.ID); err != nil {
+		log.Printf("Janitor: error removing workspace of %s: %v", conv.ID, err)
+	}
+	if j.artifactStore != nil {
+		if err := j.artifactStore.DeleteIndex(conv.ID); err != nil {
+			log.Printf("Janitor: error removing artifact index of %s: %v", conv.ID, err)
+		}
+	}
+	for _, fn := range j.onRemove {
+		fn(conv.ID)
+	}
+}
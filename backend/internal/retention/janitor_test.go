@@ -0,0 +1,134 @@
+package retention
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"backend-ai-sdlc/internal/artifacts"
+	"backend-ai-sdlc/internal/models"
+	"backend-ai-sdlc/internal/storage"
+	"backend-ai-sdlc/internal/workspace"
+)
+
+func TestPolicyFromEnvInterval(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "", want: 10 * time.Minute},
+		{value: "30s", want: 30 * time.Second},
+		{value: "0", wantErr: true},
+		{value: "-1m", wantErr: true},
+		{value: "soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("RETENTION_INTERVAL", tt.value)
+			policy, err := PolicyFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PolicyFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && policy.Interval != tt.want {
+				t.Errorf("Interval = %v, want %v", policy.Interval, tt.want)
+			}
+		})
+	}
+}
+
+func newTestJanitor(t *testing.T, policy Policy) (*Janitor, storage.Storage, *workspace.Manager, *storage.Locks) {
+	t.Helper()
+	workspaces, err := workspace.NewManager(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := storage.NewMemoryStorage()
+	locks := &storage.Locks{}
+	return NewJanitor(policy, store, workspaces, nil, locks), store, workspaces, locks
+}
+
+func TestSweepSkipsLockedConversations(t *testing.T) {
+	janitor, store, workspaces, locks := newTestJanitor(t, Policy{IdleTTL: time.Nanosecond})
+
+	for _, id := range []string{"idle", "running"} {
+		dir, err := workspaces.Path(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		store.UpdateConversation(&models.Conversation{ID: id, Workspace: dir})
+	}
+	time.Sleep(time.Millisecond)
+
+	locks.TryLock("running")
+	janitor.Sweep()
+
+	if _, exists := store.GetConversation("idle"); exists {
+		t.Error("idle conversation was not removed")
+	}
+	if _, exists := store.GetConversation("running"); !exists {
+		t.Error("conversation with a running step was removed")
+	}
+}
+
+func TestPruneFilesDropsStaleEntries(t *testing.T) {
+	janitor, store, workspaces, _ := newTestJanitor(t, Policy{MaxFilesPerConversation: 1})
+
+	dir, err := workspaces.Path("conv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, name := range []string{"app/old.go", "app/new.go"} {
+		full := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte("package app\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		modTime := time.Now().Add(time.Duration(i-2) * time.Hour)
+		if err := os.Chtimes(full, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	conv := &models.Conversation{
+		ID:             "conv",
+		Workspace:      dir,
+		Structure:      `{"app": {"old.go": {}, "new.go": {}}}`,
+		SecurityIssues: map[string][]models.SecurityIssue{"app/old.go": {{Rule: "secret"}}},
+		InvalidFiles:   map[string]string{"/app/old.go": "invalid"},
+	}
+	artifactStore, err := artifacts.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var removed []string
+	artifactStore.OnRemove(func(conversationID string, paths []string) {
+		removed = append(removed, paths...)
+	})
+	janitor.artifactStore = artifactStore
+
+	store.UpdateConversation(conv)
+	janitor.Sweep()
+	conv, _ = store.GetConversation("conv")
+
+	if _, err := os.Stat(filepath.Join(dir, "app", "old.go")); !os.IsNotExist(err) {
+		t.Errorf("oldest file was not pruned: %v", err)
+	}
+	if len(removed) != 1 || removed[0] != "app/old.go" {
+		t.Errorf("removal notified for %v, want [app/old.go]", removed)
+	}
+	if _, ok := conv.SecurityIssues["app/old.go"]; ok {
+		t.Error("security issues of the pruned file were kept")
+	}
+	if _, ok := conv.InvalidFiles["/app/old.go"]; ok {
+		t.Error("validation error of the pruned file was kept")
+	}
+	want := "{\n  \"app\": {\n    \"new.go\": {}\n  }\n}"
+	if conv.Structure != want {
+		t.Errorf("Structure = %s, want %s", conv.Structure, want)
+	}
+}
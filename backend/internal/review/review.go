@@ -0,0 +1,148 @@
+package review
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"backend-ai-sdlc/internal/models"
+)
+
+// ErrNoReplacement indica um achado que só aponta o problema, sem correção para aplicar
+var ErrNoReplacement = errors.New("finding has no replacement to apply")
+
+var severities = map[string]bool{"low": true, "medium": true, "high": true}
+
+var categories = map[string]bool{"bug": true, "security": true, "style": true}
+
+type rawFinding struct {
+	StartLine   int     `json:"start_line"`
+	EndLine     int     `json:"end_line"`
+	Severity    string  `json:"severity"`
+	Category    string  `json:"category"`
+	Message     string  `json:"message"`
+	Replacement *string `json:"replacement"`
+}
+
+// NumberLines prefixa cada linha com o seu número, para o modelo referenciar intervalos
+func NumberLines(content string) string {
+	lines := splitLines(content)
+	var sb strings.Builder
+	for i, line := range lines {
+		fmt.Fprintf(&sb, "%4d | %s\n", i+1, line)
+	}
+	return sb.String()
+}
+
+// ParseFindings lê os achados da resposta do modelo para um arquivo. Achados
+// com intervalo, severidade ou categoria inválidos são descartados.
+func ParseFindings(text, filePath, content string) ([]models.Finding, error) {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no JSON object found")
+	}
+
+	var response struct {
+		Findings []rawFinding `json:"findings"`
+	}
+	if err := json.Unmarshal([]byte(text[start:end+1]), &response); err != nil {
+		return nil, fmt.Errorf("invalid findings: %v", err)
+	}
+
+	lines := splitLines(content)
+	findings := make([]models.Finding, 0, len(response.Findings))
+	for _, raw := range response.Findings {
+		severity := strings.ToLower(raw.Severity)
+		category := strings.ToLower(raw.Category)
+		if raw.EndLine == 0 {
+			raw.EndLine = raw.StartLine
+		}
+		if raw.StartLine < 1 || raw.EndLine < raw.StartLine || raw.EndLine > len(lines) ||
+			!severities[severity] || !categories[category] || raw.Message == "" {
+			continue
+		}
+
+		if raw.Replacement != nil {
+			trimmed := strings.TrimSuffix(*raw.Replacement, "\n")
+			raw.Replacement = &trimmed
+		}
+		findings = append(findings, models.Finding{
+			Path:        filePath,
+			StartLine:   raw.StartLine,
+			EndLine:     raw.EndLine,
+			Severity:    severity,
+			Category:    category,
+			Message:     raw.Message,
+			Original:    strings.Join(lines[raw.StartLine-1:raw.EndLine], "\n"),
+			Replacement: raw.Replacement,
+			Status:      models.FindingOpen,
+		})
+	}
+	return findings, nil
+}
+
+// Apply substitui as linhas do achado pela correção sugerida. Falha se o
+// achado não tem correção ou se as linhas não são mais as que foram revisadas.
+func Apply(content string, finding models.Finding) (string, error) {
+	if finding.Replacement == nil {
+		return "", ErrNoReplacement
+	}
+	lines := splitLines(content)
+	if finding.EndLine > len(lines) || strings.Join(lines[finding.StartLine-1:finding.EndLine], "\n") != finding.Original {
+		return "", fmt.Errorf("lines %d-%d of %s changed since the review", finding.StartLine, finding.EndLine, finding.Path)
+	}
+
+	var replacement []string
+	if *finding.Replacement != "" {
+		replacement = strings.Split(*finding.Replacement, "\n")
+	}
+
+	updated := make([]string, 0, len(lines)-(finding.EndLine-finding.StartLine+1)+len(replacement))
+	updated = append(updated, lines[:finding.StartLine-1]...)
+	updated = append(updated, replacement...)
+	updated = append(updated, lines[finding.EndLine:]...)
+
+	result := strings.Join(updated, "\n")
+	if strings.HasSuffix(content, "\n") {
+		result += "\n"
+	}
+	return result, nil
+}
+
+// Shift ajusta os intervalos dos outros achados abertos do mesmo arquivo
+// depois que a correção do achado aplicado mudou o número de linhas
+func Shift(findings []models.Finding, applied models.Finding) {
+	if applied.Replacement == nil {
+		return
+	}
+	delta := lineCount(*applied.Replacement) - (applied.EndLine - applied.StartLine + 1)
+	if delta == 0 {
+		return
+	}
+	for i := range findings {
+		f := &findings[i]
+		if f.ID == applied.ID || f.Path != applied.Path || f.Status != models.FindingOpen {
+			continue
+		}
+		if f.StartLine > applied.EndLine {
+			f.StartLine += delta
+			f.EndLine += delta
+		}
+	}
+}
+
+func lineCount(s string) int {
+	if s == "" {
+		return 0
+	}
+	return strings.Count(s, "\n") + 1
+}
+
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
@@ -0,0 +1,135 @@
+package review
+
+import (
+	"testing"
+
+	"backend-ai-sdlc/internal/models"
+)
+
+func TestShift(t *testing.T) {
+	applied := models.Finding{ID: "F-1", Path: "/main.go", StartLine: 5, EndLine: 6}
+	tests := []struct {
+		name        string
+		replacement string
+		finding     models.Finding
+		wantStart   int
+		wantEnd     int
+	}{
+		{"later finding moves down", "a\nb\nc\nd", models.Finding{ID: "F-2", Path: "/main.go", StartLine: 10, EndLine: 12}, 12, 14},
+		{"later finding moves up", "a", models.Finding{ID: "F-2", Path: "/main.go", StartLine: 10, EndLine: 12}, 9, 11},
+		{"deleted lines", "", models.Finding{ID: "F-2", Path: "/main.go", StartLine: 7, EndLine: 7}, 5, 5},
+		{"same line count", "a\nb", models.Finding{ID: "F-2", Path: "/main.go", StartLine: 10, EndLine: 12}, 10, 12},
+		{"earlier finding stays", "a\nb\nc", models.Finding{ID: "F-2", Path: "/main.go", StartLine: 1, EndLine: 4}, 1, 4},
+		{"overlapping finding stays", "a\nb\nc", models.Finding{ID: "F-2", Path: "/main.go", StartLine: 6, EndLine: 8}, 6, 8},
+		{"other file stays", "a\nb\nc", models.Finding{ID: "F-2", Path: "/util.go", StartLine: 10, EndLine: 12}, 10, 12},
+		{"closed finding stays", "a\nb\nc", models.Finding{ID: "F-2", Path: "/main.go", StartLine: 10, EndLine: 12, Status: models.FindingApplied}, 10, 12},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			applied := applied
+			applied.Replacement = &tt.replacement
+			finding := tt.finding
+			if finding.Status == "" {
+				finding.Status = models.FindingOpen
+			}
+			findings := []models.Finding{applied, finding}
+
+			Shift(findings, applied)
+			if findings[1].StartLine != tt.wantStart || findings[1].EndLine != tt.wantEnd {
+				t.Errorf("Shift() range = %d-%d, want %d-%d", findings[1].StartLine, findings[1].EndLine, tt.wantStart, tt.wantEnd)
+			}
+			if findings[0].StartLine != 5 || findings[0].EndLine != 6 {
+				t.Errorf("Shift() moved the applied finding to %d-%d", findings[0].StartLine, findings[0].EndLine)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	content := "one\ntwo\nthree\nfour\n"
+	tests := []struct {
+		name    string
+		finding models.Finding
+		want    string
+		wantErr bool
+	}{
+		{name: "replace a line", finding: models.Finding{StartLine: 2, EndLine: 2, Original: "two", Replacement: ptr("TWO")}, want: "one\nTWO\nthree\nfour\n"},
+		{name: "replace with more lines", finding: models.Finding{StartLine: 2, EndLine: 3, Original: "two\nthree", Replacement: ptr("2\n2.5\n3")}, want: "one\n2\n2.5\n3\nfour\n"},
+		{name: "delete lines", finding: models.Finding{StartLine: 1, EndLine: 2, Original: "one\ntwo", Replacement: ptr("")}, want: "three\nfour\n"},
+		{name: "no replacement", finding: models.Finding{StartLine: 1, EndLine: 2, Original: "one\ntwo"}, wantErr: true},
+		{name: "lines changed since the review", finding: models.Finding{StartLine: 2, EndLine: 2, Original: "deux", Replacement: ptr("TWO")}, wantErr: true},
+		{name: "range past the end", finding: models.Finding{StartLine: 4, EndLine: 5, Original: "four", Replacement: ptr("x")}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply(content, tt.finding)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Apply() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShiftKeepsLaterFindingsApplicable(t *testing.T) {
+	content := "a\nb\nc\nd\ne\n"
+	findings := []models.Finding{
+		{ID: "F-1", Path: "/f", StartLine: 1, EndLine: 1, Original: "a", Replacement: ptr("a1\na2\na3"), Status: models.FindingOpen},
+		{ID: "F-2", Path: "/f", StartLine: 4, EndLine: 4, Original: "d", Replacement: ptr("D"), Status: models.FindingOpen},
+	}
+
+	content, err := Apply(content, findings[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	Shift(findings, findings[0])
+	content, err = Apply(content, findings[1])
+	if err != nil {
+		t.Fatalf("Apply() after Shift() error = %v", err)
+	}
+	if want := "a1\na2\na3\nb\nc\nD\ne\n"; content != want {
+		t.Errorf("content = %q, want %q", content, want)
+	}
+}
+
+func TestParseFindings(t *testing.T) {
+	content := "package main\n\nfunc main() {\n}\n"
+	text := "Here you go:\n" + `{"findings": [
+		{"start_line": 3, "end_line": 4, "severity": "HIGH", "category": "bug", "message": "empty main", "replacement": "func main() {\n\trun()\n}\n"},
+		{"start_line": 1, "severity": "low", "category": "style", "message": "package comment"},
+		{"start_line": 2, "severity": "low", "category": "style", "message": "blank line", "replacement": ""},
+		{"start_line": 4, "end_line": 9, "severity": "low", "category": "style", "message": "past the end"},
+		{"start_line": 2, "end_line": 1, "severity": "low", "category": "style", "message": "reversed"},
+		{"start_line": 1, "severity": "critical", "category": "bug", "message": "unknown severity"},
+		{"start_line": 1, "severity": "low", "category": "perf", "message": "unknown category"},
+		{"start_line": 1, "severity": "low", "category": "bug", "message": ""}
+	]}`
+
+	findings, err := ParseFindings(text, "/main.go", content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 3 {
+		t.Fatalf("ParseFindings() returned %d findings, want 3: %+v", len(findings), findings)
+	}
+	first := findings[0]
+	if first.Severity != "high" || first.Original != "func main() {\n}" || first.Replacement == nil || *first.Replacement != "func main() {\n\trun()\n}" || first.Status != models.FindingOpen {
+		t.Errorf("first finding = %+v", first)
+	}
+	if second := findings[1]; second.EndLine != 1 || second.Original != "package main" || second.Replacement != nil {
+		t.Errorf("single-line finding without a fix = %+v", second)
+	}
+	if third := findings[2]; third.Replacement == nil || *third.Replacement != "" {
+		t.Errorf("deleting finding = %+v", third)
+	}
+
+	if _, err := ParseFindings("no json here", "/main.go", content); err == nil {
+		t.Error("ParseFindings() without JSON error = nil")
+	}
+}
+
+func ptr(s string) *string {
+	return &s
+}
@@ -0,0 +1,187 @@
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"backend-ai-sdlc/internal/models"
+)
+
+// Os templates ficam em templates/<nome>. Arquivos terminados em .tmpl passam
+// pelo text/template; os demais são copiados como estão. Um "_" no início do
+// nome vira ".", para que arquivos como .gitignore não afetem este repositório.
+//
+//go:embed all:templates
+var templatesFS embed.FS
+
+// Template é um esqueleto de projeto para uma combinação de backend e frontend.
+// Entry lista os arquivos de lógica de negócio que o modelo deve gerar e que o
+// boilerplate do template já referencia.
+type Template struct {
+	Name        string
+	Description string
+	Backend     []string
+	Frontend    []string
+	Entry       []string
+}
+
+// Data são as variáveis disponíveis nos arquivos .tmpl
+type Data struct {
+	AppName string
+}
+
+var templates = []Template{
+	{
+		Name:        "go-react",
+		Description: "Go backend using only the standard library, listening on port 8080, with a React frontend built by Vite and served by nginx, which proxies /api to the backend.",
+		Backend:     []string{"go", "golang"},
+		Frontend:    []string{"react"},
+		Entry:       []string{"backend/main.go", "frontend/src/App.jsx"},
+	},
+	{
+		Name:        "node-vue",
+		Description: "Node.js backend using Express and cors, listening on the PORT environment variable (8080), with a Vue 3 frontend built by Vite and served by nginx, which proxies /api to the backend.",
+		Backend:     []string{"node", "node.js", "nodejs", "express"},
+		Frontend:    []string{"vue", "vue.js", "vuejs"},
+		Entry:       []string{"backend/src/index.js", "frontend/src/App.vue"},
+	},
+	{
+		Name:        "python-angular",
+		Description: "Python backend using FastAPI served by uvicorn on port 8080 (app.main:app), with an Angular 18 standalone frontend using HttpClient, served by nginx, which proxies /api to the backend.",
+		Backend:     []string{"python", "fastapi", "flask", "django"},
+		Frontend:    []string{"angular"},
+		Entry:       []string{"backend/app/__init__.py", "backend/app/main.py", "frontend/src/app/app.component.ts"},
+	},
+}
+
+var wordPattern = regexp.MustCompile(`[a-z0-9.+#]+`)
+
+// Names lista os templates disponíveis
+func Names() []string {
+	names := make([]string, len(templates))
+	for i, t := range templates {
+		names[i] = t.Name
+	}
+	return names
+}
+
+func Lookup(name string) (Template, bool) {
+	for _, t := range templates {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Template{}, false
+}
+
+// Match escolhe o template pela stack da arquitetura: o backend e o frontend
+// precisam aparecer nos campos correspondentes. A descrição livre do projeto
+// não é usada, porque palavras comuns como "go" e "react" disparariam um template.
+func Match(stack models.Stack) (Template, bool) {
+	backend, frontend := words(stack.Backend), words(stack.Frontend)
+	for _, t := range templates {
+		if containsAny(backend, t.Backend) && containsAny(frontend, t.Frontend) {
+			return t, true
+		}
+	}
+	return Template{}, false
+}
+
+// Files lista os caminhos dos arquivos do template, relativos à raiz do projeto
+func (t Template) Files() ([]string, error) {
+	var files []string
+	err := t.walk(func(name, _ string) error {
+		files = append(files, name)
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// Render gera o conteúdo de todos os arquivos do template, por caminho
+func (t Template) Render(data Data) (map[string]string, error) {
+	files := make(map[string]string)
+	err := t.walk(func(name, source string) error {
+		content, err := templatesFS.ReadFile(source)
+		if err != nil {
+			return err
+		}
+		if strings.HasSuffix(source, ".tmpl") {
+			tmpl, err := template.New(name).Option("missingkey=error").Parse(string(content))
+			if err != nil {
+				return fmt.Errorf("error parsing template %s: %v", source, err)
+			}
+			var buf bytes.Buffer
+			if err := tmpl.Execute(&buf, data); err != nil {
+				return fmt.Errorf("error rendering template %s: %v", source, err)
+			}
+			content = buf.Bytes()
+		}
+		files[name] = string(content)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// Summary descreve o template para os prompts: a stack, os arquivos que ele já
+// fornece e os arquivos de entrada que o modelo deve criar
+func (t Template) Summary() (string, error) {
+	files, err := t.Files()
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Template %q: %s\n\nFiles provided by the template:\n", t.Name, t.Description)
+	for _, f := range files {
+		fmt.Fprintf(&sb, "- %s\n", f)
+	}
+	sb.WriteString("\nEntry points the template expects, to be written with the business logic:\n")
+	for _, f := range t.Entry {
+		fmt.Fprintf(&sb, "- %s\n", f)
+	}
+	return sb.String(), nil
+}
+
+// walk percorre os arquivos do template com o caminho de destino e o caminho no embed.FS
+func (t Template) walk(fn func(name, source string) error) error {
+	root := path.Join("templates", t.Name)
+	return fs.WalkDir(templatesFS, root, func(source string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		name := strings.TrimPrefix(source, root+"/")
+		dir, base := path.Split(name)
+		if strings.HasPrefix(base, "_") {
+			base = "." + base[1:]
+		}
+		return fn(dir+strings.TrimSuffix(base, ".tmpl"), source)
+	})
+}
+
+func words(text string) map[string]bool {
+	found := make(map[string]bool)
+	for _, word := range wordPattern.FindAllString(strings.ToLower(text), -1) {
+		found[strings.TrimRight(word, ".")] = true
+	}
+	return found
+}
+
+func containsAny(words map[string]bool, keywords []string) bool {
+	for _, keyword := range keywords {
+		if words[keyword] {
+			return true
+		}
+	}
+	return false
+}
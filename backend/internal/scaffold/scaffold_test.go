@@ -0,0 +1,89 @@
+package scaffold
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"backend-ai-sdlc/internal/models"
+)
+
+func TestRenderAllTemplates(t *testing.T) {
+	for _, name := range Names() {
+		t.Run(name, func(t *testing.T) {
+			tmpl, ok := Lookup(name)
+			if !ok {
+				t.Fatalf("Lookup(%q) failed", name)
+			}
+			files, err := tmpl.Render(Data{AppName: "todo-app"})
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+
+			if _, ok := files[".gitignore"]; !ok {
+				t.Error("_gitignore was not renamed to .gitignore")
+			}
+			rendered := make([]string, 0, len(files))
+			for filePath, content := range files {
+				rendered = append(rendered, filePath)
+				if strings.HasSuffix(filePath, ".tmpl") || strings.Contains(filePath, "/_") || strings.HasPrefix(filePath, "_") {
+					t.Errorf("file %s kept its template name", filePath)
+				}
+				if strings.Contains(content, "{{") {
+					t.Errorf("file %s has unrendered template actions", filePath)
+				}
+			}
+			sort.Strings(rendered)
+
+			listed, err := tmpl.Files()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(listed, rendered) {
+				t.Errorf("Files() = %v, Render() wrote %v", listed, rendered)
+			}
+			for _, entry := range tmpl.Entry {
+				if _, ok := files[entry]; ok {
+					t.Errorf("entry point %s is provided by the template", entry)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderUsesAppName(t *testing.T) {
+	tmpl, _ := Lookup("go-react")
+	files, err := tmpl.Render(Data{AppName: "todo-app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(files["backend/go.mod"], "todo-app") {
+		t.Errorf("backend/go.mod does not use the app name:\n%s", files["backend/go.mod"])
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		stack models.Stack
+		want  string
+	}{
+		{"go and react", models.Stack{Backend: "Go (net/http)", Frontend: "React 18 with Vite"}, "go-react"},
+		{"golang", models.Stack{Backend: "Golang", Frontend: "React"}, "go-react"},
+		{"node and vue", models.Stack{Backend: "Node.js + Express", Frontend: "Vue.js 3"}, "node-vue"},
+		{"fastapi and angular", models.Stack{Backend: "FastAPI", Frontend: "Angular 18"}, "python-angular"},
+		{"fields are not mixed", models.Stack{Backend: "React", Frontend: "Go"}, ""},
+		{"unknown frontend", models.Stack{Backend: "Go", Frontend: "Svelte"}, ""},
+		{"words inside other words", models.Stack{Backend: "gopher service", Frontend: "reactive forms"}, ""},
+		{"empty stack", models.Stack{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Match(tt.stack)
+			if ok != (tt.want != "") || got.Name != tt.want {
+				t.Errorf("Match(%+v) = %q, %v, want %q", tt.stack, got.Name, ok, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,283 @@
+package search
+
+import (
+	"html"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"backend-ai-sdlc/internal/models"
+)
+
+// Indexer é alimentado pelos backends de armazenamento com os passos das
+// conversas e o conteúdo dos arquivos gerados
+type Indexer interface {
+	IndexConversation(conv *models.Conversation)
+	IndexFile(conversationID, path string, content []byte)
+	// RemoveFiles tira do índice os arquivos removidos do workspace da conversa
+	RemoveFiles(conversationID string, paths []string)
+	RemoveConversation(conversationID string)
+}
+
+// Searcher responde às consultas de texto
+type Searcher interface {
+	Search(query string, limit int) []Hit
+}
+
+type Hit struct {
+	ConversationID string  `json:"conversation_id"`
+	Step           int     `json:"step,omitempty"`
+	Path           string  `json:"path,omitempty"`
+	Snippet        string  `json:"snippet"`
+	Score          float64 `json:"score"`
+}
+
+// Tamanho aproximado do trecho mostrado em volta do primeiro termo encontrado
+const snippetRadius = 80
+
+type docKey struct {
+	conversationID string
+	step           int
+	path           string
+}
+
+type document struct {
+	key   docKey
+	text  string
+	terms map[string]int
+}
+
+// MemoryIndex é um índice invertido em memória
+type MemoryIndex struct {
+	mu       sync.RWMutex
+	docs     map[docKey]*document
+	postings map[string]map[docKey]bool
+}
+
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{
+		docs:     make(map[docKey]*document),
+		postings: make(map[string]map[docKey]bool),
+	}
+}
+
+// IndexConversation reindexa as entradas e respostas de todos os passos
+func (idx *MemoryIndex) IndexConversation(conv *models.Conversation) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for key := range idx.docs {
+		if key.conversationID == conv.ID && key.path == "" {
+			idx.remove(key)
+		}
+	}
+	for _, step := range conv.Steps {
+		idx.add(docKey{conversationID: conv.ID, step: step.Number}, step.Input+"\n\n"+step.Response)
+	}
+}
+
+func (idx *MemoryIndex) IndexFile(conversationID, path string, content []byte) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	key := docKey{conversationID: conversationID, path: path}
+	idx.remove(key)
+	idx.add(key, string(content))
+}
+
+func (idx *MemoryIndex) RemoveFiles(conversationID string, paths []string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for _, p := range paths {
+		idx.remove(docKey{conversationID: conversationID, path: p})
+	}
+}
+
+func (idx *MemoryIndex) RemoveConversation(conversationID string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for key := range idx.docs {
+		if key.conversationID == conversationID {
+			idx.remove(key)
+		}
+	}
+}
+
+// Search retorna os documentos que contêm todos os termos da consulta,
+// ordenados por TF-IDF
+func (idx *MemoryIndex) Search(query string, limit int) []Hit {
+	terms := tokenize(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	var candidates map[docKey]bool
+	for _, term := range terms {
+		matches := idx.postings[term]
+		if candidates == nil {
+			candidates = make(map[docKey]bool, len(matches))
+			for key := range matches {
+				candidates[key] = true
+			}
+			continue
+		}
+		for key := range candidates {
+			if !matches[key] {
+				delete(candidates, key)
+			}
+		}
+	}
+
+	hits := make([]Hit, 0, len(candidates))
+	for key := range candidates {
+		doc := idx.docs[key]
+		score := 0.0
+		for _, term := range terms {
+			idf := math.Log(1 + float64(len(idx.docs))/float64(len(idx.postings[term])))
+			score += float64(doc.terms[term]) * idf
+		}
+		hits = append(hits, Hit{
+			ConversationID: key.conversationID,
+			Step:           key.step,
+			Path:           key.path,
+			Snippet:        snippet(doc.text, terms),
+			Score:          score,
+		})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if hits[i].ConversationID != hits[j].ConversationID {
+			return hits[i].ConversationID < hits[j].ConversationID
+		}
+		if hits[i].Step != hits[j].Step {
+			return hits[i].Step < hits[j].Step
+		}
+		return hits[i].Path < hits[j].Path
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+func (idx *MemoryIndex) add(key docKey, text string) {
+	doc := &document{key: key, text: text, terms: make(map[string]int)}
+	for _, term := range tokenize(text) {
+		doc.terms[term]++
+	}
+	idx.docs[key] = doc
+
+	for term := range doc.terms {
+		if idx.postings[term] == nil {
+			idx.postings[term] = make(map[docKey]bool)
+		}
+		idx.postings[term][key] = true
+	}
+}
+
+func (idx *MemoryIndex) remove(key docKey) {
+	doc, exists := idx.docs[key]
+	if !exists {
+		return
+	}
+	for term := range doc.terms {
+		delete(idx.postings[term], key)
+		if len(idx.postings[term]) == 0 {
+			delete(idx.postings, term)
+		}
+	}
+	delete(idx.docs, key)
+}
+
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), isSeparator)
+}
+
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+}
+
+// span é a posição em bytes de um token no texto original
+type span struct {
+	start, end int
+}
+
+// matches lista as posições dos tokens do texto que são termos da consulta,
+// com a mesma divisão de tokenize: "log" não marca o meio de "catalog"
+func matches(text string, terms []string) []span {
+	wanted := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		wanted[term] = true
+	}
+
+	var spans []span
+	start := -1
+	for i, r := range text + " " {
+		if !isSeparator(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 && wanted[strings.ToLower(text[start:i])] {
+			spans = append(spans, span{start, i})
+		}
+		start = -1
+	}
+	return spans
+}
+
+// snippet recorta o texto em volta do primeiro termo encontrado e destaca
+// os termos da consulta com <mark>
+func snippet(text string, terms []string) string {
+	pos := 0
+	if spans := matches(text, terms); len(spans) > 0 {
+		pos = spans[0].start
+	}
+
+	start := max(pos-snippetRadius, 0)
+	end := min(pos+snippetRadius, len(text))
+	// Evita cortar caracteres UTF-8 ao meio
+	for start > 0 && !isRuneStart(text[start]) {
+		start--
+	}
+	for end < len(text) && !isRuneStart(text[end]) {
+		end++
+	}
+
+	fragment := strings.Join(strings.Fields(text[start:end]), " ")
+	highlighted := highlight(fragment, terms)
+	if start > 0 {
+		highlighted = "…" + highlighted
+	}
+	if end < len(text) {
+		highlighted += "…"
+	}
+	return highlighted
+}
+
+func highlight(fragment string, terms []string) string {
+	var sb strings.Builder
+	last := 0
+	for _, m := range matches(fragment, terms) {
+		sb.WriteString(html.EscapeString(fragment[last:m.start]))
+		sb.WriteString("<mark>" + html.EscapeString(fragment[m.start:m.end]) + "</mark>")
+		last = m.end
+	}
+	sb.WriteString(html.EscapeString(fragment[last:]))
+	return sb.String()
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
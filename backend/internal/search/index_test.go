@@ -0,0 +1,69 @@
+package search
+
+import (
+	"strings"
+	"testing"
+
+	"backend-ai-sdlc/internal/models"
+)
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name     string
+		fragment string
+		terms    []string
+		want     string
+	}{
+		{"whole token", "open the log file", []string{"log"}, "open the <mark>log</mark> file"},
+		{"token inside a word is not marked", "catalog and logger", []string{"log"}, "catalog and logger"},
+		{"case is ignored", "Login and LOGIN", []string{"login"}, "<mark>Login</mark> and <mark>LOGIN</mark>"},
+		{"several terms", "user_id = user.id", []string{"user_id", "id"}, "<mark>user_id</mark> = user.<mark>id</mark>"},
+		{"html is escaped", "<b>auth</b>", []string{"auth"}, "&lt;b&gt;<mark>auth</mark>&lt;/b&gt;"},
+		{"characters that change size in lower case", "İstanbul ǅemal", []string{"ǆemal"}, "İstanbul <mark>ǅemal</mark>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := highlight(tt.fragment, tt.terms); got != tt.want {
+				t.Errorf("highlight() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchSnippetStartsAtToken(t *testing.T) {
+	idx := NewMemoryIndex()
+	text := "catalog " + strings.Repeat("x ", 100) + "log rotation"
+	idx.IndexFile("conv", "main.go", []byte(text))
+
+	hits := idx.Search("log", 0)
+	if len(hits) != 1 {
+		t.Fatalf("Search() = %+v, want one hit", hits)
+	}
+	if want := "<mark>log</mark> rotation"; !strings.Contains(hits[0].Snippet, want) {
+		t.Errorf("Snippet = %q, want it to contain %q", hits[0].Snippet, want)
+	}
+}
+
+func TestRemoveFiles(t *testing.T) {
+	idx := NewMemoryIndex()
+	idx.IndexConversation(&models.Conversation{ID: "conv", Steps: []models.Step{{Number: 1, Input: "token"}}})
+	idx.IndexFile("conv", "kept.go", []byte("token"))
+	idx.IndexFile("conv", "removed.go", []byte("token"))
+	idx.IndexFile("other", "removed.go", []byte("token"))
+
+	idx.RemoveFiles("conv", []string{"removed.go", "never-indexed.go"})
+
+	got := map[string]bool{}
+	for _, hit := range idx.Search("token", 0) {
+		got[hit.ConversationID+"/"+hit.Path] = true
+	}
+	want := map[string]bool{"conv/": true, "conv/kept.go": true, "other/removed.go": true}
+	if len(got) != len(want) {
+		t.Fatalf("Search() hits = %v, want %v", got, want)
+	}
+	for key := range want {
+		if !got[key] {
+			t.Errorf("Search() hits = %v, missing %s", got, key)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package security
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"backend-ai-sdlc/internal/models"
+)
+
+const (
+	SeverityLow    = "low"
+	SeverityMedium = "medium"
+	SeverityHigh   = "high"
+)
+
+var severityRank = map[string]int{SeverityLow: 1, SeverityMedium: 2, SeverityHigh: 3}
+
+// AtLeast indica se a severidade é igual ou maior que o limite
+func AtLeast(severity, threshold string) bool {
+	return severityRank[severity] >= severityRank[threshold] && severityRank[threshold] > 0
+}
+
+// Policy define a partir de qual severidade o download do projeto é bloqueado.
+// BlockSeverity vazio desativa o bloqueio.
+type Policy struct {
+	BlockSeverity string
+}
+
+// PolicyFromEnv lê a política da variável SECURITY_BLOCK_SEVERITY
+func PolicyFromEnv() (Policy, error) {
+	policy := Policy{BlockSeverity: strings.ToLower(os.Getenv("SECURITY_BLOCK_SEVERITY"))}
+	if policy.BlockSeverity != "" && severityRank[policy.BlockSeverity] == 0 {
+		return policy, fmt.Errorf("invalid SECURITY_BLOCK_SEVERITY %q, expected low, medium or high", policy.BlockSeverity)
+	}
+	return policy, nil
+}
+
+// Blocking retorna os arquivos com achados que bloqueiam o download pela política
+func (p Policy) Blocking(issues map[string][]models.SecurityIssue) []string {
+	if p.BlockSeverity == "" {
+		return nil
+	}
+	var files []string
+	for filePath, fileIssues := range issues {
+		for _, issue := range fileIssues {
+			if AtLeast(issue.Severity, p.BlockSeverity) {
+				files = append(files, filePath)
+				break
+			}
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// Rule é uma regra de padrão inseguro. Applies restringe os tipos de arquivo e
+// Check, quando definido, confirma o match a partir dos grupos capturados e do
+// conteúdo do arquivo (por exemplo, pela entropia).
+type Rule struct {
+	ID       string
+	Severity string
+	Message  string
+	Pattern  *regexp.Regexp
+	Applies  func(filePath string) bool
+	Check    func(groups []string, text string) bool
+	// Secret indica que o match deve ser mascarado no relatório
+	Secret bool
+}
+
+func ext(exts ...string) func(string) bool {
+	return func(filePath string) bool {
+		e := strings.ToLower(path.Ext(filePath))
+		for _, candidate := range exts {
+			if e == candidate {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func isDockerfile(filePath string) bool {
+	base := path.Base(filePath)
+	return base == "Dockerfile" || strings.HasPrefix(base, "Dockerfile.") || strings.HasSuffix(strings.ToLower(base), ".dockerfile")
+}
+
+func isCompose(filePath string) bool {
+	base := strings.ToLower(path.Base(filePath))
+	return (strings.HasPrefix(base, "docker-compose") || strings.HasPrefix(base, "compose.")) &&
+		(strings.HasSuffix(base, ".yml") || strings.HasSuffix(base, ".yaml"))
+}
+
+func anyFile(string) bool { return true }
+
+var (
+	goFiles = ext(".go")
+	jsFiles = ext(".js", ".jsx", ".ts", ".tsx", ".vue", ".mjs", ".cjs")
+	pyFiles = ext(".py")
+)
+
+// Valores que são claramente exemplos ou vêm do ambiente
+var placeholderPattern = regexp.MustCompile(`(?i)^(\$\{.*\}|\$[A-Z_]+|<.*>|.*(your|example|changeme|placeholder|xxxx|dummy|process\.env|os\.getenv|os\.environ).*)$`)
+
+func realSecret(groups []string, _ string) bool {
+	value := groups[len(groups)-1]
+	return !placeholderPattern.MatchString(value) && Entropy(value) >= 3.0
+}
+
+func highEntropy(groups []string, _ string) bool {
+	value := groups[len(groups)-1]
+	return !placeholderPattern.MatchString(value) && Entropy(value) >= 4.5
+}
+
+func notEnvReference(groups []string, _ string) bool {
+	return !placeholderPattern.MatchString(strings.Trim(groups[len(groups)-1], `"'`))
+}
+
+var stagePattern = regexp.MustCompile(`(?im)^\s*FROM\s+\S+\s+AS\s+(\S+)`)
+
+// untaggedImage aceita imagens sem tag ou com :latest, exceto scratch e referências a estágios
+func untaggedImage(groups []string, text string) bool {
+	image := strings.Trim(groups[len(groups)-1], `"'`)
+	if image == "scratch" || strings.Contains(image, "$") || strings.Contains(image, "@sha256:") {
+		return false
+	}
+	for _, stage := range stagePattern.FindAllStringSubmatch(text, -1) {
+		if strings.EqualFold(stage[1], image) {
+			return false
+		}
+	}
+	name := image[strings.LastIndex(image, "/")+1:]
+	return !strings.Contains(name, ":") || strings.HasSuffix(name, ":latest")
+}
+
+// rules é o conjunto de regras embutido no scanner
+var rules = []Rule{
+	// Segredos
+	{ID: "aws-access-key", Severity: SeverityHigh, Message: "AWS access key", Secret: true,
+		Pattern: regexp.MustCompile(`\b((?:AKIA|ASIA)[0-9A-Z]{16})\b`), Applies: anyFile},
+	{ID: "private-key", Severity: SeverityHigh, Message: "Private key", Secret: true,
+		Pattern: regexp.MustCompile(`(-----BEGIN (?:RSA |EC |DSA |OPENSSH |PGP )?PRIVATE KEY-----)`), Applies: anyFile},
+	{ID: "github-token", Severity: SeverityHigh, Message: "GitHub token", Secret: true,
+		Pattern: regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,})\b`), Applies: anyFile},
+	{ID: "slack-token", Severity: SeverityHigh, Message: "Slack token", Secret: true,
+		Pattern: regexp.MustCompile(`\b(xox[abprs]-[A-Za-z0-9-]{10,})\b`), Applies: anyFile},
+	{ID: "stripe-key", Severity: SeverityHigh, Message: "Stripe live key", Secret: true,
+		Pattern: regexp.MustCompile(`\b((?:sk|rk)_live_[A-Za-z0-9]{16,})\b`), Applies: anyFile},
+	{ID: "api-key", Severity: SeverityHigh, Message: "API key", Secret: true,
+		Pattern: regexp.MustCompile(`\b(sk-(?:ant-)?[A-Za-z0-9_-]{32,})\b`), Applies: anyFile},
+	{ID: "jwt", Severity: SeverityMedium, Message: "Hardcoded JSON Web Token", Secret: true,
+		Pattern: regexp.MustCompile(`\b(eyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,})\b`), Applies: anyFile},
+	{ID: "hardcoded-credential", Severity: SeverityHigh, Message: "Hardcoded credential", Secret: true,
+		Pattern: regexp.MustCompile(`(?i)\b[a-z0-9_]*(?:password|passwd|secret|api_?key|apikey|access_?key|auth_?token|private_?key)[a-z0-9_]*["']?\s*(?::=|=|:)\s*["']([^"'\s]{8,})["']`),
+		Applies: anyFile, Check: realSecret},
+	{ID: "credentials-in-url", Severity: SeverityHigh, Message: "Credentials embedded in a connection URL", Secret: true,
+		Pattern: regexp.MustCompile(`[a-z][a-z0-9+.-]*://[^:/\s"'@]+:([^@/\s"']{4,})@`), Applies: anyFile, Check: notEnvReference},
+	{ID: "high-entropy-string", Severity: SeverityMedium, Message: "High-entropy string that may be a secret", Secret: true,
+		Pattern: regexp.MustCompile(`["']([A-Za-z0-9+/=_-]{32,})["']`), Applies: anyFile, Check: highEntropy},
+
+	// Go
+	{ID: "go-websocket-any-origin", Severity: SeverityHigh, Message: "WebSocket upgrader accepts any origin",
+		Pattern: regexp.MustCompile(`CheckOrigin:\s*func\s*\([^)]*\)\s*bool\s*\{\s*return\s+true`), Applies: goFiles},
+	{ID: "go-insecure-tls", Severity: SeverityHigh, Message: "TLS certificate verification is disabled",
+		Pattern: regexp.MustCompile(`InsecureSkipVerify:\s*true`), Applies: goFiles},
+	{ID: "go-cors-any-origin", Severity: SeverityMedium, Message: "CORS allows any origin",
+		Pattern: regexp.MustCompile(`(AllowedOrigins:\s*\[\]string\{\s*"\*"|AllowAllOrigins:\s*true|"Access-Control-Allow-Origin",\s*"\*")`), Applies: goFiles},
+	{ID: "go-sql-concatenation", Severity: SeverityHigh, Message: "SQL query built from string concatenation or formatting",
+		Pattern: regexp.MustCompile(`(?i)(?:\.(?:Query|QueryRow|Exec)(?:Context)?\([^)]*"\s*(?:SELECT|INSERT|UPDATE|DELETE)[^"]*"\s*\+|fmt\.Sprintf\(\s*"(?:SELECT|INSERT|UPDATE|DELETE)\b[^"]*%[sv])`), Applies: goFiles},
+
+	// JavaScript e TypeScript
+	{ID: "js-eval", Severity: SeverityHigh, Message: "Use of eval or new Function",
+		Pattern: regexp.MustCompile(`(?:^|[^.\w])(eval\s*\(|new\s+Function\s*\()`), Applies: jsFiles},
+	{ID: "js-inner-html", Severity: SeverityMedium, Message: "Unsanitized HTML injection",
+		Pattern: regexp.MustCompile(`(dangerouslySetInnerHTML|\.innerHTML\s*=|v-html=)`), Applies: jsFiles},
+	{ID: "js-insecure-tls", Severity: SeverityHigh, Message: "TLS certificate verification is disabled",
+		Pattern: regexp.MustCompile(`(rejectUnauthorized:\s*false|NODE_TLS_REJECT_UNAUTHORIZED\s*=\s*['"]?0)`), Applies: jsFiles},
+	{ID: "js-cors-any-origin", Severity: SeverityMedium, Message: "CORS allows any origin",
+		Pattern: regexp.MustCompile(`(origin:\s*['"]\*['"]|origin:\s*true\b|Access-Control-Allow-Origin['"],\s*['"]\*)`), Applies: jsFiles},
+
+	// Python
+	{ID: "py-eval", Severity: SeverityHigh, Message: "Use of eval or exec",
+		Pattern: regexp.MustCompile(`(?:^|[^.\w])((?:eval|exec)\s*\()`), Applies: pyFiles},
+	{ID: "py-shell", Severity: SeverityHigh, Message: "Subprocess started through the shell",
+		Pattern: regexp.MustCompile(`subprocess\.\w+\([^)]*shell\s*=\s*True`), Applies: pyFiles},
+	{ID: "py-insecure-tls", Severity: SeverityHigh, Message: "TLS certificate verification is disabled",
+		Pattern: regexp.MustCompile(`verify\s*=\s*False`), Applies: pyFiles},
+	{ID: "py-debug", Severity: SeverityMedium, Message: "Debug mode enabled",
+		Pattern: regexp.MustCompile(`(?:debug\s*=\s*True|DEBUG\s*=\s*True)`), Applies: pyFiles},
+	{ID: "py-pickle", Severity: SeverityHigh, Message: "Unsafe deserialization with pickle",
+		Pattern: regexp.MustCompile(`(pickle\.loads?\()`), Applies: pyFiles},
+	{ID: "py-yaml-load", Severity: SeverityHigh, Message: "yaml.load without a safe loader",
+		Pattern: regexp.MustCompile(`yaml\.load\(([^)\n]*)\)`), Applies: pyFiles,
+		Check: func(groups []string, _ string) bool { return !strings.Contains(groups[1], "SafeLoader") }},
+
+	// Dockerfile
+	{ID: "docker-latest-image", Severity: SeverityMedium, Message: "Base image is not pinned to a version",
+		Pattern: regexp.MustCompile(`(?im)^\s*FROM\s+(?:--platform=\S+\s+)?(\S+)`), Applies: isDockerfile, Check: untaggedImage},
+	{ID: "docker-root-user", Severity: SeverityMedium, Message: "Container runs as root",
+		Pattern: regexp.MustCompile(`(?im)^\s*USER\s+(root|0)\s*$`), Applies: isDockerfile},
+	{ID: "docker-secret-env", Severity: SeverityHigh, Message: "Secret baked into the image", Secret: true,
+		Pattern: regexp.MustCompile(`(?im)^\s*(?:ENV|ARG)\s+\w*(?:PASSWORD|SECRET|TOKEN|API_KEY)\w*[= ]\s*["']?([^\s"']+)`), Applies: isDockerfile, Check: notEnvReference},
+	{ID: "docker-pipe-to-shell", Severity: SeverityMedium, Message: "Remote script piped to a shell",
+		Pattern: regexp.MustCompile(`(?i)(?:curl|wget)[^|\n]*\|\s*(?:ba)?sh`), Applies: isDockerfile},
+
+	// docker-compose
+	{ID: "compose-latest-image", Severity: SeverityMedium, Message: "Service image is not pinned to a version",
+		Pattern: regexp.MustCompile(`(?m)^\s*image:\s*(\S+)`), Applies: isCompose, Check: untaggedImage},
+	{ID: "compose-privileged", Severity: SeverityHigh, Message: "Privileged container",
+		Pattern: regexp.MustCompile(`(?m)^\s*privileged:\s*true`), Applies: isCompose},
+	{ID: "compose-host-network", Severity: SeverityMedium, Message: "Container shares the host network",
+		Pattern: regexp.MustCompile(`(?m)^\s*network_mode:\s*["']?host`), Applies: isCompose},
+	{ID: "compose-hardcoded-secret", Severity: SeverityHigh, Message: "Hardcoded secret in service environment", Secret: true,
+		Pattern: regexp.MustCompile(`(?m)^\s*-?\s*["']?\w*(?:PASSWORD|SECRET|TOKEN|API_KEY)\w*["']?\s*[:=]\s*(\S+)`), Applies: isCompose, Check: notEnvReference},
+}
+
+// Scan aplica as regras ao conteúdo do arquivo e retorna os achados por linha
+func Scan(filePath string, content []byte) []models.SecurityIssue {
+	text := string(content)
+	issues := []models.SecurityIssue{}
+	seen := make(map[string]bool)
+	// Uma linha com segredo é reportada uma vez, pela regra mais específica
+	secretLines := make(map[int]bool)
+
+	for _, rule := range rules {
+		if !rule.Applies(filePath) {
+			continue
+		}
+		for _, loc := range rule.Pattern.FindAllStringSubmatchIndex(text, -1) {
+			groups := make([]string, 0, len(loc)/2)
+			for i := 0; i < len(loc); i += 2 {
+				if loc[i] < 0 {
+					groups = append(groups, "")
+					continue
+				}
+				groups = append(groups, text[loc[i]:loc[i+1]])
+			}
+			if rule.Check != nil && !rule.Check(groups, text) {
+				continue
+			}
+
+			line := strings.Count(text[:loc[0]], "\n") + 1
+			key := fmt.Sprintf("%s:%d", rule.ID, line)
+			if seen[key] || (rule.Secret && secretLines[line]) {
+				continue
+			}
+			seen[key] = true
+
+			match := strings.Join(strings.Fields(groups[0]), " ")
+			if rule.Secret {
+				secretLines[line] = true
+				match = mask(groups[len(groups)-1])
+			}
+			issues = append(issues, models.SecurityIssue{
+				Line:     line,
+				Rule:     rule.ID,
+				Severity: rule.Severity,
+				Message:  rule.Message,
+				Match:    match,
+			})
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Line < issues[j].Line })
+	return issues
+}
+
+// Entropy calcula a entropia de Shannon, em bits por caractere
+func Entropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	total := float64(len([]rune(s)))
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / total
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// mask mantém só o início do segredo no relatório
+func mask(secret string) string {
+	if len(secret) <= 4 {
+		return "****"
+	}
+	return secret[:4] + strings.Repeat("*", min(len(secret)-4, 12))
+}
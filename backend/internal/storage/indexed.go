@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"backend-ai-sdlc/internal/models"
+	"backend-ai-sdlc/internal/search"
+)
+
+// IndexedStorage envolve um backend de armazenamento e mantém o índice de
+// busca atualizado a cada alteração das conversas
+type IndexedStorage struct {
+	Storage
+	indexer search.Indexer
+}
+
+func NewIndexedStorage(base Storage, indexer search.Indexer) Storage {
+	for _, conv := range base.ListConversations() {
+		indexer.IndexConversation(conv)
+	}
+	return &IndexedStorage{Storage: base, indexer: indexer}
+}
+
+func (s *IndexedStorage) UpdateConversation(conv *models.Conversation) {
+	s.Storage.UpdateConversation(conv)
+	s.indexer.IndexConversation(conv)
+}
+
+func (s *IndexedStorage) DeleteConversation(id string) {
+	s.Storage.DeleteConversation(id)
+	s.indexer.RemoveConversation(id)
+}
@@ -0,0 +1,31 @@
+package storage
+
+import "sync"
+
+// Locks marca as conversas em uso por um passo ou por uma operação que as
+// altera. Quem não consegue o lock deve desistir em vez de esperar, já que um
+// passo pode levar minutos.
+type Locks struct {
+	mu     sync.Mutex
+	locked map[string]bool
+}
+
+// TryLock trava a conversa e retorna false se ela já está travada
+func (l *Locks) TryLock(conversationID string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.locked == nil {
+		l.locked = make(map[string]bool)
+	}
+	if l.locked[conversationID] {
+		return false
+	}
+	l.locked[conversationID] = true
+	return true
+}
+
+func (l *Locks) Unlock(conversationID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.locked, conversationID)
+}
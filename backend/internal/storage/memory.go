@@ -2,10 +2,13 @@ package storage
 
 import (
 	"sync"
+	"time"
 
 	"backend-ai-sdlc/internal/models"
 )
 
+// MemoryStorage guarda cópias das conversas: quem lê recebe uma cópia própria,
+// e as alterações só passam a valer com UpdateConversation
 type MemoryStorage struct {
 	conversations map[string]*models.Conversation
 	mu            sync.RWMutex
@@ -21,7 +24,10 @@ func (m *MemoryStorage) GetConversation(id string) (*models.Conversation, bool)
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 	conv, exists := m.conversations[id]
-	return conv, exists
+	if !exists {
+		return nil, false
+	}
+	return conv.Clone(), true
 }
 
 func (m *MemoryStorage) GetOrCreateConversation(id string) (*models.Conversation, bool) {
@@ -30,15 +36,39 @@ func (m *MemoryStorage) GetOrCreateConversation(id string) (*models.Conversation
 
 	conv, exists := m.conversations[id]
 	if !exists {
-		conv = &models.Conversation{ID: id}
+		now := time.Now()
+		conv = &models.Conversation{ID: id, CreatedAt: now, UpdatedAt: now}
 		m.conversations[id] = conv
 	}
-	return conv, exists
+	return conv.Clone(), exists
 }
 
 func (m *MemoryStorage) UpdateConversation(conv *models.Conversation) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	m.conversations[conv.ID] = conv
+	now := time.Now()
+	if conv.CreatedAt.IsZero() {
+		conv.CreatedAt = now
+	}
+	conv.UpdatedAt = now
+	m.conversations[conv.ID] = conv.Clone()
+}
+
+func (m *MemoryStorage) ListConversations() []*models.Conversation {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	conversations := make([]*models.Conversation, 0, len(m.conversations))
+	for _, conv := range m.conversations {
+		conversations = append(conversations, conv.Clone())
+	}
+	return conversations
+}
+
+func (m *MemoryStorage) DeleteConversation(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.conversations, id)
 }
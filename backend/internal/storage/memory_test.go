@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"sync"
+	"testing"
+
+	"backend-ai-sdlc/internal/models"
+)
+
+func TestMemoryStorageReturnsCopies(t *testing.T) {
+	store := NewMemoryStorage()
+	conv, exists := store.GetOrCreateConversation("conv")
+	if exists {
+		t.Fatal("GetOrCreateConversation() reported a new conversation as existing")
+	}
+
+	conv.Steps = append(conv.Steps, models.Step{Number: 1, Input: "hello"})
+	conv.SecurityIssues = map[string][]models.SecurityIssue{"main.go": {{Rule: "secret"}}}
+	if stored, _ := store.GetConversation("conv"); len(stored.Steps) != 0 {
+		t.Fatal("changes are visible before UpdateConversation")
+	}
+
+	store.UpdateConversation(conv)
+	conv.Steps[0].Input = "changed"
+	delete(conv.SecurityIssues, "main.go")
+
+	stored, _ := store.GetConversation("conv")
+	if len(stored.Steps) != 1 || stored.Steps[0].Input != "hello" || len(stored.SecurityIssues["main.go"]) != 1 {
+		t.Fatalf("stored conversation shares memory with the caller: %+v", stored)
+	}
+}
+
+func TestMemoryStorageConcurrentAccess(t *testing.T) {
+	store := NewMemoryStorage()
+	conv, _ := store.GetOrCreateConversation("conv")
+	store.UpdateConversation(conv)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			conv.Steps = append(conv.Steps, models.Step{Number: i + 1})
+			conv.Outputs = map[string]string{"files": "output"}
+			store.UpdateConversation(conv)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			if c, ok := store.GetConversation("conv"); ok {
+				c.Pinned = true
+				_ = len(c.Steps) + len(c.Outputs)
+			}
+			for _, c := range store.ListConversations() {
+				_ = c.UpdatedAt
+			}
+		}
+	}()
+	wg.Wait()
+}
+
+func TestLocks(t *testing.T) {
+	var locks Locks
+	if !locks.TryLock("a") {
+		t.Fatal("TryLock() on a free conversation = false")
+	}
+	if locks.TryLock("a") {
+		t.Fatal("TryLock() on a locked conversation = true")
+	}
+	if !locks.TryLock("b") {
+		t.Fatal("TryLock() on another conversation = false")
+	}
+	locks.Unlock("a")
+	if !locks.TryLock("a") {
+		t.Fatal("TryLock() after Unlock() = false")
+	}
+}
@@ -6,4 +6,6 @@ type Storage interface {
 	GetOrCreateConversation(id string) (*models.Conversation, bool)
 	UpdateConversation(conv *models.Conversation)
 	GetConversation(id string) (*models.Conversation, bool)
+	ListConversations() []*models.Conversation
+	DeleteConversation(id string)
 }
@@ -0,0 +1,331 @@
+package structure
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Rename é um arquivo que mudou de nome ou de diretório entre duas versões da estrutura
+type Rename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Diff é a diferença estrutural entre duas versões da estrutura do projeto
+type Diff struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	Renamed []Rename `json:"renamed"`
+}
+
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Renamed) == 0
+}
+
+// Extract retorna o objeto JSON contido na resposta do modelo, ignorando
+// texto ou blocos de código em volta
+func Extract(text string) string {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return text
+	}
+	return text[start : end+1]
+}
+
+// Parse interpreta a estrutura JSON do projeto
+func Parse(text string) (map[string]interface{}, error) {
+	var tree map[string]interface{}
+	if err := json.Unmarshal([]byte(Extract(text)), &tree); err != nil {
+		return nil, fmt.Errorf("error parsing JSON structure: %v", err)
+	}
+	return tree, nil
+}
+
+// Files lista os caminhos de todos os arquivos da estrutura, em ordem
+func Files(tree map[string]interface{}) []string {
+	var files []string
+	var walk func(node map[string]interface{}, dir string)
+	walk = func(node map[string]interface{}, dir string) {
+		for key, value := range node {
+			current := path.Join(dir, key)
+			switch v := value.(type) {
+			case map[string]interface{}:
+				if len(v) == 0 && IsFileName(key) {
+					files = append(files, current)
+				} else {
+					walk(v, current)
+				}
+			case []interface{}:
+				if len(v) == 0 && IsFileName(key) {
+					files = append(files, current)
+					continue
+				}
+				for _, item := range v {
+					switch entry := item.(type) {
+					case string:
+						if IsFileName(entry) {
+							files = append(files, path.Join(current, entry))
+						}
+					case map[string]interface{}:
+						walk(entry, current)
+					}
+				}
+			default:
+				files = append(files, current)
+			}
+		}
+	}
+	walk(tree, "")
+
+	sort.Strings(files)
+	return files
+}
+
+// Root retorna o diretório que envolve todo o projeto, quando a estrutura tem
+// um único diretório no topo, ou "" quando os arquivos começam na raiz
+func Root(tree map[string]interface{}) string {
+	if len(tree) != 1 {
+		return ""
+	}
+	for key, value := range tree {
+		if _, ok := value.(map[string]interface{}); ok && !IsFileName(key) {
+			return key
+		}
+	}
+	return ""
+}
+
+// IsFileName aplica a mesma heurística da geração: nomes com extensão ou
+// arquivos conhecidos sem extensão são arquivos, o resto é diretório
+func IsFileName(name string) bool {
+	switch name {
+	case "Dockerfile", "Makefile", "Procfile", "LICENSE":
+		return true
+	}
+	return strings.Contains(name, ".")
+}
+
+// Compare calcula os arquivos adicionados, removidos e renomeados. Um par
+// removido/adicionado é considerado renomeação quando o nome do arquivo se
+// mantém (arquivo movido) ou quando diretório e extensão se mantêm.
+func Compare(oldFiles, newFiles []string) Diff {
+	oldSet := toSet(oldFiles)
+	newSet := toSet(newFiles)
+
+	var removed, added []string
+	for _, f := range oldFiles {
+		if !newSet[f] {
+			removed = append(removed, f)
+		}
+	}
+	for _, f := range newFiles {
+		if !oldSet[f] {
+			added = append(added, f)
+		}
+	}
+
+	diff := Diff{Added: []string{}, Removed: []string{}, Renamed: []Rename{}}
+	matched := make(map[string]bool)
+
+	for _, sameName := range []bool{true, false} {
+		for _, from := range removed {
+			if matched[from] {
+				continue
+			}
+			// Entre os candidatos, escolhe o nome mais parecido
+			best, bestScore := "", -1
+			for _, to := range added {
+				if matched[to] {
+					continue
+				}
+				if (sameName && path.Base(from) == path.Base(to)) ||
+					(!sameName && path.Dir(from) == path.Dir(to) && path.Ext(from) == path.Ext(to)) {
+					if score := commonPrefix(path.Base(from), path.Base(to)); score > bestScore {
+						best, bestScore = to, score
+					}
+				}
+			}
+			if best != "" {
+				diff.Renamed = append(diff.Renamed, Rename{From: from, To: best})
+				matched[from], matched[best] = true, true
+			}
+		}
+	}
+
+	for _, f := range removed {
+		if !matched[f] {
+			diff.Removed = append(diff.Removed, f)
+		}
+	}
+	for _, f := range added {
+		if !matched[f] {
+			diff.Added = append(diff.Added, f)
+		}
+	}
+	return diff
+}
+
+// CompareJSON compara duas estruturas em JSON
+func CompareJSON(oldText, newText string) (Diff, error) {
+	oldTree, err := Parse(oldText)
+	if err != nil {
+		return Diff{}, err
+	}
+	newTree, err := Parse(newText)
+	if err != nil {
+		return Diff{}, err
+	}
+	return Compare(Files(oldTree), Files(newTree)), nil
+}
+
+func commonPrefix(a, b string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+func toSet(items []string) map[string]bool {
+	set := make(map[string]bool, len(items))
+	for _, item := range items {
+		set[item] = true
+	}
+	return set
+}
+
+// AddFiles inclui os arquivos na estrutura JSON, respeitando a forma de cada
+// diretório: arquivos entram na lista de um diretório em array ou como chave
+// vazia em um diretório em objeto
+func AddFiles(text string, files []string) (string, error) {
+	tree, err := Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	existing := toSet(Files(tree))
+	for _, f := range files {
+		f = strings.TrimPrefix(f, "/")
+		if existing[f] {
+			continue
+		}
+		dir, name := path.Split(f)
+		var segments []string
+		if dir = strings.Trim(dir, "/"); dir != "" {
+			segments = strings.Split(dir, "/")
+		}
+		insertFile(tree, segments, name)
+		existing[f] = true
+	}
+
+	updated, err := json.MarshalIndent(tree, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error encoding JSON structure: %v", err)
+	}
+	return string(updated), nil
+}
+
+func insertFile(node interface{}, segments []string, name string) interface{} {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		if len(segments) == 0 {
+			v[name] = map[string]interface{}{}
+			return v
+		}
+		child, ok := v[segments[0]]
+		if !ok || child == nil {
+			child = map[string]interface{}{}
+		}
+		v[segments[0]] = insertFile(child, segments[1:], name)
+		return v
+	case []interface{}:
+		if len(segments) == 0 {
+			return append(v, name)
+		}
+		// Subdiretórios de um diretório em array aparecem como objetos na lista
+		for _, item := range v {
+			if entry, ok := item.(map[string]interface{}); ok {
+				if _, ok := entry[segments[0]]; ok {
+					insertFile(entry, segments, name)
+					return v
+				}
+			}
+		}
+		return append(v, insertFile(map[string]interface{}{}, segments, name))
+	}
+	return node
+}
+
+// RemoveFiles tira os arquivos da estrutura JSON. Diretórios que ficam vazios
+// são mantidos, como na estrutura proposta.
+func RemoveFiles(text string, files []string) (string, error) {
+	tree, err := Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	for _, f := range files {
+		f = strings.TrimPrefix(f, "/")
+		var segments []string
+		if dir, _ := path.Split(f); strings.Trim(dir, "/") != "" {
+			segments = strings.Split(strings.Trim(dir, "/"), "/")
+		}
+		removeFile(tree, segments, path.Base(f))
+	}
+
+	updated, err := json.MarshalIndent(tree, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error encoding JSON structure: %v", err)
+	}
+	return string(updated), nil
+}
+
+func removeFile(node interface{}, segments []string, name string) interface{} {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		if len(segments) == 0 {
+			if child, ok := v[name]; ok && isEmptyFile(child) {
+				delete(v, name)
+			}
+			return v
+		}
+		if child, ok := v[segments[0]]; ok {
+			v[segments[0]] = removeFile(child, segments[1:], name)
+		}
+		return v
+	case []interface{}:
+		kept := v[:0]
+		for _, item := range v {
+			switch entry := item.(type) {
+			case string:
+				if len(segments) == 0 && entry == name {
+					continue
+				}
+			case map[string]interface{}:
+				if len(segments) == 0 {
+					removeFile(entry, nil, name)
+				} else if _, ok := entry[segments[0]]; ok {
+					removeFile(entry, segments, name)
+				}
+			}
+			kept = append(kept, item)
+		}
+		return kept
+	}
+	return node
+}
+
+// isEmptyFile indica se o valor de uma chave representa um arquivo, e não um diretório com conteúdo
+func isEmptyFile(value interface{}) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return true
+}
@@ -0,0 +1,56 @@
+package structure
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRemoveFiles(t *testing.T) {
+	tests := []struct {
+		name      string
+		structure string
+		remove    []string
+		want      []string
+	}{
+		{
+			name:      "file as an empty object",
+			structure: `{"app": {"main.go": {}, "go.mod": {}}}`,
+			remove:    []string{"/app/main.go"},
+			want:      []string{"app/go.mod"},
+		},
+		{
+			name:      "file in a directory array",
+			structure: `{"app": {"cmd": ["main.go", "util.go"]}}`,
+			remove:    []string{"app/cmd/util.go"},
+			want:      []string{"app/cmd/main.go"},
+		},
+		{
+			name:      "subdirectory inside an array",
+			structure: `{"app": [{"internal": ["a.go", "b.go"]}, "README.md"]}`,
+			remove:    []string{"app/internal/a.go"},
+			want:      []string{"app/README.md", "app/internal/b.go"},
+		},
+		{
+			name:      "missing file",
+			structure: `{"app": {"main.go": {}}}`,
+			remove:    []string{"app/other.go"},
+			want:      []string{"app/main.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated, err := RemoveFiles(tt.structure, tt.remove)
+			if err != nil {
+				t.Fatalf("RemoveFiles() error = %v", err)
+			}
+			tree, err := Parse(updated)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := Files(tree); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Files() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
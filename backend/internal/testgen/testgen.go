@@ -0,0 +1,73 @@
+package testgen
+
+import (
+	"path"
+	"strings"
+)
+
+// Target é um arquivo-fonte e o arquivo de testes gerado para ele
+type Target struct {
+	Source    string `json:"source"`
+	Test      string `json:"test"`
+	Framework string `json:"framework"`
+}
+
+// Pontos de entrada e arquivos de configuração não recebem testes unitários
+var skipped = map[string]bool{
+	"main.go": true, "index.js": true, "index.jsx": true, "index.ts": true, "index.tsx": true,
+	"main.js": true, "main.jsx": true, "main.ts": true, "main.tsx": true,
+	"setupTests.js": true, "reportWebVitals.js": true, "serviceWorker.js": true,
+	"__init__.py": true, "conftest.py": true, "setup.py": true, "manage.py": true, "wsgi.py": true, "asgi.py": true,
+}
+
+// Targets seleciona os arquivos-fonte que recebem testes e calcula o caminho
+// do arquivo de testes de cada um, seguindo a convenção da linguagem
+func Targets(files []string) []Target {
+	existing := make(map[string]bool, len(files))
+	for _, f := range files {
+		existing[f] = true
+	}
+
+	var targets []Target
+	for _, f := range files {
+		target, ok := targetFor(f)
+		if !ok || existing[target.Test] {
+			continue
+		}
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+// IsTest indica se o arquivo já é um arquivo de testes
+func IsTest(filePath string) bool {
+	base := path.Base(filePath)
+	return strings.HasSuffix(base, "_test.go") ||
+		strings.Contains(base, ".test.") || strings.Contains(base, ".spec.") ||
+		(strings.HasPrefix(base, "test_") && strings.HasSuffix(base, ".py")) ||
+		strings.HasSuffix(base, "_test.py") ||
+		strings.Contains("/"+filePath, "/__tests__/")
+}
+
+func targetFor(filePath string) (Target, bool) {
+	base := path.Base(filePath)
+	if skipped[base] || IsTest(filePath) || strings.Contains(base, ".config.") || strings.HasSuffix(base, ".d.ts") {
+		return Target{}, false
+	}
+
+	dir := path.Dir(filePath)
+	ext := path.Ext(base)
+	name := strings.TrimSuffix(base, ext)
+
+	switch ext {
+	case ".go":
+		return Target{filePath, path.Join(dir, name+"_test.go"), "go test"}, true
+	case ".js", ".jsx", ".ts", ".tsx":
+		return Target{filePath, path.Join(dir, name+".test"+ext), "Jest"}, true
+	case ".vue":
+		return Target{filePath, path.Join(dir, name+".test.js"), "Jest with Vue Test Utils"}, true
+	case ".py":
+		return Target{filePath, path.Join(dir, "test_"+base), "pytest"}, true
+	}
+	return Target{}, false
+}
@@ -0,0 +1,209 @@
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"gopkg.in/yaml.v3"
+)
+
+// Validator verifica a sintaxe do conteúdo de um tipo de arquivo
+type Validator func(content []byte) error
+
+// registry guarda os validadores por tipo de arquivo
+var registry = map[string]Validator{
+	"json":       validateJSON,
+	"yaml":       validateYAML,
+	"compose":    validateCompose,
+	"gomod":      validateGoMod,
+	"dockerfile": validateDockerfile,
+}
+
+// Kind retorna o tipo do arquivo no registro, ou "" quando não há validador para ele
+func Kind(filePath string) string {
+	base := path.Base(filePath)
+	lower := strings.ToLower(base)
+	switch {
+	case base == "go.mod":
+		return "gomod"
+	case base == "Dockerfile" || strings.HasPrefix(base, "Dockerfile.") || strings.HasSuffix(lower, ".dockerfile"):
+		return "dockerfile"
+	case strings.HasPrefix(lower, "docker-compose") || strings.HasPrefix(lower, "compose."):
+		if strings.HasSuffix(lower, ".yml") || strings.HasSuffix(lower, ".yaml") {
+			return "compose"
+		}
+	case strings.HasSuffix(lower, ".yml") || strings.HasSuffix(lower, ".yaml"):
+		return "yaml"
+	case strings.HasSuffix(lower, ".json"):
+		// tsconfig e jsconfig aceitam comentários, que não são JSON válido
+		if strings.HasPrefix(lower, "tsconfig") || strings.HasPrefix(lower, "jsconfig") {
+			return ""
+		}
+		return "json"
+	}
+	return ""
+}
+
+// File remove cercas de Markdown e texto em volta do conteúdo gerado e
+// valida o resultado. Arquivos sem validador são devolvidos sem alteração.
+func File(filePath, content string) (string, error) {
+	kind := Kind(filePath)
+	if kind == "" {
+		return content, nil
+	}
+
+	cleaned := StripFences(content)
+	if err := registry[kind]([]byte(cleaned)); err != nil {
+		return cleaned, fmt.Errorf("invalid %s: %v", kind, err)
+	}
+	return cleaned, nil
+}
+
+var fencePattern = regexp.MustCompile("(?m)^[ \t]*(```+|~~~+)[^\n]*$")
+
+// StripFences extrai o conteúdo do primeiro bloco de código cercado, descartando
+// a explicação que o modelo às vezes coloca antes ou depois dele
+func StripFences(content string) string {
+	opening := fencePattern.FindStringIndex(content)
+	if opening == nil {
+		return strings.TrimSpace(content) + "\n"
+	}
+
+	fence := strings.TrimSpace(content[opening[0]:opening[1]])
+	marker := fence[:len(fence)-len(strings.TrimLeft(fence, "`~"))]
+
+	body := content[opening[1]:]
+	body = strings.TrimPrefix(body, "\n")
+
+	// O bloco termina na primeira linha que contém apenas a cerca de abertura
+	lines := strings.Split(body, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) == marker {
+			return strings.Join(lines[:i], "\n") + "\n"
+		}
+	}
+	return strings.TrimRight(body, "\n") + "\n"
+}
+
+func validateJSON(content []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(content, &v); err != nil {
+		if syntaxErr, ok := err.(*json.SyntaxError); ok {
+			line := bytes.Count(content[:syntaxErr.Offset], []byte("\n")) + 1
+			return fmt.Errorf("line %d: %v", line, err)
+		}
+		return err
+	}
+	return nil
+}
+
+func validateYAML(content []byte) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return err
+	}
+	if len(doc.Content) == 0 {
+		return fmt.Errorf("document is empty")
+	}
+	return nil
+}
+
+// validateCompose exige um mapa de services em que cada serviço tem image ou build
+func validateCompose(content []byte) error {
+	if err := validateYAML(content); err != nil {
+		return err
+	}
+
+	var compose struct {
+		Services map[string]map[string]interface{} `yaml:"services"`
+	}
+	if err := yaml.Unmarshal(content, &compose); err != nil {
+		return fmt.Errorf("services: %v", err)
+	}
+	if len(compose.Services) == 0 {
+		return fmt.Errorf("no services defined")
+	}
+	for name, service := range compose.Services {
+		if service["image"] == nil && service["build"] == nil {
+			return fmt.Errorf("service %s has neither image nor build", name)
+		}
+	}
+	return nil
+}
+
+func validateGoMod(content []byte) error {
+	file, err := modfile.Parse("go.mod", content, nil)
+	if err != nil {
+		return err
+	}
+	if file.Module == nil || file.Module.Mod.Path == "" {
+		return fmt.Errorf("missing module directive")
+	}
+	return nil
+}
+
+var dockerInstructions = map[string]bool{
+	"FROM": true, "RUN": true, "CMD": true, "LABEL": true, "MAINTAINER": true, "EXPOSE": true,
+	"ENV": true, "ADD": true, "COPY": true, "ENTRYPOINT": true, "VOLUME": true, "USER": true,
+	"WORKDIR": true, "ARG": true, "ONBUILD": true, "STOPSIGNAL": true, "HEALTHCHECK": true, "SHELL": true,
+}
+
+var heredocPattern = regexp.MustCompile(`<<-?["']?([A-Za-z_][A-Za-z0-9_]*)["']?`)
+
+// validateDockerfile confere que cada instrução é conhecida e que o build começa com FROM
+func validateDockerfile(content []byte) error {
+	lines := strings.Split(string(content), "\n")
+	hasFrom := false
+	first := true
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		lineNumber := i + 1
+		instruction := strings.ToUpper(strings.Fields(line)[0])
+		if !dockerInstructions[instruction] {
+			return fmt.Errorf("line %d: unknown instruction %q", lineNumber, strings.Fields(line)[0])
+		}
+		if first && instruction != "FROM" && instruction != "ARG" {
+			return fmt.Errorf("line %d: the first instruction must be FROM or ARG, got %s", lineNumber, instruction)
+		}
+		first = false
+		if instruction == "FROM" {
+			if len(strings.Fields(line)) < 2 {
+				return fmt.Errorf("line %d: FROM requires an image", lineNumber)
+			}
+			hasFrom = true
+		}
+
+		// Pula as linhas de continuação e o corpo de heredocs
+		var terminator string
+		if match := heredocPattern.FindStringSubmatch(line); match != nil {
+			terminator = match[1]
+		}
+		for strings.HasSuffix(strings.TrimSpace(lines[i]), "\\") && i+1 < len(lines) {
+			i++
+		}
+		if terminator != "" {
+			for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != terminator {
+				i++
+			}
+			if i+1 == len(lines) {
+				return fmt.Errorf("line %d: heredoc %s is not terminated", lineNumber, terminator)
+			}
+			i++
+		}
+	}
+
+	if !hasFrom {
+		return fmt.Errorf("no FROM instruction")
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package validate
+
+import "testing"
+
+func TestKind(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/backend/go.mod", "gomod"},
+		{"/backend/Dockerfile", "dockerfile"},
+		{"/Dockerfile.dev", "dockerfile"},
+		{"/build/api.dockerfile", "dockerfile"},
+		{"/docker-compose.yml", "compose"},
+		{"/compose.yaml", "compose"},
+		{"/.github/workflows/ci.yml", "yaml"},
+		{"/frontend/package.json", "json"},
+		{"/frontend/tsconfig.json", ""},
+		{"/frontend/jsconfig.app.json", ""},
+		{"/backend/main.go", ""},
+		{"/docker-compose.override.txt", ""},
+	}
+	for _, tt := range tests {
+		if got := Kind(tt.path); got != tt.want {
+			t.Errorf("Kind(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestStripFences(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"plain content", "  {\"a\": 1}\n\n", "{\"a\": 1}\n"},
+		{"fenced", "```json\n{\"a\": 1}\n```", "{\"a\": 1}\n"},
+		{"explanation around the block", "Here it is:\n```yaml\nkey: value\n```\nThis sets key.", "key: value\n"},
+		{"tilde fence", "~~~\nFROM alpine\n~~~\n", "FROM alpine\n"},
+		{"longer fence keeps inner fences", "````md\n```go\nx\n```\n````", "```go\nx\n```\n"},
+		{"unterminated fence", "```json\n{\"a\": 1}\n\n", "{\"a\": 1}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StripFences(tt.content); got != tt.want {
+				t.Errorf("StripFences() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		content string
+		want    string
+		wantErr bool
+	}{
+		{name: "file without validator is unchanged", path: "/main.go", content: "```go\npackage main\n```", want: "```go\npackage main\n```"},
+		{name: "valid json", path: "/package.json", content: "```json\n{\"name\": \"app\"}\n```", want: "{\"name\": \"app\"}\n"},
+		{name: "invalid json", path: "/package.json", content: "{\"name\": }", want: "{\"name\": }\n", wantErr: true},
+		{name: "valid yaml", path: "/config.yml", content: "port: 8080", want: "port: 8080\n"},
+		{name: "empty yaml", path: "/config.yml", content: "# only a comment", want: "# only a comment\n", wantErr: true},
+		{name: "valid compose", path: "/docker-compose.yml", content: "services:\n  api:\n    build: ./backend\n", want: "services:\n  api:\n    build: ./backend\n"},
+		{name: "compose service without image or build", path: "/docker-compose.yml", content: "services:\n  api:\n    ports: [\"8080:8080\"]\n", want: "services:\n  api:\n    ports: [\"8080:8080\"]\n", wantErr: true},
+		{name: "compose without services", path: "/docker-compose.yml", content: "version: \"3\"\n", want: "version: \"3\"\n", wantErr: true},
+		{name: "valid go.mod", path: "/go.mod", content: "module app\n\ngo 1.22\n", want: "module app\n\ngo 1.22\n"},
+		{name: "go.mod without module", path: "/go.mod", content: "go 1.22\n", want: "go 1.22\n", wantErr: true},
+		{name: "valid Dockerfile", path: "/Dockerfile", content: "ARG GO=1.22\nFROM golang:${GO}\nRUN go build \\\n  ./...\nCMD [\"app\"]\n", want: "ARG GO=1.22\nFROM golang:${GO}\nRUN go build \\\n  ./...\nCMD [\"app\"]\n"},
+		{name: "Dockerfile heredoc", path: "/Dockerfile", content: "FROM alpine\nRUN <<EOF\necho hi\nEOF\n", want: "FROM alpine\nRUN <<EOF\necho hi\nEOF\n"},
+		{name: "Dockerfile unterminated heredoc", path: "/Dockerfile", content: "FROM alpine\nRUN <<EOF\necho hi\n", want: "FROM alpine\nRUN <<EOF\necho hi\n", wantErr: true},
+		{name: "Dockerfile unknown instruction", path: "/Dockerfile", content: "FROM alpine\nINSTALL curl\n", want: "FROM alpine\nINSTALL curl\n", wantErr: true},
+		{name: "Dockerfile without FROM first", path: "/Dockerfile", content: "RUN echo hi\n", want: "RUN echo hi\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := File(tt.path, tt.content)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("File() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("File() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,561 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Eventos enviados aos assinantes
+const (
+	EventStepCompleted      = "step_completed"
+	EventGenerationFinished = "generation_finished"
+	EventError              = "error"
+)
+
+var Events = []string{EventStepCompleted, EventGenerationFinished, EventError}
+
+// Cabeçalhos de cada entrega. IDHeader traz o id do evento, repetido nas novas
+// tentativas. A assinatura é "sha256=" seguido do HMAC-SHA256, com o segredo do
+// assinante, de "<timestamp>.<corpo>".
+const (
+	EventHeader     = "X-Webhook-Event"
+	IDHeader        = "X-Webhook-ID"
+	TimestampHeader = "X-Webhook-Timestamp"
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+// Status de uma tentativa de entrega
+const (
+	StatusDelivered = "delivered"
+	StatusRetrying  = "retrying"
+	StatusFailed    = "failed"
+)
+
+// Subscriber recebe os eventos de uma conversa ou, sem ConversationID, de todas.
+// Sem Events, recebe todos os eventos.
+type Subscriber struct {
+	ID             string    `json:"id"`
+	URL            string    `json:"url"`
+	Secret         string    `json:"secret,omitempty"`
+	Events         []string  `json:"events,omitempty"`
+	ConversationID string    `json:"conversation_id,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func (s Subscriber) wants(eventType, conversationID string) bool {
+	if s.ConversationID != "" && s.ConversationID != conversationID {
+		return false
+	}
+	if len(s.Events) == 0 {
+		return true
+	}
+	for _, e := range s.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Event é o corpo JSON enviado aos assinantes
+type Event struct {
+	ID             string      `json:"id"`
+	Type           string      `json:"type"`
+	ConversationID string      `json:"conversation_id,omitempty"`
+	CreatedAt      time.Time   `json:"created_at"`
+	Data           interface{} `json:"data,omitempty"`
+}
+
+// Delivery registra uma tentativa de entrega de um evento a um assinante
+type Delivery struct {
+	ID             string    `json:"id"`
+	EventID        string    `json:"event_id"`
+	Event          string    `json:"event"`
+	ConversationID string    `json:"conversation_id,omitempty"`
+	SubscriberID   string    `json:"subscriber_id"`
+	URL            string    `json:"url"`
+	Attempt        int       `json:"attempt"`
+	Status         string    `json:"status"`
+	StatusCode     int       `json:"status_code,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	DurationMs     int64     `json:"duration_ms"`
+	Time           time.Time `json:"time"`
+}
+
+// DeadLetter é um evento que esgotou as tentativas de entrega. Payload é o
+// corpo original, reenviado como está por Redeliver.
+type DeadLetter struct {
+	ID             string          `json:"id"`
+	EventID        string          `json:"event_id"`
+	Event          string          `json:"event"`
+	ConversationID string          `json:"conversation_id,omitempty"`
+	SubscriberID   string          `json:"subscriber_id"`
+	URL            string          `json:"url"`
+	Attempts       int             `json:"attempts"`
+	LastError      string          `json:"last_error"`
+	FailedAt       time.Time       `json:"failed_at"`
+	Payload        json.RawMessage `json:"payload"`
+}
+
+// maxRetryDelay limita o intervalo entre as tentativas, qualquer que seja WEBHOOK_MAX_ATTEMPTS
+const maxRetryDelay = time.Hour
+
+// Options configura as entregas. O intervalo entre as tentativas começa em
+// Backoff e dobra a cada nova falha, até maxRetryDelay. Endereços de loopback, redes privadas e
+// link-local (como o serviço de metadados da nuvem) só recebem entregas com
+// AllowPrivate.
+type Options struct {
+	MaxAttempts  int
+	Backoff      time.Duration
+	Timeout      time.Duration
+	Workers      int
+	QueueSize    int
+	LogSize      int
+	AllowPrivate bool
+}
+
+// OptionsFromEnv lê as opções das variáveis WEBHOOK_*
+func OptionsFromEnv() (Options, error) {
+	opts := Options{
+		MaxAttempts: 5,
+		Backoff:     2 * time.Second,
+		Timeout:     10 * time.Second,
+		Workers:     4,
+		QueueSize:   1000,
+		LogSize:     1000,
+	}
+
+	if v := os.Getenv("WEBHOOK_MAX_ATTEMPTS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return opts, fmt.Errorf("invalid WEBHOOK_MAX_ATTEMPTS: %q", v)
+		}
+		opts.MaxAttempts = n
+	}
+	if v := os.Getenv("WEBHOOK_RETRY_BACKOFF"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return opts, fmt.Errorf("invalid WEBHOOK_RETRY_BACKOFF: %q", v)
+		}
+		opts.Backoff = d
+	}
+	if v := os.Getenv("WEBHOOK_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return opts, fmt.Errorf("invalid WEBHOOK_TIMEOUT: %q", v)
+		}
+		opts.Timeout = d
+	}
+	if v := os.Getenv("WEBHOOK_ALLOW_PRIVATE"); v != "" {
+		allow, err := strconv.ParseBool(v)
+		if err != nil {
+			return opts, fmt.Errorf("invalid WEBHOOK_ALLOW_PRIVATE: %q", v)
+		}
+		opts.AllowPrivate = allow
+	}
+	return opts, nil
+}
+
+// job é a próxima tentativa de entregar um evento a um assinante
+type job struct {
+	subscriber     Subscriber
+	eventID        string
+	eventType      string
+	conversationID string
+	body           []byte
+	attempt        int
+}
+
+// Dispatcher entrega os eventos publicados aos assinantes em segundo plano,
+// com novas tentativas e uma fila de mensagens mortas para as que falharam
+type Dispatcher struct {
+	opts   Options
+	client *http.Client
+	queue  chan job
+
+	mu          sync.Mutex
+	subscribers map[string]Subscriber
+	deliveries  []Delivery
+	dead        []DeadLetter
+}
+
+func NewDispatcher(opts Options) *Dispatcher {
+	client := &http.Client{Timeout: opts.Timeout}
+	if !opts.AllowPrivate {
+		// O endereço é conferido na conexão, depois da resolução do DNS, para
+		// valer também para nomes que resolvem para a rede interna e redirecionamentos
+		dialer := &net.Dialer{Timeout: opts.Timeout, Control: checkDialAddress}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = nil
+		transport.DialContext = dialer.DialContext
+		client.Transport = transport
+	}
+	return &Dispatcher{
+		opts:        opts,
+		client:      client,
+		queue:       make(chan job, opts.QueueSize),
+		subscribers: make(map[string]Subscriber),
+	}
+}
+
+// Run entrega os eventos da fila com Options.Workers workers até o contexto ser cancelado
+func (d *Dispatcher) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < d.opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case j := <-d.queue:
+					d.deliver(ctx, j)
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// Subscribe registra um assinante. Sem Secret, um segredo é gerado e
+// retornado apenas nesta chamada.
+func (d *Dispatcher) Subscribe(sub Subscriber) (Subscriber, error) {
+	u, err := url.Parse(sub.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Subscriber{}, fmt.Errorf("invalid webhook URL %q", sub.URL)
+	}
+	if !d.opts.AllowPrivate && privateHost(u.Hostname()) {
+		return Subscriber{}, fmt.Errorf("webhook URL %q points to a private address", sub.URL)
+	}
+	for _, e := range sub.Events {
+		if !knownEvent(e) {
+			return Subscriber{}, fmt.Errorf("unknown event %q, expected one of %v", e, Events)
+		}
+	}
+	if sub.Secret == "" {
+		sub.Secret = newID(32)
+	}
+	sub.ID = newID(8)
+	sub.CreatedAt = time.Now()
+
+	d.mu.Lock()
+	d.subscribers[sub.ID] = sub
+	d.mu.Unlock()
+	return sub, nil
+}
+
+// Unsubscribe remove o assinante, retornando false se ele não existe
+func (d *Dispatcher) Unsubscribe(id string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.subscribers[id]; !ok {
+		return false
+	}
+	delete(d.subscribers, id)
+	return true
+}
+
+// Subscribers lista os assinantes, sem os segredos. Com conversationID, lista
+// os assinantes que recebem os eventos daquela conversa.
+func (d *Dispatcher) Subscribers(conversationID string) []Subscriber {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	list := make([]Subscriber, 0, len(d.subscribers))
+	for _, sub := range d.subscribers {
+		if conversationID != "" && sub.ConversationID != "" && sub.ConversationID != conversationID {
+			continue
+		}
+		sub.Secret = ""
+		list = append(list, sub)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return list
+}
+
+// Publish envia o evento a todos os assinantes interessados, sem bloquear
+func (d *Dispatcher) Publish(eventType, conversationID string, data interface{}) {
+	event := Event{
+		ID:             newID(8),
+		Type:           eventType,
+		ConversationID: conversationID,
+		CreatedAt:      time.Now(),
+		Data:           data,
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Error encoding webhook event %s: %v", eventType, err)
+		return
+	}
+
+	d.mu.Lock()
+	var targets []Subscriber
+	for _, sub := range d.subscribers {
+		if sub.wants(eventType, conversationID) {
+			targets = append(targets, sub)
+		}
+	}
+	d.mu.Unlock()
+
+	for _, sub := range targets {
+		d.enqueue(job{
+			subscriber:     sub,
+			eventID:        event.ID,
+			eventType:      eventType,
+			conversationID: conversationID,
+			body:           body,
+			attempt:        1,
+		})
+	}
+}
+
+// Deliveries retorna as tentativas de entrega mais recentes primeiro, filtradas
+// pela conversa e pelo assinante quando informados
+func (d *Dispatcher) Deliveries(conversationID, subscriberID string, limit int) []Delivery {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var list []Delivery
+	for i := len(d.deliveries) - 1; i >= 0; i-- {
+		delivery := d.deliveries[i]
+		if (conversationID != "" && delivery.ConversationID != conversationID) || (subscriberID != "" && delivery.SubscriberID != subscriberID) {
+			continue
+		}
+		list = append(list, delivery)
+		if limit > 0 && len(list) == limit {
+			break
+		}
+	}
+	return list
+}
+
+// DeadLetters lista os eventos que não puderam ser entregues
+func (d *Dispatcher) DeadLetters() []DeadLetter {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]DeadLetter(nil), d.dead...)
+}
+
+// Redeliver tira o evento da fila de mensagens mortas e tenta entregá-lo de novo
+func (d *Dispatcher) Redeliver(id string) error {
+	d.mu.Lock()
+	var letter *DeadLetter
+	for i := range d.dead {
+		if d.dead[i].ID == id {
+			l := d.dead[i]
+			letter = &l
+			d.dead = append(d.dead[:i], d.dead[i+1:]...)
+			break
+		}
+	}
+	var sub Subscriber
+	var subscribed bool
+	if letter != nil {
+		sub, subscribed = d.subscribers[letter.SubscriberID]
+	}
+	d.mu.Unlock()
+
+	if letter == nil {
+		return fmt.Errorf("dead letter %q not found", id)
+	}
+	if !subscribed {
+		return fmt.Errorf("subscriber %q no longer exists", letter.SubscriberID)
+	}
+	d.enqueue(job{
+		subscriber:     sub,
+		eventID:        letter.EventID,
+		eventType:      letter.Event,
+		conversationID: letter.ConversationID,
+		body:           letter.Payload,
+		attempt:        1,
+	})
+	return nil
+}
+
+func (d *Dispatcher) enqueue(j job) {
+	select {
+	case d.queue <- j:
+	default:
+		d.bury(j, "delivery queue is full")
+	}
+}
+
+// deliver faz uma tentativa de entrega e agenda a próxima em caso de falha
+func (d *Dispatcher) deliver(ctx context.Context, j job) {
+	start := time.Now()
+	statusCode, err := d.post(ctx, j)
+
+	delivery := Delivery{
+		ID:             newID(8),
+		EventID:        j.eventID,
+		Event:          j.eventType,
+		ConversationID: j.conversationID,
+		SubscriberID:   j.subscriber.ID,
+		URL:            j.subscriber.URL,
+		Attempt:        j.attempt,
+		Status:         StatusDelivered,
+		StatusCode:     statusCode,
+		DurationMs:     time.Since(start).Milliseconds(),
+		Time:           start,
+	}
+	if err != nil {
+		delivery.Error = err.Error()
+		delivery.Status = StatusRetrying
+		if j.attempt >= d.opts.MaxAttempts {
+			delivery.Status = StatusFailed
+		}
+	}
+	d.record(delivery)
+
+	switch delivery.Status {
+	case StatusRetrying:
+		delay := retryDelay(d.opts.Backoff, j.attempt)
+		log.Printf("Webhook %s to %s failed (attempt %d), retrying in %s: %v", j.eventType, j.subscriber.URL, j.attempt, delay, err)
+		j.attempt++
+		time.AfterFunc(delay, func() { d.enqueue(j) })
+	case StatusFailed:
+		log.Printf("Webhook %s to %s failed after %d attempts: %v", j.eventType, j.subscriber.URL, j.attempt, err)
+		d.bury(j, err.Error())
+	}
+}
+
+// retryDelay é o intervalo antes da tentativa seguinte a attempt: Backoff
+// dobrado a cada falha, limitado a maxRetryDelay
+func retryDelay(backoff time.Duration, attempt int) time.Duration {
+	delay := backoff
+	for i := 1; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryDelay)
+}
+
+func (d *Dispatcher) post(ctx context.Context, j job) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, j.subscriber.URL, bytes.NewReader(j.body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, j.eventType)
+	req.Header.Set(IDHeader, j.eventID)
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(j.subscriber.Secret, timestamp, j.body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// record guarda a tentativa no log, descartando as mais antigas além de Options.LogSize
+func (d *Dispatcher) record(delivery Delivery) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.deliveries = append(d.deliveries, delivery)
+	if over := len(d.deliveries) - d.opts.LogSize; over > 0 {
+		d.deliveries = append([]Delivery(nil), d.deliveries[over:]...)
+	}
+}
+
+// bury move o evento para a fila de mensagens mortas, limitada a Options.LogSize
+func (d *Dispatcher) bury(j job, reason string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.dead = append(d.dead, DeadLetter{
+		ID:             newID(8),
+		EventID:        j.eventID,
+		Event:          j.eventType,
+		ConversationID: j.conversationID,
+		SubscriberID:   j.subscriber.ID,
+		URL:            j.subscriber.URL,
+		Attempts:       j.attempt,
+		LastError:      reason,
+		FailedAt:       time.Now(),
+		Payload:        j.body,
+	})
+	if over := len(d.dead) - d.opts.LogSize; over > 0 {
+		d.dead = append([]DeadLetter(nil), d.dead[over:]...)
+	}
+}
+
+// Sign calcula a assinatura enviada em X-Webhook-Signature
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify confere a assinatura de uma entrega, para uso pelos receptores
+func Verify(secret, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// privateHost indica se o host é um nome local ou um IP fora da internet pública
+func privateHost(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && privateIP(ip)
+}
+
+func privateIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified()
+}
+
+// checkDialAddress recusa conexões para endereços privados
+func checkDialAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || privateIP(ip) {
+		return fmt.Errorf("webhook delivery to private address %s is not allowed", host)
+	}
+	return nil
+}
+
+func knownEvent(name string) bool {
+	for _, e := range Events {
+		if e == name {
+			return true
+		}
+	}
+	return false
+}
+
+func newID(size int) string {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("error generating random id: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
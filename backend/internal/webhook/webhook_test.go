@@ -0,0 +1,261 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"type":"step_completed"}`)
+	signature := Sign("secret", "1700000000", body)
+
+	if signature != Sign("secret", "1700000000", body) {
+		t.Fatal("Sign() is not deterministic")
+	}
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		body      []byte
+		want      bool
+	}{
+		{"valid", "secret", "1700000000", body, true},
+		{"wrong secret", "other", "1700000000", body, false},
+		{"replayed timestamp", "secret", "1700000001", body, false},
+		{"tampered body", "secret", "1700000000", []byte(`{"type":"error"}`), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Verify(tt.secret, tt.timestamp, tt.body, signature); got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{4, 8 * time.Second},
+		{13, maxRetryDelay},
+		{64, maxRetryDelay},
+		{1000, maxRetryDelay},
+	}
+	for _, tt := range tests {
+		if got := retryDelay(time.Second, tt.attempt); got != tt.want {
+			t.Errorf("retryDelay(1s, %d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+// receiver é um receptor de webhooks local que falha as primeiras requisições
+type receiver struct {
+	mu       sync.Mutex
+	failures int
+	requests []*http.Request
+	bodies   [][]byte
+	done     chan struct{}
+}
+
+func newReceiver(t *testing.T, failures int) (*receiver, *httptest.Server) {
+	rec := &receiver{failures: failures, done: make(chan struct{}, 16)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rec.mu.Lock()
+		rec.requests = append(rec.requests, r)
+		rec.bodies = append(rec.bodies, body)
+		fail := len(rec.requests) <= rec.failures
+		rec.mu.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		rec.done <- struct{}{}
+	}))
+	t.Cleanup(server.Close)
+	return rec, server
+}
+
+func (r *receiver) wait(t *testing.T, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-r.done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("received %d requests, want %d", i, n)
+		}
+	}
+}
+
+// waitDeliveries espera o dispatcher registrar n tentativas de entrega
+func waitDeliveries(t *testing.T, d *Dispatcher, n int) []Delivery {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		deliveries := d.Deliveries("", "", 0)
+		if len(deliveries) >= n || time.Now().After(deadline) {
+			return deliveries
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func newTestDispatcher(t *testing.T, maxAttempts int) *Dispatcher {
+	d := NewDispatcher(Options{
+		MaxAttempts:  maxAttempts,
+		Backoff:      time.Millisecond,
+		Timeout:      time.Second,
+		Workers:      1,
+		QueueSize:    16,
+		LogSize:      16,
+		AllowPrivate: true,
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go d.Run(ctx)
+	return d
+}
+
+func TestDeliverySigned(t *testing.T) {
+	rec, server := newReceiver(t, 0)
+	d := newTestDispatcher(t, 3)
+	sub, err := d.Subscribe(Subscriber{URL: server.URL, Secret: "secret", ConversationID: "conv"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d.Publish(EventStepCompleted, "other", map[string]int{"step": 1})
+	d.Publish(EventStepCompleted, "conv", map[string]int{"step": 2})
+	rec.wait(t, 1)
+
+	rec.mu.Lock()
+	req, body := rec.requests[0], rec.bodies[0]
+	rec.mu.Unlock()
+
+	if got := req.Header.Get(EventHeader); got != EventStepCompleted {
+		t.Errorf("%s = %q, want %q", EventHeader, got, EventStepCompleted)
+	}
+	timestamp := req.Header.Get(TimestampHeader)
+	if _, err := strconv.ParseInt(timestamp, 10, 64); err != nil {
+		t.Errorf("invalid %s %q", TimestampHeader, timestamp)
+	}
+	if !Verify("secret", timestamp, body, req.Header.Get(SignatureHeader)) {
+		t.Error("signature does not verify")
+	}
+
+	var event Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.ConversationID != "conv" || event.ID != req.Header.Get(IDHeader) {
+		t.Errorf("unexpected event %+v", event)
+	}
+
+	waitDeliveries(t, d, 1)
+	deliveries := d.Deliveries("conv", sub.ID, 0)
+	if len(deliveries) != 1 || deliveries[0].Status != StatusDelivered {
+		t.Errorf("Deliveries() = %+v, want one delivered", deliveries)
+	}
+}
+
+func TestDeliveryRetries(t *testing.T) {
+	rec, server := newReceiver(t, 2)
+	d := newTestDispatcher(t, 5)
+	if _, err := d.Subscribe(Subscriber{URL: server.URL, Secret: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+
+	d.Publish(EventError, "conv", nil)
+	rec.wait(t, 3)
+
+	rec.mu.Lock()
+	ids := []string{rec.requests[0].Header.Get(IDHeader), rec.requests[2].Header.Get(IDHeader)}
+	rec.mu.Unlock()
+	if ids[0] != ids[1] {
+		t.Errorf("retries changed the event id: %v", ids)
+	}
+
+	deliveries := waitDeliveries(t, d, 3)
+	if len(deliveries) != 3 {
+		t.Fatalf("Deliveries() returned %d attempts, want 3", len(deliveries))
+	}
+	wantStatus := []string{StatusDelivered, StatusRetrying, StatusRetrying}
+	for i, delivery := range deliveries {
+		if delivery.Status != wantStatus[i] || delivery.Attempt != 3-i {
+			t.Errorf("delivery %d = attempt %d %s, want attempt %d %s", i, delivery.Attempt, delivery.Status, 3-i, wantStatus[i])
+		}
+	}
+	if len(d.DeadLetters()) != 0 {
+		t.Error("delivered event is in the dead letters")
+	}
+}
+
+func TestDeliveryDeadLetterAndRedeliver(t *testing.T) {
+	rec, server := newReceiver(t, 2)
+	d := newTestDispatcher(t, 2)
+	if _, err := d.Subscribe(Subscriber{URL: server.URL, Secret: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+
+	d.Publish(EventGenerationFinished, "conv", nil)
+	rec.wait(t, 2)
+
+	var letters []DeadLetter
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if letters = d.DeadLetters(); len(letters) > 0 {
+			break
+		}
+	}
+	if len(letters) != 1 || letters[0].Attempts != 2 {
+		t.Fatalf("DeadLetters() = %+v, want one letter after 2 attempts", letters)
+	}
+
+	if err := d.Redeliver(letters[0].ID); err != nil {
+		t.Fatalf("Redeliver() error = %v", err)
+	}
+	rec.wait(t, 1)
+	waitDeliveries(t, d, 3)
+	if len(d.DeadLetters()) != 0 {
+		t.Error("redelivered event is still in the dead letters")
+	}
+}
+
+func TestSubscribeRejectsPrivateAddresses(t *testing.T) {
+	d := NewDispatcher(Options{Timeout: time.Second})
+	for _, u := range []string{
+		"http://localhost:8080/hook",
+		"http://127.0.0.1/hook",
+		"http://10.0.0.5/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[::1]/hook",
+		"ftp://example.com/hook",
+	} {
+		if _, err := d.Subscribe(Subscriber{URL: u}); err == nil {
+			t.Errorf("Subscribe(%q) error = nil, want an error", u)
+		}
+	}
+	if _, err := d.Subscribe(Subscriber{URL: "https://example.com/hook"}); err != nil {
+		t.Errorf("Subscribe() public URL error = %v", err)
+	}
+}
+
+func TestDeliveryBlocksPrivateAddresses(t *testing.T) {
+	_, server := newReceiver(t, 0)
+	d := NewDispatcher(Options{Timeout: time.Second})
+
+	// Um assinante registrado por nome que resolve para a rede interna é barrado na conexão
+	status, err := d.post(context.Background(), job{subscriber: Subscriber{URL: server.URL}, body: []byte("{}")})
+	if err == nil || status != 0 {
+		t.Errorf("post() = %d, %v, want the connection to be refused", status, err)
+	}
+}
@@ -0,0 +1,143 @@
+package workspace
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Manager organiza os projetos gerados em um diretório por conversa
+type Manager struct {
+	Root string
+}
+
+// FileInfo descreve um arquivo do workspace, com o caminho relativo à raiz do projeto
+type FileInfo struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+func NewManager(root string) (*Manager, error) {
+	if err := os.MkdirAll(root, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("error creating workspace root: %v", err)
+	}
+	return &Manager{Root: root}, nil
+}
+
+// NewID gera o ID de uma nova conversa. Os IDs são sempre gerados pelo
+// servidor para que o diretório do workspace tenha um nome seguro.
+func NewID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("error generating conversation id: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
+
+// CheckID recusa IDs que não podem ser usados como nome do diretório do
+// workspace. IDs começando com ponto são recusados porque ".", ".." e ".git"
+// apontariam para a raiz, o diretório pai ou os repositórios dos workspaces.
+func CheckID(conversationID string) error {
+	if conversationID == "" || strings.HasPrefix(conversationID, ".") ||
+		strings.ContainsAny(conversationID, `/\`) || strings.ContainsRune(conversationID, 0) {
+		return fmt.Errorf("invalid conversation id %q", conversationID)
+	}
+	return nil
+}
+
+// Path retorna o diretório do workspace da conversa
+func (m *Manager) Path(conversationID string) (string, error) {
+	if err := CheckID(conversationID); err != nil {
+		return "", err
+	}
+	dir := filepath.Join(m.Root, url.PathEscape(conversationID))
+	if filepath.Dir(dir) != filepath.Clean(m.Root) {
+		return "", fmt.Errorf("workspace of conversation %q is outside of %s", conversationID, m.Root)
+	}
+	return dir, nil
+}
+
+func (m *Manager) Remove(conversationID string) error {
+	dir, err := m.Path(conversationID)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("error removing workspace: %v", err)
+	}
+	if err := os.RemoveAll(GitDir(dir)); err != nil {
+		return fmt.Errorf("error removing workspace repository: %v", err)
+	}
+	return nil
+}
+
+// Join monta o caminho de um arquivo do workspace e recusa caminhos que saem dele
+func Join(dir, rel string) (string, error) {
+	full := filepath.Join(dir, filepath.FromSlash(rel))
+	inside, err := filepath.Rel(dir, full)
+	if err != nil || inside == "." || inside == ".." || strings.HasPrefix(inside, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %q is outside of the workspace", rel)
+	}
+	return full, nil
+}
+
+// GitDir retorna o diretório do repositório git do workspace. Ele fica fora do
+// workspace para não entrar no download, no bundle nem na contagem de arquivos.
+func GitDir(dir string) string {
+	return filepath.Join(filepath.Dir(dir), ".git", filepath.Base(dir))
+}
+
+// Files lista os arquivos do workspace, do mais antigo para o mais recente
+func Files(dir string) ([]FileInfo, error) {
+	var files []FileInfo
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return files, nil
+	}
+
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		relPath, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, FileInfo{
+			Path:    filepath.ToSlash(relPath),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error walking workspace %s: %v", dir, err)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime.Before(files[j].ModTime)
+	})
+	return files, nil
+}
+
+// DiskUsage soma o tamanho de todos os arquivos do diretório
+func DiskUsage(dir string) (int64, error) {
+	files, err := Files(dir)
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, f := range files {
+		total += f.Size
+	}
+	return total, nil
+}
@@ -0,0 +1,93 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJoin(t *testing.T) {
+	dir := filepath.Join("root", "conv")
+	tests := []struct {
+		rel     string
+		want    string
+		wantErr bool
+	}{
+		{rel: "main.go", want: filepath.Join(dir, "main.go")},
+		{rel: "src/a..b.go", want: filepath.Join(dir, "src", "a..b.go")},
+		{rel: "/src/main.go", want: filepath.Join(dir, "src", "main.go")},
+		{rel: "", wantErr: true},
+		{rel: ".", wantErr: true},
+		{rel: "..", wantErr: true},
+		{rel: "../other/x", wantErr: true},
+		{rel: "src/../../x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rel, func(t *testing.T) {
+			got, err := Join(dir, tt.rel)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Join() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Join() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckID(t *testing.T) {
+	tests := []struct {
+		id      string
+		wantErr bool
+	}{
+		{id: NewID()},
+		{id: "conv-1"},
+		{id: "", wantErr: true},
+		{id: ".", wantErr: true},
+		{id: "..", wantErr: true},
+		{id: ".git", wantErr: true},
+		{id: "a/b", wantErr: true},
+		{id: `a\b`, wantErr: true},
+		{id: "../root", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			if err := CheckID(tt.id); (err != nil) != tt.wantErr {
+				t.Errorf("CheckID(%q) error = %v, wantErr %v", tt.id, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRemoveRejectsInvalidID(t *testing.T) {
+	root := t.TempDir()
+	m, err := NewManager(filepath.Join(root, "workspaces"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keep := filepath.Join(m.Root, "keep", "main.go")
+	if err := os.MkdirAll(filepath.Dir(keep), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keep, []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"", ".", ".."} {
+		if err := m.Remove(id); err == nil {
+			t.Errorf("Remove(%q) error = nil, want an error", id)
+		}
+	}
+	if _, err := os.Stat(keep); err != nil {
+		t.Fatalf("workspace file was removed: %v", err)
+	}
+
+	if err := m.Remove("keep"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Dir(keep)); !os.IsNotExist(err) {
+		t.Fatalf("workspace still exists: %v", err)
+	}
+}
@@ -5,9 +5,9 @@ import { Input } from './ui/input';
 import { Button } from './ui/button';
 import { Select, SelectContent, SelectItem, SelectTrigger, SelectValue } from './ui/select';
 
-const DownloadButton = ({ projectName }) => {
+const DownloadButton = ({ projectName, conversationID }) => {
   const handleDownload = () => {
-    const downloadUrl = `http://localhost:8080/downloadProject?project=${encodeURIComponent(projectName)}`;
+    const downloadUrl = `http://localhost:8080/downloadProject?conversation_id=${encodeURIComponent(conversationID)}&project=${encodeURIComponent(projectName)}`;
     window.open(downloadUrl, '_blank');
   };
 
@@ -37,7 +37,7 @@ const JsonCode = ({ json }) => {
     );
   };
 
-  const ChatMessage = ({ message, isUser, step, isDownloadButton, projectName }) => {
+  const ChatMessage = ({ message, isUser, step, isDownloadButton, projectName, conversationID }) => {
 
       const isJson = typeof message === 'string' && message.trim().startsWith('{') && message.trim().endsWith('}');
 
@@ -47,7 +47,7 @@ const JsonCode = ({ json }) => {
             <div className="rounded-lg p-3 bg-white dark:bg-gray-700 text-gray-800 dark:text-white shadow-md">
               <div className="text-xs mb-1">{`Step ${step}`}</div>
               <p className="mb-2">Your project is ready for download!</p>
-              <DownloadButton projectName={projectName} />
+              <DownloadButton projectName={projectName} conversationID={conversationID} />
             </div>
           </div>
         );
@@ -131,6 +131,9 @@ const ChatApp = () => {
   const messagesEndRef = useRef(null);
   const socketRef = useRef(null);
   const reconnectTimeoutRef = useRef(null);
+  // Último evento recebido da conversa, usado para retomar os eventos após uma reconexão
+  const lastSeqRef = useRef(0);
+  const conversationIDRef = useRef(null);
   const [progress, setProgress] = useState({ percentage: 0, message: '' });
   const [projectName, setProjectName] = useState('');
 
@@ -148,11 +151,22 @@ const ChatApp = () => {
     socketRef.current.onopen = () => {
       console.log('WebSocket connected');
       setConnectionStatus('Connected');
+      if (lastSeqRef.current > 0) {
+        console.log('Resuming events after', lastSeqRef.current);
+        socketRef.current.send(JSON.stringify({
+          type: 'resume',
+          conversation_id: conversationIDRef.current,
+          last_seq: lastSeqRef.current
+        }));
+      }
     };
 
     socketRef.current.onmessage = (event) => {
       const data = JSON.parse(event.data);
       console.log('Received message:', data);
+      if (data.seq) {
+        lastSeqRef.current = data.seq;
+      }
       handleIncomingMessage(data);
     };
 
@@ -169,6 +183,10 @@ const ChatApp = () => {
     };
   }, []);
 
+  useEffect(() => {
+    conversationIDRef.current = conversationID;
+  }, [conversationID]);
+
   useEffect(() => {
     connectWebSocket();
 
@@ -383,8 +401,7 @@ const ChatApp = () => {
 
     setSelectedFile(filePath);
 
-    const projectName = "chat-app-maker";
-    const url = `http://localhost:8080/readFile?path=${encodeURIComponent(filePath)}&project=${encodeURIComponent(projectName)}`;
+    const url = `http://localhost:8080/readFile?path=${encodeURIComponent(filePath)}&conversation_id=${encodeURIComponent(conversationID)}`;
 
     console.log(`Requesting file content from: ${url}`);
 
@@ -482,6 +499,7 @@ const ChatApp = () => {
                     step={msg.step}
                     isDownloadButton={msg.isDownloadButton}
                     projectName={projectName}
+                    conversationID={conversationID}
                   />
                 ))}
                 {isLoading && (